@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -15,7 +16,9 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 
+	"github.com/skycoin/cxo/data"
 	"github.com/skycoin/cxo/node"
+	"github.com/skycoin/cxo/skyobject"
 )
 
 // defaults
@@ -38,6 +41,21 @@ var (
 		"unsubscribe_from",
 		"feeds",
 		"stat",
+		"progress",
+		"quota",
+		"set_quota",
+		"backup",
+		"fsck",
+		"gc",
+		"registries",
+		"registry",
+		"schema",
+		"del_registry",
+		"export",
+		"import",
+		"summary",
+		"bundle",
+		"import_bundle",
 		"connections",
 		"incoming_connections",
 		"outgoing_connections",
@@ -46,6 +64,7 @@ var (
 		"listening_address",
 		"roots",
 		"tree",
+		"import_tree",
 		"terminate",
 		"quit",
 		"exit",
@@ -233,6 +252,36 @@ func executeCommand(command string, rpc *node.RPCClient) (terminate bool,
 		err = feeds(rpc)
 	case "stat":
 		err = stat(rpc)
+	case "progress":
+		err = progress(rpc)
+	case "quota":
+		err = quota(rpc, ss)
+	case "set_quota":
+		err = setQuota(rpc, ss)
+	case "backup":
+		err = backup(rpc, ss)
+	case "fsck":
+		err = fsck(rpc, ss)
+	case "gc":
+		err = gc(rpc, ss)
+	case "registries":
+		err = registries(rpc)
+	case "registry":
+		err = registry(rpc, ss)
+	case "schema":
+		err = schema(rpc, ss)
+	case "del_registry":
+		err = delRegistry(rpc, ss)
+	case "export":
+		err = export(rpc, ss)
+	case "import":
+		err = importArchive(rpc, ss)
+	case "summary":
+		err = summary(rpc, ss)
+	case "bundle":
+		err = bundle(rpc, ss)
+	case "import_bundle":
+		err = importBundle(rpc, ss)
 	case "connections":
 		err = connections(rpc)
 	case "incoming_connections":
@@ -249,6 +298,8 @@ func executeCommand(command string, rpc *node.RPCClient) (terminate bool,
 		err = roots(rpc, ss)
 	case "tree":
 		err = tree(rpc, ss)
+	case "import_tree":
+		err = importTree(rpc, ss)
 	case "terminate":
 		err = term(rpc)
 	// help and exit
@@ -278,6 +329,42 @@ func showHelp() {
     list feeds
   stat
     statistic
+  progress
+    show progress of filling root objects
+  quota <public key>
+    show storage quota of feed
+  set_quota <public key> <roots> <bytes>
+    set storage quota of feed (0 = no limit, 0 0 = default quota)
+  backup <file>
+    save backup of database of the node to the file
+  fsck [--repair] [--refetch]
+    check integrity of database of the node; the --repair unmarks
+    non-full root objects and removes corrupt objects, the --refetch
+    fills the unmarked root objects again from connected peers
+  gc [--dry-run] [--keep-roots]
+    remove unused objects and root objects before last full; the
+    --dry-run shows what would be removed without removing, the
+    --keep-roots keeps root objects
+  registries
+    list registries and feeds that use them
+  registry <registry reference>
+    print schemas of the registry
+  schema <registry reference> [--json]
+    print the registry in human readable form or in JSON
+  del_registry <registry reference>
+    remove unused registry
+  export <public key> <file> [from] [to]
+    save archive of full root objects of given feed to the file;
+    the from and to are first and last seq numbers of the roots
+  import <file>
+    load archive created by export command
+  summary <file> [public keys...]
+    save summary of given feeds (or all feeds) to the file
+  bundle <summary file> <file>
+    save roots and objects missing on node that created
+    the summary to the file
+  import_bundle <file>
+    load bundle created by bundle command
   connections
     list connections
   incoming_connections
@@ -290,11 +377,18 @@ func showHelp() {
     disconnect from
   listening_address
     print listening address
-  roots <public key>
-    print brief information about all root objects of given feed
-  tree <pub key> [seq]
+  roots <public key> [seq] [limit]
+    print brief information about all root objects of given feed,
+    or, if the seq given, about limit (default 20) root objects
+    starting from the seq
+  tree <pub key> [seq] [--json] [--inline]
     print root by public key and seq number, if the seq omited then
-    last full root printed
+    last full root printed; the --json prints the tree in JSON and
+    the --inline adds values of all references to the JSON
+  import_tree <pub key> <sec key file> <registry reference> <file>
+    create and publish new root of given feed from JSON
+    printed by tree --json; the sec key file is file with
+    hex-encoded secret key, the file is read by the daemon
   terminate
     terminate server if allowed
   help
@@ -407,20 +501,465 @@ func stat(rpc *node.RPCClient) (err error) {
 	fmt.Fprintln(out, "  ----")
 	fmt.Fprintln(out, "  Objects:", stat.Data.Objects)
 	fmt.Fprintln(out, "  Space:  ", stat.Data.Space.String())
+	if stat.Data.PhysicalSpace != stat.Data.Space {
+		fmt.Fprintln(out, "  On disk:", stat.Data.PhysicalSpace.String())
+	}
+	if cs := stat.Data.Cache; cs != nil {
+		fmt.Fprintln(out, "  ----")
+		fmt.Fprintln(out, "  Cache:  ", cs.Objects, "objects,",
+			cs.Space.String(), "of", cs.MaxSpace.String())
+		fmt.Fprintln(out, "  Hits:   ", cs.Hits)
+		fmt.Fprintln(out, "  Misses: ", cs.Misses)
+	}
 	fmt.Fprintln(out, "  ----")
 	for pk, fs := range stat.Data.Feeds {
 		fmt.Fprintln(out, "  -", pk.Hex())
 		fmt.Fprintln(out, "    Root Objects: ", fs.Roots)
 		fmt.Fprintln(out, "    Space:        ", fs.Space.String())
+		if sp, ok := stat.CXO.Feeds[pk]; ok {
+			fmt.Fprintln(out, "    Objects:      ", sp.Objects)
+			fmt.Fprintln(out, "    Exclusive:    ", sp.Exclusive.String())
+			fmt.Fprintln(out, "    Shared:       ", sp.Shared.String())
+		}
 	}
 	fmt.Fprintln(out, "  ----")
 	fmt.Fprintln(out, "  Registries:    ", stat.CXO.Registries)
 	fmt.Fprintln(out, "  Save    (avg): ", stat.CXO.Save)
 	fmt.Fprintln(out, "  Cleanup (avg): ", stat.CXO.CleanUp)
+	if cp := stat.CXO.CleanUpProgress; cp.Cycles > 0 || cp.Slices > 0 {
+		fmt.Fprintln(out, "  Pause   (avg): ", stat.CXO.CleanUpPause)
+		fmt.Fprintln(out, "  Pause   (max): ", stat.CXO.CleanUpMaxPause)
+		fmt.Fprintf(out, "  Cleanup:        %s, %d cycles, %d marked, "+
+			"%d swept, %d removed\n", cp.Phase, cp.Cycles, cp.Marked,
+			cp.Swept, cp.Removed)
+	}
 	fmt.Fprintln(out, "  ----")
 	return
 }
 
+func progress(rpc *node.RPCClient) (err error) {
+	var stat node.Stat
+	if stat, err = rpc.Stat(); err != nil {
+		return
+	}
+	if len(stat.Filling) == 0 {
+		fmt.Fprintln(out, "  no filling roots")
+		return
+	}
+	for _, fp := range stat.Filling {
+		fmt.Fprintf(out, "  - %s:%d %s\n", fp.Feed.Hex()[:7], fp.Seq,
+			fp.Hash.Hex()[:7])
+		fmt.Fprintf(out, "    [%-20s] %5.1f%%\n",
+			strings.Repeat("#", int(fp.Percent()/5)), fp.Percent())
+		fmt.Fprintf(out, "    objects: %d/%d (local %d, requested %d)\n",
+			fp.Done(), fp.Discovered, fp.Local, fp.Requested)
+		fmt.Fprintln(out, "    received:", fp.Bytes.String())
+	}
+	return
+}
+
+func quota(rpc *node.RPCClient, ss []string) (err error) {
+	var pk cipher.PubKey
+	if pk, err = publicKeyArg(ss); err != nil {
+		return
+	}
+	var q skyobject.Quota
+	if q, err = rpc.Quota(pk); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  Root objects:", q.MaxRoots)
+	fmt.Fprintln(out, "  Space:       ", q.MaxSpace.String())
+	return
+}
+
+func setQuota(rpc *node.RPCClient, ss []string) (err error) {
+	switch {
+	case len(ss) < 4:
+		return errMisisngArgument
+	case len(ss) > 4:
+		return errTooManyArguments
+	}
+	var pk cipher.PubKey
+	if pk, err = cipher.PubKeyFromHex(ss[1]); err != nil {
+		return
+	}
+	var q skyobject.Quota
+	if q.MaxRoots, err = strconv.Atoi(ss[2]); err != nil {
+		return
+	}
+	var space int
+	if space, err = strconv.Atoi(ss[3]); err != nil {
+		return
+	}
+	q.MaxSpace = data.Space(space)
+	if err = rpc.SetQuota(pk, q); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  quota set")
+	return
+}
+
+func backup(rpc *node.RPCClient, ss []string) (err error) {
+	switch {
+	case len(ss) < 2:
+		return errMisisngArgument
+	case len(ss) > 2:
+		return errTooManyArguments
+	}
+	var fl *os.File
+	fl, err = os.OpenFile(ss[1], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return
+	}
+	if err = rpc.Backup(fl); err != nil {
+		fl.Close()
+		os.Remove(ss[1])
+		return
+	}
+	if err = fl.Close(); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  backup saved")
+	return
+}
+
+func export(rpc *node.RPCClient, ss []string) (err error) {
+	switch {
+	case len(ss) < 3:
+		return errMisisngArgument
+	case len(ss) > 5:
+		return errTooManyArguments
+	}
+	var pk cipher.PubKey
+	if pk, err = cipher.PubKeyFromHex(ss[1]); err != nil {
+		return
+	}
+	seqs := skyobject.AllSeqs
+	if len(ss) > 3 {
+		if seqs.From, err = strconv.ParseUint(ss[3], 10, 64); err != nil {
+			return
+		}
+	}
+	if len(ss) > 4 {
+		if seqs.To, err = strconv.ParseUint(ss[4], 10, 64); err != nil {
+			return
+		}
+	}
+	var fl *os.File
+	fl, err = os.OpenFile(ss[2], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return
+	}
+	if err = rpc.Export(fl, pk, seqs); err != nil {
+		fl.Close()
+		os.Remove(ss[2])
+		return
+	}
+	if err = fl.Close(); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  archive saved")
+	return
+}
+
+func importArchive(rpc *node.RPCClient, ss []string) (err error) {
+	switch {
+	case len(ss) < 2:
+		return errMisisngArgument
+	case len(ss) > 2:
+		return errTooManyArguments
+	}
+	var fl *os.File
+	if fl, err = os.Open(ss[1]); err != nil {
+		return
+	}
+	defer fl.Close()
+	var as skyobject.ArchiveStat
+	as, err = rpc.Import(fl)
+	fmt.Fprintln(out, "  imported roots:  ", as.Roots)
+	fmt.Fprintln(out, "  received objects:", as.Objects)
+	for _, pk := range as.Feeds {
+		fmt.Fprintln(out, "  feed:", pk.Hex())
+	}
+	return
+}
+
+func summary(rpc *node.RPCClient, ss []string) (err error) {
+	if len(ss) < 2 {
+		return errMisisngArgument
+	}
+	var feeds []cipher.PubKey
+	for _, arg := range ss[2:] {
+		var pk cipher.PubKey
+		if pk, err = cipher.PubKeyFromHex(arg); err != nil {
+			return
+		}
+		feeds = append(feeds, pk)
+	}
+	var fl *os.File
+	fl, err = os.OpenFile(ss[1], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return
+	}
+	if err = rpc.Summary(fl, feeds...); err != nil {
+		fl.Close()
+		os.Remove(ss[1])
+		return
+	}
+	if err = fl.Close(); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  summary saved")
+	return
+}
+
+func bundle(rpc *node.RPCClient, ss []string) (err error) {
+	switch {
+	case len(ss) < 3:
+		return errMisisngArgument
+	case len(ss) > 3:
+		return errTooManyArguments
+	}
+	var sum *os.File
+	if sum, err = os.Open(ss[1]); err != nil {
+		return
+	}
+	defer sum.Close()
+	var fl *os.File
+	fl, err = os.OpenFile(ss[2], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return
+	}
+	if err = rpc.Bundle(fl, sum); err != nil {
+		fl.Close()
+		os.Remove(ss[2])
+		return
+	}
+	if err = fl.Close(); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  bundle saved")
+	return
+}
+
+func importBundle(rpc *node.RPCClient, ss []string) (err error) {
+	switch {
+	case len(ss) < 2:
+		return errMisisngArgument
+	case len(ss) > 2:
+		return errTooManyArguments
+	}
+	var fl *os.File
+	if fl, err = os.Open(ss[1]); err != nil {
+		return
+	}
+	defer fl.Close()
+	var as skyobject.ArchiveStat
+	as, err = rpc.ImportBundle(fl)
+	fmt.Fprintln(out, "  imported roots:  ", as.Roots)
+	fmt.Fprintln(out, "  received objects:", as.Objects)
+	for _, pk := range as.Feeds {
+		fmt.Fprintln(out, "  feed:", pk.Hex())
+	}
+	for _, ri := range as.NonFull {
+		fmt.Fprintln(out, "  non-full root:", ri.String())
+	}
+	return
+}
+
+func fsck(rpc *node.RPCClient, ss []string) (err error) {
+	var repair, refetch bool
+	for _, arg := range ss[1:] {
+		switch arg {
+		case "--repair":
+			repair = true
+		case "--refetch":
+			refetch = true
+		default:
+			return fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+	if refetch && !repair {
+		return errors.New("--refetch requires --repair")
+	}
+	var reply node.CheckReply
+	if reply, err = rpc.Check(repair, refetch); err != nil {
+		return
+	}
+	rep := reply.Report
+	fmt.Fprintln(out, "  objects:", rep.Objects)
+	fmt.Fprintln(out, "  roots:  ", rep.Roots)
+	for _, hash := range rep.CorruptObjects {
+		fmt.Fprintln(out, "  corrupt object:", hash.Hex())
+	}
+	for _, ri := range rep.InvalidRoots {
+		fmt.Fprintln(out, "  invalid root:", ri.String())
+	}
+	for _, ri := range rep.NonFullRoots {
+		fmt.Fprintln(out, "  non-full root:", ri.String())
+	}
+	for _, hash := range rep.MissingObjects {
+		fmt.Fprintln(out, "  missing object:", hash.Hex())
+	}
+	for _, rr := range rep.OrphanedRegistries {
+		fmt.Fprintln(out, "  orphaned registry:", rr.String())
+	}
+	switch {
+	case rep.IsClean():
+		fmt.Fprintln(out, "  clean")
+	case rep.Repaired:
+		fmt.Fprintln(out, "  repaired")
+		if refetch {
+			fmt.Fprintln(out, "  refetching roots:", reply.Refetched)
+		}
+	default:
+		fmt.Fprintln(out, "  broken (use --repair to repair)")
+	}
+	return
+}
+
+func gc(rpc *node.RPCClient, ss []string) (err error) {
+	var dryRun, keepRoots bool
+	for _, arg := range ss[1:] {
+		switch arg {
+		case "--dry-run":
+			dryRun = true
+		case "--keep-roots":
+			keepRoots = true
+		default:
+			return fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+	if !dryRun {
+		if err = rpc.CleanUp(keepRoots); err != nil {
+			return
+		}
+		fmt.Fprintln(out, "  cleaned up")
+		return
+	}
+	var plan skyobject.CleanUpPlan
+	if plan, err = rpc.CleanUpPlan(keepRoots); err != nil {
+		return
+	}
+	for pk, fg := range plan.Feeds {
+		fmt.Fprintln(out, "  -", pk.Hex())
+		fmt.Fprintln(out, "    Root Objects: ", len(fg.Roots), fg.Roots)
+		fmt.Fprintln(out, "    Objects:      ", fg.Objects)
+		fmt.Fprintln(out, "    Space:        ", fg.Space.String())
+	}
+	fmt.Fprintln(out, "  unreachable objects:", plan.Unreachable,
+		plan.UnreachableSpace.String())
+	for _, rr := range plan.Registries {
+		fmt.Fprintln(out, "  registry:", rr.String())
+	}
+	fmt.Fprintln(out, "  total objects:", len(plan.Objects),
+		plan.Space.String())
+	return
+}
+
+func registryArg(ss []string) (rr skyobject.RegistryRef, err error) {
+	var rrs string
+	if rrs, err = args(ss); err != nil {
+		return
+	}
+	return skyobject.RegistryRefFromHex(rrs)
+}
+
+func registries(rpc *node.RPCClient) (err error) {
+	var ris []skyobject.RegistryInfo
+	if ris, err = rpc.Registries(); err != nil {
+		return
+	}
+	if len(ris) == 0 {
+		fmt.Fprintln(out, "  no registries")
+		return
+	}
+	for _, ri := range ris {
+		var notes []string
+		if ri.Core {
+			notes = append(notes, "core")
+		}
+		if ri.Loaded {
+			notes = append(notes, "loaded")
+		}
+		if !ri.Saved {
+			notes = append(notes, "missing")
+		}
+		if !ri.IsUsed() {
+			notes = append(notes, "unused")
+		}
+		fmt.Fprintln(out, "  -", ri.Ref.String(), strings.Join(notes, ", "))
+		fmt.Fprintln(out, "    Root Objects: ", ri.Roots)
+		for _, pk := range ri.Feeds {
+			fmt.Fprintln(out, "    feed:", pk.Hex())
+		}
+	}
+	return
+}
+
+func registry(rpc *node.RPCClient, ss []string) (err error) {
+	var rr skyobject.RegistryRef
+	if rr, err = registryArg(ss); err != nil {
+		return
+	}
+	var reg *skyobject.Registry
+	if reg, err = rpc.Registry(rr); err != nil {
+		return
+	}
+	for _, sch := range reg.Schemas() {
+		fmt.Fprintln(out, "  "+sch.Name())
+		for _, fl := range sch.Fields() {
+			if fl.Tag() == "" {
+				fmt.Fprintln(out, "    "+fl.Name(), fl.Schema().String())
+			} else {
+				fmt.Fprintln(out, "    "+fl.String())
+			}
+		}
+	}
+	return
+}
+
+func schema(rpc *node.RPCClient, ss []string) (err error) {
+	var asJSON bool
+	if len(ss) == 3 {
+		if ss[2] != "--json" {
+			return fmt.Errorf("unknown argument %q", ss[2])
+		}
+		asJSON = true
+		ss = ss[:2]
+	}
+	var rr skyobject.RegistryRef
+	if rr, err = registryArg(ss); err != nil {
+		return
+	}
+	var reg *skyobject.Registry
+	if reg, err = rpc.Registry(rr); err != nil {
+		return
+	}
+	if !asJSON {
+		fmt.Fprint(out, reg.IDL())
+		return
+	}
+	var b []byte
+	if b, err = json.MarshalIndent(reg.Describe(), "", "  "); err != nil {
+		return
+	}
+	fmt.Fprintln(out, string(b))
+	return
+}
+
+func delRegistry(rpc *node.RPCClient, ss []string) (err error) {
+	var rr skyobject.RegistryRef
+	if rr, err = registryArg(ss); err != nil {
+		return
+	}
+	if err = rpc.DelRegistry(rr); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  removed")
+	return
+}
+
 func connections(rpc *node.RPCClient) (err error) {
 	var list []string
 	if list, err = rpc.Connections(); err != nil {
@@ -499,7 +1038,48 @@ func listeningAddress(rpc *node.RPCClient) (err error) {
 	return
 }
 
+// default limit of roots command
+const rootsPageLimit = 20
+
 func roots(rpc *node.RPCClient, ss []string) (err error) {
+	var sel node.RootsPage
+	switch len(ss) {
+	case 0, 1:
+		return errors.New("to few arguments: want <pub key> [seq] [limit]")
+	case 2:
+		return allRoots(rpc, ss)
+	case 3, 4:
+	default:
+		return errors.New("to many arguments: want <pub key> [seq] [limit]")
+	}
+	if sel.Feed, err = cipher.PubKeyFromHex(ss[1]); err != nil {
+		return
+	}
+	if sel.From, err = strconv.ParseUint(ss[2], 10, 64); err != nil {
+		return
+	}
+	sel.Limit = rootsPageLimit
+	if len(ss) == 4 {
+		if sel.Limit, err = strconv.Atoi(ss[3]); err != nil {
+			return
+		}
+	}
+	var page node.RootsPageReply
+	if page, err = rpc.RootsPage(sel); err != nil {
+		return
+	}
+	if len(page.Roots) == 0 {
+		fmt.Fprintln(out, "  no roots")
+		return
+	}
+	printRoots(page.Roots)
+	if page.More {
+		fmt.Fprintln(out, "  next seq:", page.Next)
+	}
+	return
+}
+
+func allRoots(rpc *node.RPCClient, ss []string) (err error) {
 	var pub cipher.PubKey
 	if pub, err = publicKeyArg(ss); err != nil {
 		return
@@ -512,13 +1092,17 @@ func roots(rpc *node.RPCClient, ss []string) (err error) {
 		fmt.Fprintln(out, "  empty feed")
 		return
 	}
+	printRoots(ris)
+	return
+}
+
+func printRoots(ris []node.RootInfo) {
 	for _, ri := range ris {
 		fmt.Fprintln(out, "  -", ri.Hash.Hex())
 		fmt.Fprintln(out, "      time:", ri.Time)
 		fmt.Fprintln(out, "      seq:", ri.Seq)
 		fmt.Fprintln(out, "      fill:", ri.IsFull)
 	}
-	return
 }
 
 func tree(rpc *node.RPCClient, ss []string) (err error) {
@@ -526,6 +1110,24 @@ func tree(rpc *node.RPCClient, ss []string) (err error) {
 	var pk cipher.PubKey
 	var seq uint64
 	var lsatFull bool
+	var asJSON, inline bool
+
+	// flags
+	var pos []string
+	for _, arg := range ss {
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "--inline":
+			inline = true
+		default:
+			pos = append(pos, arg)
+		}
+	}
+	if inline && !asJSON {
+		return errors.New("--inline requires --json")
+	}
+	ss = pos
 
 	switch len(ss) {
 	case 0, 1:
@@ -545,13 +1147,52 @@ func tree(rpc *node.RPCClient, ss []string) (err error) {
 		}
 	}
 	var tree string
-	if tree, err = rpc.Tree(pk, seq, lsatFull); err != nil {
+	if asJSON {
+		tree, err = rpc.TreeJSON(pk, seq, lsatFull, inline)
+	} else {
+		tree, err = rpc.Tree(pk, seq, lsatFull)
+	}
+	if err != nil {
 		return
 	}
 	fmt.Fprintln(out, tree)
 	return
 }
 
+func importTree(rpc *node.RPCClient, ss []string) (err error) {
+	switch {
+	case len(ss) < 5:
+		return errMisisngArgument
+	case len(ss) > 5:
+		return errTooManyArguments
+	}
+	var pk cipher.PubKey
+	if pk, err = cipher.PubKeyFromHex(ss[1]); err != nil {
+		return
+	}
+	// the secret key is read by the daemon,
+	// thus it's not sent by RPC
+	var secFile string
+	if secFile, err = filepath.Abs(ss[2]); err != nil {
+		return
+	}
+	var rr skyobject.RegistryRef
+	if rr, err = skyobject.RegistryRefFromHex(ss[3]); err != nil {
+		return
+	}
+	var fl *os.File
+	if fl, err = os.Open(ss[4]); err != nil {
+		return
+	}
+	defer fl.Close()
+	var ri node.RootInfo
+	if ri, err = rpc.RootFromJSON(pk, secFile, rr, fl); err != nil {
+		return
+	}
+	fmt.Fprintln(out, "  root created:", ri.Seq, ri.Hash.Hex()[:7])
+	return
+}
+
 func term(rpc *node.RPCClient) (err error) {
 	if err = rpc.Terminate(); err == io.ErrUnexpectedEOF {
 		err = nil
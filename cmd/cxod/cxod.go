@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 
+	"github.com/skycoin/cxo/data"
 	"github.com/skycoin/cxo/node"
 )
 
@@ -14,6 +17,11 @@ const (
 	Host        string = "[::]:8998" // default host address of the server
 	RPC         string = "[::]:8997" // default RPC address
 	RemoteClose bool   = false       // don't allow closing by RPC by default
+
+	// PassphraseEnv is name of environment variable
+	// with passphrase to encrypt DB, the -passphrase-file
+	// flag overrides the variable
+	PassphraseEnv string = "CXO_PASSPHRASE"
 )
 
 func waitInterrupt(quit <-chan struct{}) {
@@ -25,6 +33,35 @@ func waitInterrupt(quit <-chan struct{}) {
 	}
 }
 
+// restore DB file or log of in-memory DB from given backup
+func restore(c node.Config, backup string) (err error) {
+	path := c.DBPath
+	if c.InMemoryDB {
+		if c.DBLogPath == "" {
+			return errors.New("can't restore in-memory database without log")
+		}
+		path = c.DBLogPath
+	}
+	var fl *os.File
+	if fl, err = os.Open(backup); err != nil {
+		return
+	}
+	defer fl.Close()
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	var db data.DB
+	if c.InMemoryDB {
+		db, err = data.RestorePersistentMemoryDB(path, fl)
+	} else {
+		db, err = data.RestoreDriveDB(path, fl)
+	}
+	if err != nil {
+		return
+	}
+	return db.Close()
+}
+
 func main() {
 
 	var code int
@@ -36,6 +73,13 @@ func main() {
 	c.RPCAddress = RPC
 	c.Listen = Host
 	c.RemoteClose = RemoteClose
+	c.Passphrase = os.Getenv(PassphraseEnv)
+
+	var backup string
+	flag.StringVar(&backup,
+		"restore",
+		"",
+		"restore database from given backup before start")
 
 	c.FromFlags()
 	flag.Parse()
@@ -43,6 +87,14 @@ func main() {
 	var s *node.Node
 	var err error
 
+	if backup != "" {
+		if err = restore(c, backup); err != nil {
+			log.Print(err)
+			code = 1
+			return
+		}
+	}
+
 	// create and launch
 	if s, err = node.NewNode(c); err != nil {
 		log.Print(err)
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/skycoin/cxo/node"
+	"github.com/skycoin/cxo/skyobject"
+)
+
+// defaults
+const (
+	ADDRESS = "[::]:8997" // default RPC address to connect to
+	PACKAGE = "types"     // default package name
+)
+
+// load registry from file or from daemon
+func load(file, address, ref string) (reg *skyobject.Registry, err error) {
+	if file != "" {
+		var val []byte
+		if val, err = ioutil.ReadFile(file); err != nil {
+			return
+		}
+		return skyobject.DecodeRegistry(val)
+	}
+	var rr skyobject.RegistryRef
+	if rr, err = skyobject.RegistryRefFromHex(ref); err != nil {
+		return
+	}
+	var rpc *node.RPCClient
+	if rpc, err = node.NewRPCClient(address); err != nil {
+		return
+	}
+	defer rpc.Close()
+	return rpc.Registry(rr)
+}
+
+func main() {
+
+	var (
+		address string
+		ref     string
+		file    string
+		pkg     string
+		output  string
+
+		help bool
+		code int
+	)
+
+	defer func() { os.Exit(code) }()
+
+	flag.StringVar(&address,
+		"a",
+		ADDRESS,
+		"rpc address of daemon")
+	flag.StringVar(&ref,
+		"r",
+		"",
+		"reference of registry to load from daemon")
+	flag.StringVar(&file,
+		"f",
+		"",
+		"file with encoded registry (instead of daemon)")
+	flag.StringVar(&pkg,
+		"p",
+		PACKAGE,
+		"package name of generated code")
+	flag.StringVar(&output,
+		"o",
+		"",
+		"output file (default is stdout)")
+
+	flag.BoolVar(&help,
+		"h",
+		false,
+		"show help")
+
+	flag.Parse()
+
+	if help {
+		fmt.Printf("Usage %s <flags>\n", os.Args[0])
+		flag.PrintDefaults()
+		return
+	}
+
+	if file == "" && ref == "" {
+		fmt.Fprintln(os.Stderr, "provide -r or -f flag")
+		code = 1
+		return
+	}
+
+	var (
+		reg *skyobject.Registry
+		src []byte
+		err error
+	)
+
+	if reg, err = load(file, address, ref); err == nil {
+		src, err = reg.GoSource(pkg)
+	}
+	if err == nil {
+		if output == "" {
+			_, err = os.Stdout.Write(src)
+		} else {
+			err = ioutil.WriteFile(output, src, 0644)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		code = 1
+	}
+}
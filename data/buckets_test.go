@@ -0,0 +1,199 @@
+package data
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func testBucketCollect(t *testing.T, db DB, name []byte,
+	rng func(bk ViewBucket, fn func(key, value []byte) error) error) (
+	keys []string) {
+
+	err := db.View(func(tx Tv) (_ error) {
+		return rng(tx.Bucket(name), func(key, value []byte) (_ error) {
+			if !bytes.Equal(key, value) {
+				t.Errorf("wrong value of %q: %q", key, value)
+			}
+			keys = append(keys, string(key))
+			return
+		})
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	return
+}
+
+func testBucketCompare(t *testing.T, got []string, want ...string) {
+	if len(got) != len(want) {
+		t.Errorf("wrong keys: want %q, got %q", want, got)
+		return
+	}
+	for i, k := range want {
+		if got[i] != k {
+			t.Errorf("wrong keys: want %q, got %q", want, got)
+			return
+		}
+	}
+}
+
+func testBucket(t *testing.T, db DB) {
+
+	name := []byte("app")
+
+	t.Run("empty name", func(t *testing.T) {
+		db.View(func(tx Tv) (_ error) {
+			if tx.Bucket(nil) != nil {
+				t.Error("got bucket with empty name")
+			}
+			return
+		})
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		err := db.View(func(tx Tv) (_ error) {
+			bk := tx.Bucket(name)
+			if bk.Get([]byte("any")) != nil {
+				t.Error("got value from empty bucket")
+			}
+			return bk.Range(func(_, _ []byte) (_ error) {
+				t.Error("range over empty bucket")
+				return
+			})
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	// values equal to keys
+	keys := []string{"b:2", "a:1", "b:1", "c", "a:2"}
+
+	err := db.Update(func(tx Tu) (err error) {
+		bk := tx.Bucket(name)
+		for _, k := range keys {
+			if err = bk.Set([]byte(k), []byte(k)); err != nil {
+				return
+			}
+		}
+		// another bucket
+		return tx.Bucket([]byte("another")).Set([]byte("x"), []byte("x"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		db.View(func(tx Tv) (_ error) {
+			if val := tx.Bucket(name).Get([]byte("c")); string(val) != "c" {
+				t.Errorf("wrong value %q", val)
+			}
+			if tx.Bucket(name).Get([]byte("x")) != nil {
+				t.Error("got value of another bucket")
+			}
+			return
+		})
+	})
+
+	t.Run("range", func(t *testing.T) {
+		got := testBucketCollect(t, db, name, ViewBucket.Range)
+		testBucketCompare(t, got, "a:1", "a:2", "b:1", "b:2", "c")
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		got := testBucketCollect(t, db, name, ViewBucket.Reverse)
+		testBucketCompare(t, got, "c", "b:2", "b:1", "a:2", "a:1")
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		got := testBucketCollect(t, db, name,
+			func(bk ViewBucket, fn func(key, value []byte) error) error {
+				return bk.Prefix([]byte("b:"), fn)
+			})
+		testBucketCompare(t, got, "b:1", "b:2")
+	})
+
+	t.Run("stop range", func(t *testing.T) {
+		var n int
+		err := db.View(func(tx Tv) error {
+			return tx.Bucket(name).Range(func(_, _ []byte) error {
+				n++
+				return ErrStopRange
+			})
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if n != 1 {
+			t.Error("ErrStopRange doesn't stop the Range")
+		}
+	})
+
+	t.Run("del", func(t *testing.T) {
+		err := db.Update(func(tx Tu) (err error) {
+			bk := tx.Bucket(name)
+			if err = bk.Del([]byte("c")); err != nil {
+				return
+			}
+			return bk.Del([]byte("not exist"))
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		got := testBucketCollect(t, db, name, ViewBucket.Range)
+		testBucketCompare(t, got, "a:1", "a:2", "b:1", "b:2")
+	})
+
+	t.Run("del bucket", func(t *testing.T) {
+		err := db.Update(func(tx Tu) error {
+			return tx.DelBucket(name)
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		testBucketCompare(t, testBucketCollect(t, db, name, ViewBucket.Range))
+		got := testBucketCollect(t, db, []byte("another"), ViewBucket.Range)
+		testBucketCompare(t, got, "x")
+	})
+
+}
+
+func TestTu_Bucket(t *testing.T) {
+	// Bucket(name []byte) UpdateBucket
+
+	t.Run("memory", func(t *testing.T) {
+		testBucket(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testBucket(t, db)
+	})
+
+	t.Run("drive error", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+
+		// the bucket can't be created in read-only transaction
+		err := db.(*driveDB).bolt.View(func(tx *bolt.Tx) (_ error) {
+			bk := (&driveTu{tx}).Bucket([]byte("app"))
+			if err := bk.Set([]byte("k"), []byte("v")); err == nil {
+				t.Error("missing error")
+			}
+			if err := bk.Del([]byte("k")); err == nil {
+				t.Error("missing error")
+			}
+			if bk.Get([]byte("k")) != nil {
+				t.Error("got value from broken bucket")
+			}
+			return
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+}
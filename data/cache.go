@@ -0,0 +1,301 @@
+package data
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// A CacheStat represents statistic of cache of objects
+type CacheStat struct {
+	Hits     int64 `json:"hits"`      // objects got from the cache
+	Misses   int64 `json:"misses"`    // objects got from underlying DB
+	Objects  int   `json:"objects"`   // objects in the cache
+	Space    Space `json:"space"`     // space taken by the objects
+	MaxSpace Space `json:"max_space"` // limit of the space
+}
+
+// an item of LRU list
+type cacheItem struct {
+	key cipher.SHA256
+	val []byte
+}
+
+// cache is LRU cache of objects limited by size of objects
+type cache struct {
+	mx sync.Mutex
+
+	items map[cipher.SHA256]*list.Element
+	lru   *list.List // front is most recently used
+
+	space    Space
+	maxSpace Space
+
+	// gen is incremented every time objects are changed;
+	// a reader doesn't put an object to the cache if
+	// the generation is changed after its transaction
+	// begins, because the reader can see stale data
+	gen uint64
+
+	hits, misses int64
+}
+
+func newCache(maxSpace int) (c *cache) {
+	c = new(cache)
+	c.items = make(map[cipher.SHA256]*list.Element)
+	c.lru = list.New()
+	c.maxSpace = Space(maxSpace)
+	return
+}
+
+// generation of the cache
+func (c *cache) generation() uint64 {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.gen
+}
+
+// get object from the cache, the val is nil if the cache
+// doesn't contain the object; the val must not be modified
+func (c *cache) get(key cipher.SHA256) (val []byte) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.hits++
+		c.lru.MoveToFront(el)
+		return el.Value.(*cacheItem).val
+	}
+	c.misses++
+	return
+}
+
+// put a copy of given object to the cache, if
+// the generation is the same as given one
+func (c *cache) put(gen uint64, key cipher.SHA256, val []byte) {
+	if Space(len(val)) > c.maxSpace {
+		return // too big
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if gen != c.gen {
+		return // can be stale
+	}
+	if _, ok := c.items[key]; ok {
+		return // already cached
+	}
+
+	cp := make([]byte, len(val))
+	copy(cp, val)
+
+	c.items[key] = c.lru.PushFront(&cacheItem{key, cp})
+	c.space += Space(len(cp))
+
+	for c.space > c.maxSpace {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *cache) remove(el *list.Element) {
+	ci := c.lru.Remove(el).(*cacheItem)
+	delete(c.items, ci.key)
+	c.space -= Space(len(ci.val))
+}
+
+// invalidate given objects
+func (c *cache) invalidate(keys map[cipher.SHA256]struct{}) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.gen++
+	for key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *cache) stat() (cs *CacheStat) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return &CacheStat{
+		Hits:     c.hits,
+		Misses:   c.misses,
+		Objects:  len(c.items),
+		Space:    c.space,
+		MaxSpace: c.maxSpace,
+	}
+}
+
+// cacheDB is DB with LRU cache of objects
+type cacheDB struct {
+	DB
+	c *cache
+
+	// writers are serialized by DB anyway, but next
+	// writer must not start before the cache is
+	// invalidated by previous one
+	wmx sync.Mutex
+}
+
+// NewCacheDB wraps given DB adding LRU cache of objects limited by
+// given size in bytes. The cache used by Get, GetCopy and IsExist
+// methods of objects. Statistic of the cache is in Stat().Cache
+func NewCacheDB(db DB, size int) DB {
+	return &cacheDB{DB: db, c: newCache(size)}
+}
+
+func (d *cacheDB) View(fn func(t Tv) error) error {
+	gen := d.c.generation()
+	return d.DB.View(func(t Tv) error {
+		return fn(&cacheTv{t, d.c, gen})
+	})
+}
+
+func (d *cacheDB) Update(fn func(t Tu) error) (err error) {
+	d.wmx.Lock()
+	defer d.wmx.Unlock()
+
+	touched := make(map[cipher.SHA256]struct{})
+	gen := d.c.generation()
+	err = d.DB.Update(func(t Tu) error {
+		return fn(&cacheTu{t, d.c, gen, touched})
+	})
+	if len(touched) > 0 {
+		d.c.invalidate(touched) // committed or not
+	}
+	return
+}
+
+func (d *cacheDB) Stat() (s Stat) {
+	s = d.DB.Stat()
+	s.Cache = d.c.stat()
+	return
+}
+
+type cacheTv struct {
+	Tv
+	c   *cache
+	gen uint64
+}
+
+func (c *cacheTv) Objects() ViewObjects {
+	return &cacheViewObjects{c.Tv.Objects(), c.c, c.gen}
+}
+
+type cacheViewObjects struct {
+	ViewObjects
+	c   *cache
+	gen uint64
+}
+
+func (c *cacheViewObjects) Get(key cipher.SHA256) (val []byte) {
+	if val = c.c.get(key); val != nil {
+		return
+	}
+	if val = c.ViewObjects.Get(key); val != nil {
+		c.c.put(c.gen, key, val)
+	}
+	return
+}
+
+func (c *cacheViewObjects) GetCopy(key cipher.SHA256) (val []byte) {
+	if val = c.Get(key); val != nil {
+		cp := make([]byte, len(val))
+		copy(cp, val)
+		val = cp
+	}
+	return
+}
+
+func (c *cacheViewObjects) IsExist(key cipher.SHA256) bool {
+	return c.Get(key) != nil
+}
+
+type cacheTu struct {
+	Tu
+	c       *cache
+	gen     uint64
+	touched map[cipher.SHA256]struct{} // changed objects
+}
+
+func (c *cacheTu) Objects() UpdateObjects {
+	return &cacheUpdateObjects{c.Tu.Objects(), c.c, c.gen, c.touched}
+}
+
+type cacheUpdateObjects struct {
+	UpdateObjects
+	c       *cache
+	gen     uint64
+	touched map[cipher.SHA256]struct{}
+}
+
+func (c *cacheUpdateObjects) Get(key cipher.SHA256) (val []byte) {
+	if _, ok := c.touched[key]; ok {
+		return c.UpdateObjects.Get(key) // changed by this transaction
+	}
+	if val = c.c.get(key); val != nil {
+		return
+	}
+	if val = c.UpdateObjects.Get(key); val != nil {
+		c.c.put(c.gen, key, val)
+	}
+	return
+}
+
+func (c *cacheUpdateObjects) GetCopy(key cipher.SHA256) (val []byte) {
+	if val = c.Get(key); val != nil {
+		cp := make([]byte, len(val))
+		copy(cp, val)
+		val = cp
+	}
+	return
+}
+
+func (c *cacheUpdateObjects) IsExist(key cipher.SHA256) bool {
+	return c.Get(key) != nil
+}
+
+func (c *cacheUpdateObjects) Del(key cipher.SHA256) error {
+	c.touched[key] = struct{}{}
+	return c.UpdateObjects.Del(key)
+}
+
+func (c *cacheUpdateObjects) Set(key cipher.SHA256, value []byte) error {
+	c.touched[key] = struct{}{}
+	return c.UpdateObjects.Set(key, value)
+}
+
+func (c *cacheUpdateObjects) Add(value []byte) (key cipher.SHA256,
+	err error) {
+
+	if key, err = c.UpdateObjects.Add(value); err == nil {
+		c.touched[key] = struct{}{}
+	}
+	return
+}
+
+func (c *cacheUpdateObjects) SetMap(m map[cipher.SHA256][]byte) error {
+	for key := range m {
+		c.touched[key] = struct{}{}
+	}
+	return c.UpdateObjects.SetMap(m)
+}
+
+func (c *cacheUpdateObjects) RangeDel(
+	fn func(key cipher.SHA256, value []byte) (bool, error)) error {
+
+	return c.UpdateObjects.RangeDel(func(key cipher.SHA256,
+		value []byte) (del bool, err error) {
+
+		if del, err = fn(key, value); del {
+			c.touched[key] = struct{}{}
+		}
+		return
+	})
+}
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func testCacheDB(t *testing.T, db DB) {
+
+	db = NewCacheDB(db, 10)
+
+	get := func(key cipher.SHA256) (val []byte) {
+		db.View(func(tx Tv) (_ error) {
+			val = tx.Objects().GetCopy(key)
+			return
+		})
+		return
+	}
+
+	var one, two, big cipher.SHA256
+
+	err := db.Update(func(tx Tu) (err error) {
+		objs := tx.Objects()
+		if one, err = objs.Add([]byte("one")); err != nil {
+			return
+		}
+		if two, err = objs.Add([]byte("two")); err != nil {
+			return
+		}
+		big, err = objs.Add([]byte("the object is too big"))
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("hits", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if string(get(one)) != "one" || string(get(big)) == "" {
+				t.Fatal("wrong or missing object")
+			}
+		}
+		cs := db.Stat().Cache
+		if cs == nil {
+			t.Fatal("missing cache statistic")
+		}
+		if cs.Hits != 2 || cs.Misses != 4 || cs.Objects != 1 {
+			t.Errorf("wrong statistic: %+v", *cs)
+		}
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		get(two)
+		get(two)
+		db.Update(func(tx Tu) (_ error) {
+			_, err := tx.Objects().Add([]byte("three"))
+			return err
+		})
+		get(cipher.SumSHA256([]byte("three")))
+		if cs := db.Stat().Cache; cs.Objects != 2 || cs.Space > 10 {
+			t.Errorf("wrong statistic: %+v", *cs)
+		}
+	})
+
+	t.Run("del", func(t *testing.T) {
+		get(two)
+		err := db.Update(func(tx Tu) error {
+			return tx.Objects().Del(two)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if get(two) != nil {
+			t.Error("deleted object is cached")
+		}
+	})
+
+	t.Run("range del", func(t *testing.T) {
+		get(one)
+		err := db.Update(func(tx Tu) error {
+			return tx.Objects().RangeDel(func(key cipher.SHA256,
+				_ []byte) (bool, error) {
+
+				return key == one, nil
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if get(one) != nil {
+			t.Error("deleted object is cached")
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		three := cipher.SumSHA256([]byte("three"))
+		get(three)
+		errRollback := errors.New("rollback")
+		err := db.Update(func(tx Tu) error {
+			objs := tx.Objects()
+			if err := objs.Del(three); err != nil {
+				return err
+			}
+			if objs.Get(three) != nil {
+				t.Error("deleted object is visible inside transaction")
+			}
+			return errRollback
+		})
+		if err != errRollback {
+			t.Fatal("unexpected error:", err)
+		}
+		if string(get(three)) != "three" {
+			t.Error("missing object after rollback")
+		}
+	})
+
+}
+
+func TestNewCacheDB(t *testing.T) {
+	// NewCacheDB(db DB, size int) DB
+
+	t.Run("memory", func(t *testing.T) {
+		testCacheDB(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testCacheDB(t, db)
+	})
+
+}
@@ -0,0 +1,458 @@
+package data
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// compression related errors
+var (
+	ErrNotCompressed    = errors.New("DB is not empty and not compressed")
+	ErrCompressed       = errors.New("DB is compressed")
+	ErrUnknownCodec     = errors.New("unknown codec")
+	ErrMalformedEncoded = errors.New("malformed compressed object")
+)
+
+// A Codec represents compression algorithm of objects
+type Codec byte
+
+// codecs
+const (
+	CodecNone      Codec = iota // no compression
+	CodecFlateFast              // compress/flate, best speed
+	CodecFlate                  // compress/flate, default compression
+)
+
+var codecNames = map[Codec]string{
+	CodecNone:      "none",
+	CodecFlateFast: "flate-fast",
+	CodecFlate:     "flate",
+}
+
+// String implements fmt.Stringer interface
+func (c Codec) String() string {
+	if name, ok := codecNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Codec(%d)", c)
+}
+
+// Set implements flag.Value interface
+func (c *Codec) Set(name string) error {
+	for codec, n := range codecNames {
+		if n == name {
+			*c = codec
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown codec %q", name)
+}
+
+func (c Codec) level() int {
+	if c == CodecFlateFast {
+		return flate.BestSpeed
+	}
+	return flate.DefaultCompression
+}
+
+// compress given object; the result is codec byte, uvarint length of
+// the object and compressed object. If compressed object is not smaller
+// then the object stored as is with CodecNone
+func (c Codec) compress(val []byte) (enc []byte) {
+	head := make([]byte, 1+binary.MaxVarintLen64)
+	hl := 1 + binary.PutUvarint(head[1:], uint64(len(val)))
+
+	if c != CodecNone {
+		var buf bytes.Buffer
+		buf.Write(head[:hl])
+		fw, err := flate.NewWriter(&buf, c.level())
+		if err == nil {
+			fw.Write(val)
+			fw.Close()
+			if buf.Len() < hl+len(val) {
+				enc = buf.Bytes()
+				enc[0] = byte(c)
+				return
+			}
+		}
+	}
+
+	enc = make([]byte, hl+len(val))
+	head[0] = byte(CodecNone)
+	copy(enc, head[:hl])
+	copy(enc[hl:], val)
+	return
+}
+
+// header of compressed object: codec,
+// logical size and offset of payload
+func header(enc []byte) (c Codec, size uint64, offset int, err error) {
+	if len(enc) < 2 {
+		err = ErrMalformedEncoded
+		return
+	}
+	c = Codec(enc[0])
+	var n int
+	if size, n = binary.Uvarint(enc[1:]); n <= 0 {
+		err = ErrMalformedEncoded
+		return
+	}
+	offset = 1 + n
+	return
+}
+
+func decompress(enc []byte) (val []byte, err error) {
+	var c Codec
+	var size uint64
+	var offset int
+	if c, size, offset, err = header(enc); err != nil {
+		return
+	}
+	switch c {
+	case CodecNone:
+		val = enc[offset:]
+	case CodecFlateFast, CodecFlate:
+		fr := flate.NewReader(bytes.NewReader(enc[offset:]))
+		defer fr.Close()
+		if val, err = ioutil.ReadAll(fr); err != nil {
+			return
+		}
+	default:
+		return nil, ErrUnknownCodec
+	}
+	if uint64(len(val)) != size {
+		val, err = nil, ErrMalformedEncoded
+	}
+	return
+}
+
+// logical size of compressed object, it's 0 for malformed object
+func logicalSize(enc []byte) int64 {
+	_, size, _, err := header(enc)
+	if err != nil {
+		return 0
+	}
+	return int64(size)
+}
+
+// compression bucket keeps logical space
+// of objects and marks DB as compressed
+var (
+	compressionBucket = []byte("data.compression")
+	logicalSpaceKey   = []byte("logical")
+)
+
+// compressedDB compresses objects of underlying DB
+type compressedDB struct {
+	DB
+	codec Codec
+}
+
+// NewCompressedDB wraps given DB compressing objects using given codec.
+// Hashes of objects are computed over uncompressed objects. The codec
+// can be changed between openings of a DB, because every object keeps
+// its codec. Given DB must be empty or must be compressed before,
+// otherwise ErrNotCompressed returned. Stat of the DB reports
+// uncompressed size of objects as Space and compressed size
+// as PhysicalSpace. Since Get and GetCopy methods can't return
+// an error, they return nil if a stored object can't be
+// decompressed (the DB is corrupted), e.g. such object looks
+// like missing one. Range methods return the error. Use
+// IsCompressed to check a DB before using it without the wrapper
+func NewCompressedDB(db DB, codec Codec) (cdb DB, err error) {
+	if _, ok := codecNames[codec]; !ok {
+		return nil, ErrUnknownCodec
+	}
+	err = db.Update(func(tx Tu) (err error) {
+		bk := tx.Bucket(compressionBucket)
+		if bk.Get(logicalSpaceKey) != nil {
+			return // already compressed
+		}
+		var empty = true
+		tx.Objects().Range(func(cipher.SHA256, []byte) error {
+			empty = false
+			return ErrStopRange
+		})
+		if !empty {
+			return ErrNotCompressed
+		}
+		return bk.Set(logicalSpaceKey, counter{}.encode())
+	})
+	if err != nil {
+		return
+	}
+	cdb = &compressedDB{db, codec}
+	return
+}
+
+// IsCompressed reports whether given DB has been wrapped using
+// NewCompressedDB before. Such DB can't be used without the
+// wrapper, since its objects are compressed
+func IsCompressed(db DB) (yep bool) {
+	db.View(func(tx Tv) (_ error) {
+		yep = tx.Bucket(compressionBucket).Get(logicalSpaceKey) != nil
+		return
+	})
+	return
+}
+
+func (d *compressedDB) View(fn func(t Tv) error) error {
+	return d.DB.View(func(t Tv) error {
+		return fn(&compressedTv{t})
+	})
+}
+
+func (d *compressedDB) Update(fn func(t Tu) error) error {
+	return d.DB.Update(func(t Tu) error {
+		return fn(&compressedTu{t, d.codec})
+	})
+}
+
+func (d *compressedDB) Stat() (s Stat) {
+	s = d.DB.Stat()
+	d.DB.View(func(tx Tv) (_ error) {
+		lc := decodeCounter(tx.Bucket(compressionBucket).Get(logicalSpaceKey))
+		s.Space = Space(lc.volume)
+		return
+	})
+	return
+}
+
+func (d *compressedDB) Recount() (err error) {
+	if err = d.DB.Recount(); err != nil {
+		return
+	}
+	return d.DB.Update(func(tx Tu) (err error) {
+		var lc counter
+		err = tx.Objects().Range(func(_ cipher.SHA256, enc []byte) (_ error) {
+			lc.add(1, logicalSize(enc))
+			return
+		})
+		if err != nil {
+			return
+		}
+		return tx.Bucket(compressionBucket).Set(logicalSpaceKey, lc.encode())
+	})
+}
+
+type compressedTv struct {
+	Tv
+}
+
+func (c *compressedTv) Objects() ViewObjects {
+	return &compressedViewObjects{c.Tv.Objects()}
+}
+
+type compressedViewObjects struct {
+	ViewObjects
+}
+
+// decompressed object or nil if the object not found
+// or can't be decompressed (malformed)
+func decompressed(enc []byte) (val []byte) {
+	if enc == nil {
+		return
+	}
+	var err error
+	if val, err = decompress(enc); err != nil {
+		return nil
+	}
+	return
+}
+
+func (c *compressedViewObjects) Get(key cipher.SHA256) []byte {
+	return decompressed(c.ViewObjects.Get(key))
+}
+
+func (c *compressedViewObjects) GetCopy(key cipher.SHA256) []byte {
+	return decompressed(c.ViewObjects.GetCopy(key))
+}
+
+func (c *compressedViewObjects) Range(
+	fn func(key cipher.SHA256, value []byte) error) error {
+
+	return c.ViewObjects.Range(func(key cipher.SHA256, enc []byte) (err error) {
+		var val []byte
+		if val, err = decompress(enc); err != nil {
+			return
+		}
+		return fn(key, val)
+	})
+}
+
+func (c *compressedViewObjects) RangeFrom(from cipher.SHA256, limit int,
+	fn func(key cipher.SHA256, value []byte) error) (cipher.SHA256, bool,
+	error) {
+
+	return c.ViewObjects.RangeFrom(from, limit, decompressing(fn))
+}
+
+func (c *compressedViewObjects) RangeErr(
+	fn func(key cipher.SHA256, value []byte, err error) error) error {
+
+	return c.ViewObjects.RangeErr(decompressingErr(fn))
+}
+
+// decompressingErr wraps given function of RangeErr
+func decompressingErr(
+	fn func(key cipher.SHA256, value []byte, err error) error,
+) func(key cipher.SHA256, enc []byte, err error) error {
+
+	return func(key cipher.SHA256, enc []byte, err error) error {
+		var val []byte
+		if err == nil {
+			val, err = decompress(enc)
+		}
+		return fn(key, val, err)
+	}
+}
+
+// decompressing wraps given function of RangeFrom
+func decompressing(
+	fn func(key cipher.SHA256, value []byte) error,
+) func(key cipher.SHA256, enc []byte) error {
+
+	return func(key cipher.SHA256, enc []byte) (err error) {
+		var val []byte
+		if val, err = decompress(enc); err != nil {
+			return
+		}
+		return fn(key, val)
+	}
+}
+
+type compressedTu struct {
+	Tu
+	codec Codec
+}
+
+func (c *compressedTu) Objects() UpdateObjects {
+	return &compressedUpdateObjects{c.Tu.Objects(), c.Tu.Bucket(
+		compressionBucket), c.codec}
+}
+
+type compressedUpdateObjects struct {
+	UpdateObjects
+	bk    UpdateBucket
+	codec Codec
+}
+
+// update logical space
+func (c *compressedUpdateObjects) count(amount, volume int64) error {
+	if amount == 0 && volume == 0 {
+		return nil
+	}
+	lc := decodeCounter(c.bk.Get(logicalSpaceKey))
+	lc.add(amount, volume)
+	return c.bk.Set(logicalSpaceKey, lc.encode())
+}
+
+func (c *compressedUpdateObjects) Get(key cipher.SHA256) []byte {
+	return decompressed(c.UpdateObjects.Get(key))
+}
+
+func (c *compressedUpdateObjects) GetCopy(key cipher.SHA256) []byte {
+	return decompressed(c.UpdateObjects.GetCopy(key))
+}
+
+func (c *compressedUpdateObjects) Range(
+	fn func(key cipher.SHA256, value []byte) error) error {
+
+	return c.UpdateObjects.Range(func(key cipher.SHA256,
+		enc []byte) (err error) {
+
+		var val []byte
+		if val, err = decompress(enc); err != nil {
+			return
+		}
+		return fn(key, val)
+	})
+}
+
+func (c *compressedUpdateObjects) RangeErr(
+	fn func(key cipher.SHA256, value []byte, err error) error) error {
+
+	return c.UpdateObjects.RangeErr(decompressingErr(fn))
+}
+
+func (c *compressedUpdateObjects) RangeFrom(from cipher.SHA256, limit int,
+	fn func(key cipher.SHA256, value []byte) error) (cipher.SHA256, bool,
+	error) {
+
+	return c.UpdateObjects.RangeFrom(from, limit, decompressing(fn))
+}
+
+func (c *compressedUpdateObjects) Set(key cipher.SHA256,
+	value []byte) (err error) {
+
+	var amount, volume int64 = 1, int64(len(value))
+	if old := c.UpdateObjects.Get(key); old != nil {
+		amount, volume = 0, volume-logicalSize(old)
+	}
+	if err = c.UpdateObjects.Set(key, c.codec.compress(value)); err != nil {
+		return
+	}
+	return c.count(amount, volume)
+}
+
+func (c *compressedUpdateObjects) Add(value []byte) (key cipher.SHA256,
+	err error) {
+
+	key = cipher.SumSHA256(value)
+	err = c.Set(key, value)
+	return
+}
+
+func (c *compressedUpdateObjects) SetMap(
+	m map[cipher.SHA256][]byte) (err error) {
+
+	for _, kv := range sortMap(m) {
+		if err = c.Set(kv.key, kv.val); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (c *compressedUpdateObjects) Del(key cipher.SHA256) (err error) {
+	old := c.UpdateObjects.Get(key)
+	if old == nil {
+		// not found or can't be read (e.g. can't be
+		// decrypted), logical size of such object
+		// is unknown, use Recount to fix it
+		return c.UpdateObjects.Del(key)
+	}
+	volume := logicalSize(old)
+	if err = c.UpdateObjects.Del(key); err != nil {
+		return
+	}
+	return c.count(-1, -volume)
+}
+
+func (c *compressedUpdateObjects) RangeDel(
+	fn func(key cipher.SHA256, value []byte) (bool, error)) (err error) {
+
+	var dc counter // deleted
+	err = c.UpdateObjects.RangeDel(func(key cipher.SHA256,
+		enc []byte) (del bool, err error) {
+
+		var val []byte
+		if val, err = decompress(enc); err != nil {
+			return
+		}
+		if del, err = fn(key, val); del {
+			dc.add(1, int64(len(val)))
+		}
+		return
+	})
+	if cerr := c.count(-dc.amount, -dc.volume); err == nil {
+		err = cerr
+	}
+	return
+}
@@ -0,0 +1,197 @@
+package data
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func Test_compress(t *testing.T) {
+
+	long := bytes.Repeat([]byte("compress me "), 100)
+
+	for _, c := range []Codec{CodecNone, CodecFlateFast, CodecFlate} {
+		for _, val := range [][]byte{{}, []byte("x"), long} {
+			enc := c.compress(val)
+			if got, err := decompress(enc); err != nil {
+				t.Error(c, err)
+			} else if !bytes.Equal(got, val) {
+				t.Error(c, "wrong decompressed object")
+			}
+			if logicalSize(enc) != int64(len(val)) {
+				t.Error(c, "wrong logical size")
+			}
+		}
+		if enc := c.compress(long); c != CodecNone && len(enc) >= len(long) {
+			t.Error(c, "not compressed")
+		}
+	}
+
+	if _, err := decompress([]byte{0xff, 0x00}); err != ErrUnknownCodec {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func testCompressedDB(t *testing.T, db DB) {
+
+	long := bytes.Repeat([]byte("compress me "), 100)
+
+	var key cipher.SHA256
+	err := db.Update(func(tx Tu) (err error) {
+		key, err = tx.Objects().Add([]byte("one"))
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewCompressedDB(db, CodecFlate); err != ErrNotCompressed {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = db.Update(func(tx Tu) error {
+		return tx.Objects().Del(key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if IsCompressed(db) {
+		t.Error("not compressed DB is compressed")
+	}
+
+	raw := db
+	if db, err = NewCompressedDB(db, CodecFlate); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsCompressed(raw) {
+		t.Error("compressed DB is not compressed")
+	}
+
+	t.Run("add", func(t *testing.T) {
+		err := db.Update(func(tx Tu) (err error) {
+			key, err = tx.Objects().Add(long)
+			return
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != cipher.SumSHA256(long) {
+			t.Error("hash is not computed over uncompressed object")
+		}
+		db.View(func(tx Tv) (_ error) {
+			if !bytes.Equal(tx.Objects().Get(key), long) {
+				t.Error("wrong object")
+			}
+			tx.Objects().RangeFrom(key, 1, func(_ cipher.SHA256,
+				val []byte) error {
+
+				if !bytes.Equal(val, long) {
+					t.Error("wrong object of RangeFrom")
+				}
+				return nil
+			})
+			return
+		})
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		s := db.Stat()
+		if s.Objects != 1 || s.Space != Space(len(long)) {
+			t.Errorf("wrong statistic: %+v", s)
+		}
+		if s.PhysicalSpace == 0 || s.PhysicalSpace >= s.Space {
+			t.Errorf("wrong physical space: %+v", s)
+		}
+		if err := db.Recount(); err != nil {
+			t.Fatal(err)
+		}
+		if rs := db.Stat(); rs.Space != s.Space ||
+			rs.PhysicalSpace != s.PhysicalSpace {
+
+			t.Errorf("wrong statistic after recount: %+v", rs)
+		}
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		cdb, err := NewCompressedDB(db.(*compressedDB).DB, CodecNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cdb.View(func(tx Tv) (_ error) {
+			if !bytes.Equal(tx.Objects().Get(key), long) {
+				t.Error("wrong object")
+			}
+			return
+		})
+	})
+
+	t.Run("range del", func(t *testing.T) {
+		err := db.Update(func(tx Tu) error {
+			return tx.Objects().RangeDel(func(_ cipher.SHA256,
+				val []byte) (bool, error) {
+
+				return bytes.Equal(val, long), nil
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := db.Stat(); s.Objects != 0 || s.Space != 0 ||
+			s.PhysicalSpace != 0 {
+
+			t.Errorf("wrong statistic: %+v", s)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		bad := cipher.SumSHA256([]byte("bad"))
+		err := raw.Update(func(tx Tu) error {
+			return tx.Objects().Set(bad, []byte{0xff, 0x01, 0x00})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.View(func(tx Tv) (_ error) {
+			if tx.Objects().Get(bad) != nil {
+				t.Error("got malformed object")
+			}
+			err := tx.Objects().Range(func(cipher.SHA256, []byte) error {
+				return nil
+			})
+			if err == nil {
+				t.Error("missing error")
+			}
+			err = tx.Objects().RangeErr(func(key cipher.SHA256, val []byte,
+				err error) error {
+
+				if key == bad && (val != nil || err == nil) {
+					t.Error("malformed object is not reported")
+				}
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			return
+		})
+	})
+
+}
+
+func TestNewCompressedDB(t *testing.T) {
+	// NewCompressedDB(db DB, codec Codec) (DB, error)
+
+	t.Run("memory", func(t *testing.T) {
+		testCompressedDB(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testCompressedDB(t, db)
+	})
+
+}
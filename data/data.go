@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"sort"
+	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
 )
@@ -21,6 +23,14 @@ var (
 	// ErrStopRange used by Range, RangeDelete and Reverse functions
 	// to stop itterating. It's error never bubbles up
 	ErrStopRange = errors.New("stop range")
+	// ErrSuccessorExists occurs when you try to record successor
+	// of a feed that already has another successor. The first
+	// recorded successor wins
+	ErrSuccessorExists = errors.New("feed already has another successor")
+	// ErrPredecessorExists occurs when you try to record successor
+	// that already continues another feed
+	ErrPredecessorExists = errors.New(
+		"successor already has another predecessor")
 )
 
 // ViewObjects represents read-only bucket of objects
@@ -35,6 +45,22 @@ type ViewObjects interface {
 	IsExist(key cipher.SHA256) (ok bool)
 	// Range over all objects. Use ErrStopRange to break itteration
 	Range(func(key cipher.SHA256, value []byte) error) (err error)
+	// RangeFrom itterates objects ordered by key starting from
+	// given key (inclusive). It visits up to limit objects, zero
+	// limit means no limit. If there are objects after visited,
+	// then it returns key of next one and more = true. Use the
+	// next key to get next page. Use ErrStopRange to break
+	// itteration
+	RangeFrom(from cipher.SHA256, limit int,
+		fn func(key cipher.SHA256, value []byte) error) (next cipher.SHA256,
+		more bool, err error)
+	// RangeErr is the same as Range, but it doesn't stop on
+	// objects that can't be read (decompressed or decrypted,
+	// see NewCompressedDB and NewEncryptedDB). Given function
+	// called with nil value and the error for such objects.
+	// Use ErrStopRange to break itteration
+	RangeErr(fn func(key cipher.SHA256, value []byte, err error) error) (
+		err error)
 }
 
 // UpdateObjects represents read-write bucket of objects
@@ -70,6 +96,19 @@ type ViewFeeds interface {
 	// Range itterates all feeds. Use ErrStopRange to break
 	// the Range
 	Range(func(pk cipher.PubKey) error) (err error)
+	// RangeFrom itterates feeds ordered by public key starting
+	// from given one (inclusive). See ViewObjects.RangeFrom
+	// for details
+	RangeFrom(from cipher.PubKey, limit int,
+		fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+		err error)
+
+	// Successor returns new public key of given feed if
+	// the feed has been continued using another key
+	Successor(pk cipher.PubKey) (next cipher.PubKey, ok bool)
+	// Predecessor returns previous public key of given
+	// feed if the feed continues another one
+	Predecessor(pk cipher.PubKey) (prev cipher.PubKey, ok bool)
 
 	// Roots of given feed. This method returns nil if
 	// given feed doesn't exist. Use this method to access
@@ -87,15 +126,30 @@ type UpdateFeeds interface {
 	IsExist(pk cipher.PubKey) (ok bool)
 	List() (list []cipher.PubKey)
 	Range(func(pk cipher.PubKey) error) (err error)
+	RangeFrom(from cipher.PubKey, limit int,
+		fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+		err error)
+	Successor(pk cipher.PubKey) (next cipher.PubKey, ok bool)
+	Predecessor(pk cipher.PubKey) (prev cipher.PubKey, ok bool)
 
 	//
 	// change feed/roots
 	//
 
+	// SetSuccessor records that given feed continued
+	// using next public key (feed key rotation). The
+	// first recorded successor wins: the method returns
+	// ErrSuccessorExists if the feed already has another
+	// successor and ErrPredecessorExists if the next
+	// already continues another feed. Recording the same
+	// succession again is not an error
+	SetSuccessor(pk, next cipher.PubKey) (err error)
+
 	// Add an empty feed
 	Add(pk cipher.PubKey) (err error)
-	// Del deletes given feed and all roos.
-	// It never returns "not found" error
+	// Del deletes given feed, all roos and succession
+	// records of the feed. It never returns "not found"
+	// error
 	Del(pk cipher.PubKey) (err error)
 
 	// RangeDel itterates all feeds. If given function returns del = true
@@ -117,6 +171,10 @@ type ViewRoots interface {
 	Last() (rp *RootPack)
 	// Get a root object by seq number
 	Get(seq uint64) (rp *RootPack)
+	// Meta returns local metadata of a root object
+	// by seq number. It returns nil if the root
+	// doesn't exist
+	Meta(seq uint64) (rm *RootMeta)
 
 	// Range itterates all root objects ordered
 	// by seq from oldest to newest. Use ErrStopRange
@@ -126,6 +184,27 @@ type ViewRoots interface {
 	// E.g. it itterates all root objects ordered by seq
 	// from newest (latest) to oldest
 	Reverse(fn func(rp *RootPack) (err error)) error
+
+	// RangeFrom itterates root objects starting from given
+	// seq (inclusive) to newest. It visits up to limit roots,
+	// zero limit means no limit. If there are roots after
+	// visited, then it returns seq of next one and more = true.
+	// Use ErrStopRange to break the RangeFrom
+	RangeFrom(seq uint64, limit int,
+		fn func(rp *RootPack) error) (next uint64, more bool, err error)
+	// ReverseFrom is the same as RangeFrom, but it itterates
+	// from given seq (inclusive) to oldest
+	ReverseFrom(seq uint64, limit int,
+		fn func(rp *RootPack) error) (next uint64, more bool, err error)
+
+	// SeqByTime returns seq number of first root object with
+	// RootMeta.Time equal to or after given time (unix nano).
+	// Roots with zero RootMeta.Time are not indexed
+	SeqByTime(t int64) (seq uint64, ok bool)
+
+	// Stat returns statistic of the feed. Unlike
+	// DB.Stat it reads counter of this feed only
+	Stat() (fs FeedStat)
 }
 
 // UpdateRoots represents read-write bucket of Root obejcts
@@ -134,8 +213,8 @@ type UpdateRoots interface {
 
 	// Add a root object. It returns ErrRootAlreadyExists
 	// if root with the same seq number already exists.
-	// The method doesn't modify rp. And if rp.IsFull is
-	// true then it will be saved as full
+	// The method doesn't modify rp. The root will be saved
+	// with empty metadata, that has only Received time
 	Add(rp *RootPack) (err error)
 	// Del deletes root object by seq number. It never
 	// returns "not found" error
@@ -143,6 +222,9 @@ type UpdateRoots interface {
 	// MarkFull marks root with given seq as full.
 	// The method can return ErrNotFound
 	MarkFull(seq uint64) (err error)
+	// SetMeta replaces local metadata of root with
+	// given seq. The method can return ErrNotFound
+	SetMeta(seq uint64, rm *RootMeta) (err error)
 
 	// RangeDelete used to delete Root obejcts.
 	// If given function returns del = true, then
@@ -157,23 +239,61 @@ type UpdateRoots interface {
 	DelBefore(seq uint64) (err error)
 }
 
-// A Tv represents read-only transaction
-type Tv interface {
+// ViewBucket represents read-only end-user bucket. Keys
+// of the bucket are ordered (bytewise). Don't modify
+// the bucket inside Range, Reverse and Prefix
+type ViewBucket interface {
+	// Get value by key. It returns nil if the value doesn't
+	// exist. Returned slice valid only inside current transaction
+	Get(key []byte) (value []byte)
+
+	// Range over all values ordered by key. Use
+	// ErrStopRange to break itteration
+	Range(func(key, value []byte) error) (err error)
+	// Reverse is the same as Range in reversed order
+	Reverse(func(key, value []byte) error) (err error)
+	// Prefix itterates ordered values with keys that
+	// starts with given prefix. Use ErrStopRange to
+	// break itteration
+	Prefix(prefix []byte, fn func(key, value []byte) error) (err error)
+}
+
+// UpdateBucket represents read-write end-user bucket
+type UpdateBucket interface {
+	ViewBucket
 
-	// TODO (kostyarin): add bucket for end-user needs
+	// Set value by key
+	Set(key, value []byte) (err error)
+	// Del value by key. It never returns
+	// "not found" error
+	Del(key []byte) (err error)
+}
 
+// A Tv represents read-only transaction
+type Tv interface {
 	Objects() ViewObjects // access objects
 	Feeds() ViewFeeds     // access feeds
+
+	// Bucket returns end-user bucket by name. If bucket doesn't
+	// exist, then it will be empty. The name must not be empty,
+	// otherwise the method returns nil
+	Bucket(name []byte) ViewBucket
 }
 
 // A Tu represents read-write transaction
 type Tu interface {
-
-	// TODO (kostyarin): add bucket for end-user needs
-
 	Objects() UpdateObjects // access objects
 	Feeds() UpdateFeeds     // access feeds
 
+	// Bucket returns end-user bucket by name creating it
+	// if it doesn't exist. The name must not be empty,
+	// otherwise the method returns nil. If the bucket
+	// can't be created, then it is empty and its Set
+	// and Del methods return the error
+	Bucket(name []byte) UpdateBucket
+	// DelBucket deletes end-user bucket with all its
+	// values. It never returns "not found" error
+	DelBucket(name []byte) (err error)
 }
 
 // A DB is common database interface
@@ -182,6 +302,18 @@ type DB interface {
 	Update(func(t Tu) error) (err error) // perform a read-write transaction
 	Stat() (s Stat)                      // statistic
 	Close() (err error)                  // clsoe database
+
+	// Recount rebuilds statistic of the DB walking through all
+	// objects and roots. The statistic is kept incrementally
+	// and the Recount is a repair routine for old or broken
+	// databases. It's slow for big databases
+	Recount() (err error)
+
+	// Backup writes consistent snapshot of the DB to given
+	// writer. The Backup doesn't block other readers and
+	// writers. Use RestoreDriveDB or RestoreMemoryDB to
+	// restore DB of the same type from the backup
+	Backup(w io.Writer) (err error)
 }
 
 // A RootPack represents encoded root object with signature,
@@ -197,13 +329,45 @@ type RootPack struct {
 
 	Hash cipher.SHA256 // hash of the Root filed
 	Sig  cipher.Sig    // signature of the Hash field
+}
 
-	// TODO (kostyarin): move the field from here making it
-	//                   machine local
-	// IsFull is true if the Root has all related objects in
-	// this DB. The field is temporary added and likely to
-	// be moved outside the RootPack
+// A RootFlag represents pin and retention flags of RootMeta
+type RootFlag uint32
+
+// root flags
+const (
+	// RootPinned means that CleanUp of skyobject.Container
+	// never removes the Root and its objects
+	RootPinned RootFlag = 1 << iota
+)
+
+// A RootMeta represents machine-local metadata of a Root. The
+// RootMeta is not a part of signed Root and never sent
+// through network
+type RootMeta struct {
+	// IsFull is true if the Root has all related objects in this DB
 	IsFull bool
+	// Received is time (unix nano) when the Root has been
+	// received or created
+	Received int64
+	// Peer is address of peer from which the Root has been
+	// received, it's empty if the Root is created locally
+	Peer string
+	// Flags of the Root
+	Flags RootFlag
+	// Time is timestamp of the Root (unix nano), it's
+	// set by skyobject and used by ViewRoots.SeqByTime
+	Time int64
+}
+
+// IsPinned reports whether the Root is pinned
+func (r *RootMeta) IsPinned() bool {
+	return r.Flags&RootPinned != 0
+}
+
+// metadata of just added Root
+func newRootMeta() *RootMeta {
+	return &RootMeta{Received: time.Now().UnixNano()}
 }
 
 // A RootError represents error that can be returned by AddRoot method
@@ -271,3 +435,26 @@ func sortMap(m map[cipher.SHA256][]byte) (slice keyValues) {
 	sort.Sort(slice)
 	return
 }
+
+// page keeps state of RangeFrom and ReverseFrom
+type page struct {
+	limit   int  // max items, 0 means no limit
+	visited int  // visited items
+	stop    bool // stopped by ErrStopRange
+}
+
+// full returns true if the page is full or stopped,
+// and current item is first item of next page
+func (p *page) full() bool {
+	return p.stop || (p.limit > 0 && p.visited >= p.limit)
+}
+
+// visit handles result of function of RangeFrom
+func (p *page) visit(err error) error {
+	p.visited++
+	if err == ErrStopRange {
+		p.stop = true
+		return nil
+	}
+	return err
+}
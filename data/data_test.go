@@ -3,6 +3,7 @@ package data
 import (
 	"bytes"
 	"errors"
+	"io"
 	"io/ioutil"
 	"os"
 	"sort"
@@ -10,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/boltdb/bolt"
 	"github.com/skycoin/skycoin/src/cipher"
 	// "github.com/skycoin/skycoin/src/cipher/encoder"
 )
@@ -324,6 +326,212 @@ func TestDB_Stat(t *testing.T) {
 
 }
 
+func testDBRecount(t *testing.T, db DB) {
+
+	pk, _ := cipher.GenerateKeyPair()
+
+	if testFillWithExampleFeed(t, pk, db); t.Failed() {
+		return
+	}
+
+	err := db.Update(func(tx Tu) (err error) {
+		objs := tx.Objects()
+		for _, s := range []string{"one", "two", "three"} {
+			if _, err = objs.Add([]byte(s)); err != nil {
+				return
+			}
+		}
+		if err = objs.Del(cipher.SumSHA256([]byte("two"))); err != nil {
+			return
+		}
+		return tx.Feeds().Roots(pk).Del(1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat := db.Stat()
+	if stat.Objects != 2 {
+		t.Error("wrong amount of objects", stat.Objects)
+	}
+	if stat.Space != Space(len("one")+len("three")) {
+		t.Error("wrong space", stat.Space)
+	}
+	if fs := stat.Feeds[pk]; fs.Roots != 2 {
+		t.Error("wrong amount of roots", fs.Roots)
+	}
+
+	if err = db.Recount(); err != nil {
+		t.Fatal(err)
+	}
+
+	if recounted := db.Stat(); recounted.String() != stat.String() {
+		t.Errorf("wrong statistic after Recount: want %s, got %s",
+			stat.String(), recounted.String())
+	}
+}
+
+func TestDB_Recount(t *testing.T) {
+	// Recount() (err error)
+
+	t.Run("memory", func(t *testing.T) {
+		testDBRecount(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testDBRecount(t, db)
+	})
+
+	t.Run("drive without stat", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		pk, _ := cipher.GenerateKeyPair()
+		if testFillWithExampleFeed(t, pk, db); t.Failed() {
+			return
+		}
+		stat := db.Stat()
+		err := db.(*driveDB).bolt.Update(func(tx *bolt.Tx) error {
+			return tx.DeleteBucket(statBucket) // old DB file
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = db.Recount(); err != nil {
+			t.Fatal(err)
+		}
+		if recounted := db.Stat(); recounted.String() != stat.String() {
+			t.Errorf("wrong statistic after Recount: want %s, got %s",
+				stat.String(), recounted.String())
+		}
+	})
+
+}
+
+func testDBBackup(t *testing.T, db DB,
+	restore func(r io.Reader) (DB, error)) {
+
+	pk, _ := cipher.GenerateKeyPair()
+	if testFillWithExampleFeed(t, pk, db); t.Failed() {
+		return
+	}
+
+	err := db.Update(func(tx Tu) (err error) {
+		if _, err = tx.Objects().Add([]byte("one")); err != nil {
+			return
+		}
+		return tx.Bucket([]byte("bucket")).Set([]byte("key"), []byte("value"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = db.Backup(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes after backup
+	err = db.Update(func(tx Tu) (err error) {
+		_, err = tx.Objects().Add([]byte("two"))
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rdb, err := restore(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rdb.Close()
+
+	rdb.View(func(tx Tv) (_ error) {
+		objs := tx.Objects()
+		if string(objs.Get(cipher.SumSHA256([]byte("one")))) != "one" {
+			t.Error("missing object")
+		}
+		if objs.IsExist(cipher.SumSHA256([]byte("two"))) {
+			t.Error("object added after backup")
+		}
+		if roots := tx.Feeds().Roots(pk); roots == nil {
+			t.Error("missing feed")
+		} else if roots.Last() == nil {
+			t.Error("missing roots")
+		}
+		val := tx.Bucket([]byte("bucket")).Get([]byte("key"))
+		if string(val) != "value" {
+			t.Error("missing value of bucket")
+		}
+		return
+	})
+
+	if rs, s := rdb.Stat(), db.Stat(); rs.Objects != s.Objects-1 {
+		t.Errorf("wrong statistic of restored DB: %s", rs.String())
+	}
+}
+
+func TestDB_Backup(t *testing.T) {
+	// Backup(w io.Writer) (err error)
+
+	t.Run("memory", func(t *testing.T) {
+		testDBBackup(t, NewMemoryDB(), RestoreMemoryDB)
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		path := testPath(t)
+		os.Remove(path) // must not exist
+		defer os.Remove(path)
+		testDBBackup(t, db, func(r io.Reader) (DB, error) {
+			return RestoreDriveDB(path, r)
+		})
+	})
+
+}
+
+func TestNewPersistentMemoryDB(t *testing.T) {
+	// NewPersistentMemoryDB(path string) (DB, error)
+
+	path := testPath(t)
+	defer os.Remove(path)
+
+	db, err := NewPersistentMemoryDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pk, _ := cipher.GenerateKeyPair()
+	if testFillWithExampleFeed(t, pk, db); t.Failed() {
+		return
+	}
+	stat := db.Stat()
+
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if db, err = NewPersistentMemoryDB(path); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if reopened := db.Stat(); reopened.String() != stat.String() {
+		t.Errorf("wrong statistic after reopening: want %s, got %s",
+			stat.String(), reopened.String())
+	}
+	db.View(func(tx Tv) (_ error) {
+		if roots := tx.Feeds().Roots(pk); roots == nil {
+			t.Error("missing feed")
+		} else if roots.Last() == nil {
+			t.Error("missing roots")
+		}
+		return
+	})
+}
+
 func testDBClose(t *testing.T, db DB) {
 	if err := db.Close(); err != nil {
 		t.Error("closing error:", err)
@@ -6,11 +6,13 @@
 // DB.View, DB.Update and Tv, Tu. The Tv is read-only transaction,
 // the Tu is read-write transaction.
 //
-// Any transaction allows access to Objects, Feeds and Roots (through Feeds).
-// Approx. schema is:
+// Any transaction allows access to Objects, Feeds and Roots (through Feeds)
+// and to end-user buckets. Approx. schema is:
 //
 //     objects { key -> value }
 //     feeds   { pk -> roots { seq -> root } }
+//     meta    { pk -> roots { seq -> meta } }
+//     buckets { name -> { key -> value } }
 //
 //
 // Objects. There are ViewObjects and UpdateObjects interfaces that used
@@ -19,5 +21,52 @@
 // read-write transaction returns UpdateObjects. Thus, you will never
 // modify any read-only transaction.
 //
+// Metadata of Roots. A RootPack is signed content of a Root only. Things
+// that are local for a machine (fullness, time when the Root has been
+// received, peer it came from, pin flags) are kept in RootMeta. Use
+// ViewRoots.Meta and UpdateRoots.SetMeta to access it.
+//
+// Pages. RangeFrom methods of objects, feeds and roots start from given
+// key or seq and visit limited number of items returning key or seq of
+// next page. Thus, a feed with millions of Root objects can be viewed
+// page by page. ViewRoots.SeqByTime looks up a Root by RootMeta.Time
+// using secondary index.
+//
+// Versions. DB file keeps version of its format. NewDriveDB upgrades old
+// files running ordered migrations in one transaction, and returns
+// *VersionError if a file is newer than DriveVersion. The same way, log
+// of NewPersistentMemoryDB and backups of memory DB keep MemoryVersion.
+//
+// Statistic. Both databases keep amount and size of objects and roots
+// incrementally, thus DB.Stat is cheap. Use DB.Recount to repair the
+// statistic if it's broken.
+//
+// Cache. Use NewCacheDB to wrap a DB adding size-bounded LRU cache
+// of objects. Hits and misses of the cache are in Stat.Cache.
+//
+// Compression. Use NewCompressedDB to compress objects. Every object
+// keeps its Codec, and hash of an object is hash of uncompressed object.
+// Stat.Space is uncompressed size, Stat.PhysicalSpace is compressed one.
+// The cache should wrap compressed DB to keep uncompressed objects.
+// Use IsCompressed to check that a DB can be used without the wrapper.
+//
+// Encryption. Use NewEncryptedDB to encrypt objects, Root objects, feeds
+// and keys and values of buckets at rest using a passphrase. Compressed
+// DB should wrap encrypted one, since encrypted objects can't be
+// compressed. Use IsEncrypted to check that a DB can be used without
+// the wrapper.
+//
+// Persistence. NewPersistentMemoryDB creates in-memory DB that keeps
+// all changes in append-only log and loads the log on start. The log
+// is compacted in background.
+//
+// Backup. DB.Backup writes consistent snapshot of a DB using single
+// read-only transaction, thus it can be used while the DB is in use.
+// Use RestoreDriveDB or RestoreMemoryDB to restore DB from the backup.
+//
+// Buckets. An application can store its own data (read markers, indexes,
+// settings, etc) in named buckets using Tv.Bucket and Tu.Bucket. Changes
+// of a bucket are commited atomically with other changes of a transaction.
+//
 // TODO (kostyarin) improve the docs
 package data
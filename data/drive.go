@@ -3,6 +3,8 @@ package data
 import (
 	"bytes"
 	"encoding/binary"
+	"io"
+	"os"
 	"sync"
 	"time"
 
@@ -16,20 +18,46 @@ const dbMode = 0644
 
 // names of buckets
 var (
-	objectsBucket = []byte("objects")
-	feedsBucket   = []byte("feeds")
+	infoBucket       = []byte("info")
+	objectsBucket    = []byte("objects")
+	feedsBucket      = []byte("feeds")
+	metaBucket       = []byte("meta")
+	timeBucket       = []byte("time")
+	statBucket       = []byte("stat")
+	successionBucket = []byte("succession")
+	endUserBucket    = []byte("end-user")
+)
+
+// prefixes of keys of succession bucket
+const (
+	successorPrefix   byte = 'n' // next
+	predecessorPrefix byte = 'p' // previous
+)
+
+// keys of stat bucket
+var (
+	objectsCounterKey = []byte("objects")
+	feedCounterPrefix = []byte("feed:") // + pubkey
 )
 
 // buckets:
-//  - objects hash -> []byte (including schemas)
-//  - feeds   pubkey -> (roots) { seq -> root }
+//  - info       "version" -> DriveVersion
+//  - objects    hash -> []byte (including schemas)
+//  - feeds      pubkey -> (roots) { seq -> root }
+//  - meta       pubkey -> (roots) { seq -> meta }
+//  - time       pubkey -> (roots) { time + seq -> nil }
+//  - stat       "objects" | "feed:" + pubkey -> counter
+//  - succession {'n', 'p'} + pubkey -> pubkey
+//  - end-user   name -> { key -> value }
 type driveDB struct {
 	bolt   *bolt.DB
 	closeo sync.Once // boltdb panics when Close closed database
 }
 
 // NewDriveDB creates new database using given path
-// to create or use existsing database file
+// to create or use existsing database file. Old DB
+// files are upgraded to DriveVersion. If the file is
+// newer, then *VersionError returned
 func NewDriveDB(path string) (db DB, err error) {
 	var b *bolt.DB
 	b, err = bolt.Open(path, dbMode, &bolt.Options{
@@ -38,20 +66,49 @@ func NewDriveDB(path string) (db DB, err error) {
 	if err != nil {
 		return
 	}
-	err = b.Update(func(t *bolt.Tx) (err error) {
-		if _, err = t.CreateBucketIfNotExists(objectsBucket); err != nil {
-			return
-		}
-		_, err = t.CreateBucketIfNotExists(feedsBucket)
-		return
+	err = b.Update(func(t *bolt.Tx) error {
+		return upgrade(t) // old DB file or new one
 	})
 	if err != nil {
+		b.Close()
 		return
 	}
 	db = &driveDB{bolt: b}
 	return
 }
 
+// RestoreDriveDB creates DB file by given path from backup
+// made by DB.Backup of DB created by NewDriveDB. The file
+// must not exist
+func RestoreDriveDB(path string, r io.Reader) (db DB, err error) {
+	if err = restoreFile(path, r); err != nil {
+		return
+	}
+	if db, err = NewDriveDB(path); err != nil {
+		os.Remove(path) // not a backup of drive DB
+	}
+	return
+}
+
+// restoreFile creates file by given path
+// and writes content of given reader to it
+func restoreFile(path string, r io.Reader) (err error) {
+	var fl *os.File
+	fl, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, dbMode)
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(fl, r); err != nil {
+		fl.Close()
+		os.Remove(path)
+		return
+	}
+	if err = fl.Close(); err != nil {
+		os.Remove(path)
+	}
+	return
+}
+
 func (d *driveDB) View(fn func(t Tv) error) (err error) {
 	err = d.bolt.View(func(t *bolt.Tx) error {
 		tx := new(driveTv)
@@ -74,43 +131,28 @@ func (d *driveDB) Stat() (s Stat) {
 
 	d.bolt.View(func(t *bolt.Tx) (_ error) {
 
-		// objects
+		st := t.Bucket(statBucket)
 
-		objects := t.Bucket(objectsBucket)
-		s.Objects = objects.Stats().KeyN
+		// objects
 
-		objects.ForEach(func(_, v []byte) (_ error) {
-			s.Space += Space(len(v))
-			return
-		})
+		oc := decodeCounter(st.Get(objectsCounterKey))
+		s.Objects, s.Space = int(oc.amount), Space(oc.volume)
+		s.PhysicalSpace = s.Space
 
 		// feeds (and roots)
 
 		feeds := t.Bucket(feedsBucket)
-		fln := feeds.Stats().KeyN
-
-		if fln == 0 {
-			return // no feeds
-		}
-
-		s.Feeds = make(map[cipher.PubKey]FeedStat, fln)
 
 		feeds.ForEach(func(kk, _ []byte) (_ error) {
 
-			roots := feeds.Bucket(kk)
+			if s.Feeds == nil {
+				s.Feeds = make(map[cipher.PubKey]FeedStat)
+			}
 
-			var fs FeedStat
 			var cp cipher.PubKey
-
-			fs.Roots = roots.Stats().KeyN
-			roots.ForEach(func(_, v []byte) (_ error) {
-				fs.Space += Space(len(v))
-				return
-			})
-
 			copy(cp[:], kk)
-			s.Feeds[cp] = fs
 
+			s.Feeds[cp] = decodeCounter(st.Get(feedCounterKey(kk))).feedStat()
 			return
 		})
 
@@ -121,6 +163,72 @@ func (d *driveDB) Stat() (s Stat) {
 	return
 }
 
+func (d *driveDB) Recount() (err error) {
+	return d.bolt.Update(func(t *bolt.Tx) (err error) {
+		err = t.DeleteBucket(statBucket)
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return
+		}
+		return recount(t)
+	})
+}
+
+// recount creates stat bucket counting all
+// objects and roots of DB
+func recount(t *bolt.Tx) (err error) {
+
+	var st *bolt.Bucket
+	if st, err = t.CreateBucket(statBucket); err != nil {
+		return
+	}
+
+	var oc counter
+	err = t.Bucket(objectsBucket).ForEach(func(_, v []byte) (_ error) {
+		oc.add(1, int64(len(v)))
+		return
+	})
+	if err != nil {
+		return
+	}
+	if err = st.Put(objectsCounterKey, oc.encode()); err != nil {
+		return
+	}
+
+	feeds := t.Bucket(feedsBucket)
+	return feeds.ForEach(func(pk, _ []byte) (err error) {
+		var fc counter
+		err = feeds.Bucket(pk).ForEach(func(_, v []byte) (_ error) {
+			fc.add(1, int64(len(v)))
+			return
+		})
+		if err != nil {
+			return
+		}
+		return st.Put(feedCounterKey(pk), fc.encode())
+	})
+}
+
+func feedCounterKey(pk []byte) []byte {
+	return append(append([]byte{}, feedCounterPrefix...), pk...)
+}
+
+// addCounter adds given values to counter with given key
+func addCounter(st *bolt.Bucket, key []byte, amount, volume int64) error {
+	if amount == 0 && volume == 0 {
+		return nil
+	}
+	c := decodeCounter(st.Get(key))
+	c.add(amount, volume)
+	return st.Put(key, c.encode())
+}
+
+func (d *driveDB) Backup(w io.Writer) error {
+	return d.bolt.View(func(t *bolt.Tx) (err error) {
+		_, err = t.WriteTo(w)
+		return
+	})
+}
+
 func (d *driveDB) Close() (err error) {
 	d.closeo.Do(func() {
 		err = d.bolt.Close()
@@ -135,15 +243,29 @@ type driveTv struct {
 func (d *driveTv) Objects() ViewObjects {
 	o := new(driveObjects)
 	o.bk = d.tx.Bucket(objectsBucket)
+	o.st = d.tx.Bucket(statBucket)
 	return o
 }
 
 func (d *driveTv) Feeds() ViewFeeds {
 	f := new(driveFeeds)
 	f.bk = d.tx.Bucket(feedsBucket)
+	f.sc = d.tx.Bucket(successionBucket)
+	f.mt = d.tx.Bucket(metaBucket)
+	f.tm = d.tx.Bucket(timeBucket)
+	f.st = d.tx.Bucket(statBucket)
 	return &driveViewFeeds{f}
 }
 
+func (d *driveTv) Bucket(name []byte) ViewBucket {
+	if len(name) == 0 {
+		return nil
+	}
+	b := new(driveBucket)
+	b.bk = d.tx.Bucket(endUserBucket).Bucket(name) // can be nil
+	return b
+}
+
 type driveTu struct {
 	tx *bolt.Tx
 }
@@ -151,25 +273,72 @@ type driveTu struct {
 func (d *driveTu) Objects() UpdateObjects {
 	o := new(driveObjects)
 	o.bk = d.tx.Bucket(objectsBucket)
+	o.st = d.tx.Bucket(statBucket)
 	return o
 }
 
 func (d *driveTu) Feeds() UpdateFeeds {
 	f := new(driveFeeds)
 	f.bk = d.tx.Bucket(feedsBucket)
+	f.sc = d.tx.Bucket(successionBucket)
+	f.mt = d.tx.Bucket(metaBucket)
+	f.tm = d.tx.Bucket(timeBucket)
+	f.st = d.tx.Bucket(statBucket)
 	return f
 }
 
+func (d *driveTu) Bucket(name []byte) UpdateBucket {
+	if len(name) == 0 {
+		return nil
+	}
+	bk, err := d.tx.Bucket(endUserBucket).CreateBucketIfNotExists(name)
+	if err != nil {
+		return &driveBucket{err: err} // empty, Set and Del return the err
+	}
+	return &driveBucket{bk: bk}
+}
+
+func (d *driveTu) DelBucket(name []byte) (err error) {
+	if err = d.tx.Bucket(endUserBucket).DeleteBucket(name); err != nil {
+		if err == bolt.ErrBucketNotFound || err == bolt.ErrBucketNameRequired {
+			err = nil
+		}
+	}
+	return
+}
+
 type driveObjects struct {
 	bk *bolt.Bucket
+	st *bolt.Bucket // stat
+}
+
+// put value and update counter
+func (d *driveObjects) put(key, value []byte) (err error) {
+	old := d.bk.Get(key)
+	if err = d.bk.Put(key, value); err != nil {
+		return
+	}
+	if old == nil {
+		return addCounter(d.st, objectsCounterKey, 1, int64(len(value)))
+	}
+	return addCounter(d.st, objectsCounterKey, 0,
+		int64(len(value))-int64(len(old)))
 }
 
 func (d *driveObjects) Set(key cipher.SHA256, value []byte) (err error) {
-	return d.bk.Put(key[:], value)
+	return d.put(key[:], value)
 }
 
 func (d *driveObjects) Del(key cipher.SHA256) (err error) {
-	return d.bk.Delete(key[:])
+	old := d.bk.Get(key[:])
+	if old == nil {
+		return
+	}
+	volume := int64(len(old))
+	if err = d.bk.Delete(key[:]); err != nil {
+		return
+	}
+	return addCounter(d.st, objectsCounterKey, -1, -volume)
 }
 
 func (d *driveObjects) Get(key cipher.SHA256) (val []byte) {
@@ -189,7 +358,7 @@ func (d *driveObjects) GetCopy(key cipher.SHA256) (value []byte) {
 
 func (d *driveObjects) Add(value []byte) (key cipher.SHA256, err error) {
 	key = cipher.SumSHA256(value)
-	err = d.bk.Put(key[:], value)
+	err = d.put(key[:], value)
 	return
 }
 
@@ -199,7 +368,7 @@ func (d *driveObjects) IsExist(key cipher.SHA256) bool {
 
 func (d *driveObjects) SetMap(m map[cipher.SHA256][]byte) (err error) {
 	for _, kv := range sortMap(m) {
-		if err = d.bk.Put(kv.key[:], kv.val); err != nil {
+		if err = d.put(kv.key[:], kv.val); err != nil {
 			return
 		}
 	}
@@ -226,9 +395,52 @@ func (d *driveObjects) Range(
 	return
 }
 
+func (d *driveObjects) RangeErr(
+	fn func(key cipher.SHA256, value []byte, err error) error) error {
+
+	return d.Range(func(key cipher.SHA256, value []byte) error {
+		return fn(key, value, nil)
+	})
+}
+
+func (d *driveObjects) RangeFrom(from cipher.SHA256, limit int,
+	fn func(key cipher.SHA256, value []byte) error) (next cipher.SHA256,
+	more bool, err error) {
+
+	c := d.bk.Cursor()
+	pg := page{limit: limit}
+
+	var ck cipher.SHA256
+
+	for k, v := c.Seek(from[:]); k != nil; k, v = c.Next() {
+		copy(ck[:], k)
+		if pg.full() {
+			return ck, true, nil
+		}
+		if err = pg.visit(fn(ck, v)); err != nil {
+			return
+		}
+	}
+	return
+}
+
 func (d *driveObjects) RangeDel(
 	fn func(key cipher.SHA256, value []byte) (bool, error)) (err error) {
 
+	var dc counter // deleted
+	err = d.rangeDel(fn, &dc)
+	if aerr := addCounter(d.st, objectsCounterKey, -dc.amount,
+		-dc.volume); err == nil {
+
+		err = aerr
+	}
+	return
+}
+
+func (d *driveObjects) rangeDel(
+	fn func(key cipher.SHA256, value []byte) (bool, error),
+	dc *counter) (err error) {
+
 	c := d.bk.Cursor()
 
 	var ck cipher.SHA256
@@ -249,6 +461,7 @@ func (d *driveObjects) RangeDel(
 				if err = c.Delete(); err != nil {
 					return
 				}
+				dc.add(1, int64(len(v)))
 				// coninue seek loop, because after deleting
 				// we have got invalid cusor and we need to
 				// call Seek to make it valid; the Seek will
@@ -268,22 +481,106 @@ func (d *driveObjects) RangeDel(
 
 type driveFeeds struct {
 	bk *bolt.Bucket
+	sc *bolt.Bucket // succession
+	mt *bolt.Bucket // meta
+	tm *bolt.Bucket // time
+	st *bolt.Bucket // stat
+}
+
+func successionKey(prefix byte, pk cipher.PubKey) []byte {
+	return append([]byte{prefix}, pk[:]...)
+}
+
+func (d *driveFeeds) succession(prefix byte,
+	pk cipher.PubKey) (sp cipher.PubKey, ok bool) {
+
+	if val := d.sc.Get(successionKey(prefix, pk)); val != nil {
+		copy(sp[:], val)
+		ok = true
+	}
+	return
+}
+
+func (d *driveFeeds) Successor(pk cipher.PubKey) (cipher.PubKey, bool) {
+	return d.succession(successorPrefix, pk)
+}
+
+func (d *driveFeeds) Predecessor(pk cipher.PubKey) (cipher.PubKey, bool) {
+	return d.succession(predecessorPrefix, pk)
+}
+
+func (d *driveFeeds) SetSuccessor(pk, next cipher.PubKey) (err error) {
+	if sp, ok := d.Successor(pk); ok && sp != next {
+		return ErrSuccessorExists
+	}
+	if pp, ok := d.Predecessor(next); ok && pp != pk {
+		return ErrPredecessorExists
+	}
+	if err = d.sc.Put(successionKey(successorPrefix, pk), next[:]); err != nil {
+		return
+	}
+	return d.sc.Put(successionKey(predecessorPrefix, next), pk[:])
+}
+
+// delete succession records of a feed (both directions)
+func (d *driveFeeds) delSuccession(pk cipher.PubKey) (err error) {
+	if next, ok := d.Successor(pk); ok {
+		err = d.sc.Delete(successionKey(predecessorPrefix, next))
+		if err != nil {
+			return
+		}
+		if err = d.sc.Delete(successionKey(successorPrefix, pk)); err != nil {
+			return
+		}
+	}
+	if prev, ok := d.Predecessor(pk); ok {
+		err = d.sc.Delete(successionKey(successorPrefix, prev))
+		if err != nil {
+			return
+		}
+		err = d.sc.Delete(successionKey(predecessorPrefix, pk))
+	}
+	return
 }
 
 func (d *driveFeeds) Add(pk cipher.PubKey) (err error) {
-	_, err = d.bk.CreateBucketIfNotExists(pk[:])
+	if _, err = d.bk.CreateBucketIfNotExists(pk[:]); err != nil {
+		return
+	}
+	if _, err = d.mt.CreateBucketIfNotExists(pk[:]); err != nil {
+		return
+	}
+	_, err = d.tm.CreateBucketIfNotExists(pk[:])
 	return
 }
 
 func (d *driveFeeds) Del(pk cipher.PubKey) (err error) {
-	if err = d.bk.DeleteBucket(pk[:]); err != nil {
-		if err == bolt.ErrBucketNotFound {
-			err = nil
-		}
+	if err = d.del(pk[:]); err == bolt.ErrBucketNotFound {
+		err = nil
 	}
 	return
 }
 
+// delete roots, meta, time index and succession records of a feed
+func (d *driveFeeds) del(pk []byte) (err error) {
+	if err = d.bk.DeleteBucket(pk); err != nil {
+		return
+	}
+	var cp cipher.PubKey
+	copy(cp[:], pk)
+	if err = d.delSuccession(cp); err != nil {
+		return
+	}
+	for _, bk := range []*bolt.Bucket{d.mt, d.tm} {
+		if err = bk.DeleteBucket(pk); err != nil {
+			if err != bolt.ErrBucketNotFound {
+				return
+			}
+		}
+	}
+	return d.st.Delete(feedCounterKey(pk))
+}
+
 func (d *driveFeeds) IsExist(pk cipher.PubKey) bool {
 	return d.bk.Bucket(pk[:]) != nil
 }
@@ -324,6 +621,27 @@ func (d *driveFeeds) Range(fn func(pk cipher.PubKey) error) (err error) {
 	return
 }
 
+func (d *driveFeeds) RangeFrom(from cipher.PubKey, limit int,
+	fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+	err error) {
+
+	c := d.bk.Cursor()
+	pg := page{limit: limit}
+
+	var cp cipher.PubKey
+
+	for k, _ := c.Seek(from[:]); k != nil; k, _ = c.Next() {
+		copy(cp[:], k)
+		if pg.full() {
+			return cp, true, nil
+		}
+		if err = pg.visit(fn(cp)); err != nil {
+			return
+		}
+	}
+	return
+}
+
 func (d *driveFeeds) RangeDel(
 	fn func(pk cipher.PubKey) (bool, error)) (err error) {
 
@@ -346,7 +664,7 @@ func (d *driveFeeds) RangeDel(
 				return
 			}
 			if del {
-				if err = d.bk.DeleteBucket(k); err != nil {
+				if err = d.del(k); err != nil {
 					return
 				}
 				break // break "next loop" (= continue "seek loop")
@@ -370,6 +688,9 @@ func (d *driveFeeds) Roots(pk cipher.PubKey) UpdateRoots {
 		return nil
 	}
 	r.bk = bk
+	r.mt = d.mt.Bucket(pk[:])
+	r.tm = d.tm.Bucket(pk[:])
+	r.st = d.st
 	return r
 }
 
@@ -384,6 +705,52 @@ func (d *driveViewFeeds) Roots(pk cipher.PubKey) ViewRoots {
 type driveRoots struct {
 	feed cipher.PubKey
 	bk   *bolt.Bucket
+	mt   *bolt.Bucket // meta
+	tm   *bolt.Bucket // time index
+	st   *bolt.Bucket // stat
+}
+
+// key of time index: time (sortable) + seq
+func timeKey(t int64, seqb []byte) (k []byte) {
+	k = make([]byte, 16)
+	binary.BigEndian.PutUint64(k, uint64(t)^(1<<63))
+	copy(k[8:], seqb)
+	return
+}
+
+// putMeta stores metadata of a root updating time index
+func (d *driveRoots) putMeta(seqb []byte, rm *RootMeta) (err error) {
+	if err = d.unindex(seqb); err != nil {
+		return
+	}
+	if err = d.mt.Put(seqb, encoder.Serialize(rm)); err != nil {
+		return
+	}
+	if rm.Time != 0 {
+		err = d.tm.Put(timeKey(rm.Time, seqb), []byte{})
+	}
+	return
+}
+
+// delMeta deletes metadata of a root and its time index
+func (d *driveRoots) delMeta(seqb []byte) (err error) {
+	if err = d.unindex(seqb); err != nil {
+		return
+	}
+	return d.mt.Delete(seqb)
+}
+
+// unindex removes a root from time index
+func (d *driveRoots) unindex(seqb []byte) (err error) {
+	if rm := d.Meta(btou(seqb)); rm != nil && rm.Time != 0 {
+		err = d.tm.Delete(timeKey(rm.Time, seqb))
+	}
+	return
+}
+
+// update counter of the feed
+func (d *driveRoots) count(amount, volume int64) error {
+	return addCounter(d.st, feedCounterKey(d.feed[:]), amount, volume)
 }
 
 func (d *driveRoots) Feed() cipher.PubKey {
@@ -417,7 +784,13 @@ func (d *driveRoots) Add(rp *RootPack) (err error) {
 
 		// not found
 
-		err = d.bk.Put(seqb, data) // store
+		if err = d.bk.Put(seqb, data); err != nil { // store
+			return
+		}
+		if err = d.putMeta(seqb, newRootMeta()); err != nil {
+			return
+		}
+		err = d.count(1, int64(len(data)))
 		return
 	}
 
@@ -452,18 +825,49 @@ func (d *driveRoots) Get(seq uint64) (rp *RootPack) {
 	return
 }
 
-func (d *driveRoots) Del(seq uint64) error {
+func (d *driveRoots) Del(seq uint64) (err error) {
 	seqb := utob(seq)
-	return d.bk.Delete(seqb)
+	old := d.bk.Get(seqb)
+	if old == nil {
+		return
+	}
+	volume := int64(len(old))
+	if err = d.bk.Delete(seqb); err != nil {
+		return
+	}
+	if err = d.delMeta(seqb); err != nil {
+		return
+	}
+	return d.count(-1, -volume)
+}
+
+func (d *driveRoots) Meta(seq uint64) (rm *RootMeta) {
+	data := d.mt.Get(utob(seq))
+	if data == nil {
+		return // nil
+	}
+	rm = new(RootMeta)
+	if err := encoder.DeserializeRaw(data, rm); err != nil {
+		panic(err) // critical
+	}
+	return
+}
+
+func (d *driveRoots) SetMeta(seq uint64, rm *RootMeta) (err error) {
+	seqb := utob(seq)
+	if d.bk.Get(seqb) == nil {
+		return ErrNotFound
+	}
+	return d.putMeta(seqb, rm)
 }
 
 func (d *driveRoots) MarkFull(seq uint64) (err error) {
-	rp := d.Get(seq)
-	if rp == nil {
+	rm := d.Meta(seq)
+	if rm == nil {
 		return ErrNotFound
 	}
-	rp.IsFull = true
-	return d.bk.Put(utob(seq), encoder.Serialize(rp))
+	rm.IsFull = true
+	return d.putMeta(utob(seq), rm)
 }
 
 func (d *driveRoots) Range(fn func(rp *RootPack) error) (err error) {
@@ -504,7 +908,80 @@ func (d *driveRoots) Reverse(fn func(rp *RootPack) error) (err error) {
 	return
 }
 
+func (d *driveRoots) RangeFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (next uint64, more bool, err error) {
+
+	var rp *RootPack
+	c := d.bk.Cursor()
+	pg := page{limit: limit}
+	for k, v := c.Seek(utob(seq)); k != nil; k, v = c.Next() {
+		if pg.full() {
+			return btou(k), true, nil
+		}
+		rp = new(RootPack)
+		if err = encoder.DeserializeRaw(v, rp); err != nil {
+			panic(err) // critical
+		}
+		if err = pg.visit(fn(rp)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *driveRoots) ReverseFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (next uint64, more bool, err error) {
+
+	var rp *RootPack
+	c := d.bk.Cursor()
+	pg := page{limit: limit}
+
+	// seek to given seq or to previous one
+	k, v := c.Seek(utob(seq))
+	if k == nil {
+		k, v = c.Last()
+	} else if btou(k) > seq {
+		k, v = c.Prev()
+	}
+
+	for ; k != nil; k, v = c.Prev() {
+		if pg.full() {
+			return btou(k), true, nil
+		}
+		rp = new(RootPack)
+		if err = encoder.DeserializeRaw(v, rp); err != nil {
+			panic(err) // critical
+		}
+		if err = pg.visit(fn(rp)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *driveRoots) SeqByTime(t int64) (seq uint64, ok bool) {
+	k, _ := d.tm.Cursor().Seek(timeKey(t, utob(0)))
+	if k == nil {
+		return
+	}
+	return btou(k[8:]), true
+}
+
+func (d *driveRoots) Stat() (fs FeedStat) {
+	return decodeCounter(d.st.Get(feedCounterKey(d.feed[:]))).feedStat()
+}
+
 func (d *driveRoots) RangeDel(fn func(rp *RootPack) (bool, error)) (err error) {
+	var dc counter // deleted
+	err = d.rangeDel(fn, &dc)
+	if cerr := d.count(-dc.amount, -dc.volume); err == nil {
+		err = cerr
+	}
+	return
+}
+
+func (d *driveRoots) rangeDel(fn func(rp *RootPack) (bool, error),
+	dc *counter) (err error) {
 
 	var rp *RootPack
 	var del bool
@@ -527,9 +1004,13 @@ func (d *driveRoots) RangeDel(fn func(rp *RootPack) (bool, error)) (err error) {
 				return
 			}
 			if del {
+				if err = d.delMeta(k); err != nil {
+					return
+				}
 				if err = c.Delete(); err != nil {
 					return
 				}
+				dc.add(1, int64(len(v)))
 				break // break "next loop" (= continue "seek loop")
 			}
 			if k, v = c.Next(); k == nil {
@@ -544,21 +1025,117 @@ func (d *driveRoots) RangeDel(fn func(rp *RootPack) (bool, error)) (err error) {
 }
 
 func (d *driveRoots) DelBefore(seq uint64) (err error) {
+	var dc counter // deleted
+	err = d.delBefore(seq, &dc)
+	if cerr := d.count(-dc.amount, -dc.volume); err == nil {
+		err = cerr
+	}
+	return
+}
+
+func (d *driveRoots) delBefore(seq uint64, dc *counter) (err error) {
 
 	c := d.bk.Cursor()
 
-	for k, _ := c.First(); k != nil; k, _ = c.Seek(k) {
+	for k, v := c.First(); k != nil; k, v = c.Seek(k) {
 
 		if btou(k) >= seq {
 			return
 		}
 
+		if err = d.delMeta(k); err != nil {
+			return
+		}
+
 		if err = c.Delete(); err != nil {
 			return
 		}
 
+		dc.add(1, int64(len(v)))
+
+	}
+
+	return
+}
+
+// end-user bucket, the bk is nil for
+// unexisting bucket (read-only)
+type driveBucket struct {
+	bk  *bolt.Bucket
+	err error // error of creating the bucket (read-write only)
+}
+
+func (d *driveBucket) Get(key []byte) (value []byte) {
+	if d.bk == nil {
+		return
+	}
+	return d.bk.Get(key)
+}
+
+func (d *driveBucket) Set(key, value []byte) (err error) {
+	if d.bk == nil {
+		return d.err
+	}
+	return d.bk.Put(key, value)
+}
+
+func (d *driveBucket) Del(key []byte) (err error) {
+	if d.bk == nil {
+		return d.err
+	}
+	return d.bk.Delete(key)
+}
+
+func (d *driveBucket) Range(fn func(key, value []byte) error) (err error) {
+	if d.bk == nil {
+		return
+	}
+	c := d.bk.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err = fn(k, v); err != nil {
+			if err == ErrStopRange {
+				err = nil
+			}
+			return
+		}
+	}
+	return
+}
+
+func (d *driveBucket) Reverse(fn func(key, value []byte) error) (err error) {
+	if d.bk == nil {
+		return
+	}
+	c := d.bk.Cursor()
+	for k, v := c.Last(); k != nil; k, v = c.Prev() {
+		if err = fn(k, v); err != nil {
+			if err == ErrStopRange {
+				err = nil
+			}
+			return
+		}
 	}
+	return
+}
+
+func (d *driveBucket) Prefix(prefix []byte,
+	fn func(key, value []byte) error) (err error) {
 
+	if d.bk == nil {
+		return
+	}
+	c := d.bk.Cursor()
+	for k, v := c.Seek(prefix); k != nil; k, v = c.Next() {
+		if !bytes.HasPrefix(k, prefix) {
+			return // end of the prefix
+		}
+		if err = fn(k, v); err != nil {
+			if err == ErrStopRange {
+				err = nil
+			}
+			return
+		}
+	}
 	return
 }
 
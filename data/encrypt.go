@@ -0,0 +1,965 @@
+package data
+
+import (
+	"bytes"
+	"crypto/aes"
+	stdcipher "crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// encryption related errors
+var (
+	ErrEmptyPassphrase = errors.New("empty passphrase")
+	ErrNotEncrypted    = errors.New("DB is not empty and not encrypted")
+	ErrEncrypted       = errors.New("DB is encrypted")
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrMalformedSealed = errors.New("malformed encrypted value")
+)
+
+// encryption bucket keeps salt of passphrase
+// and value to check the passphrase
+var (
+	encryptionBucket = []byte("data.encryption")
+	saltKey          = []byte("salt")
+	checkKey         = []byte("check")
+)
+
+// iterations of PBKDF2, it's variable for tests
+var deriveIterations = 100000
+
+// deriveKey is PBKDF2-HMAC-SHA256 that
+// returns one block (32 bytes) long key
+func deriveKey(passphrase, salt []byte, iterations int) (key []byte) {
+	prf := hmac.New(sha256.New, passphrase)
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+	u := prf.Sum(nil)
+	key = append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return
+}
+
+// subkey derived from master key
+func subkey(master []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// cryptor encrypts values using AES-GCM and feeds
+// using length preserving Feistel cipher, because
+// encrypted public key must be the same for the
+// same feed, and must have the same length. Keys
+// of end-user buckets encrypted deterministically
+// using AES-CTR with synthetic IV
+type cryptor struct {
+	aead    stdcipher.AEAD
+	feedKey []byte
+
+	keyBlock stdcipher.Block // keys of buckets
+	keyMAC   []byte          // synthetic IV of keys of buckets
+}
+
+func newCryptor(passphrase, salt []byte) (cr *cryptor, err error) {
+	master := deriveKey(passphrase, salt, deriveIterations)
+	var block stdcipher.Block
+	if block, err = aes.NewCipher(subkey(master, "value")); err != nil {
+		return
+	}
+	cr = new(cryptor)
+	if cr.aead, err = stdcipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	cr.feedKey = subkey(master, "feed")
+	if cr.keyBlock, err = aes.NewCipher(subkey(master, "key")); err != nil {
+		return nil, err
+	}
+	cr.keyMAC = subkey(master, "key-iv")
+	return
+}
+
+// seal given value, the result is nonce and sealed value;
+// the ad is additional data that binds sealed value with
+// its key, to prevent swapping of values
+func (c *cryptor) seal(val, ad []byte) (enc []byte) {
+	ns := c.aead.NonceSize()
+	enc = make([]byte, ns, ns+len(val)+c.aead.Overhead())
+	if _, err := rand.Read(enc); err != nil {
+		panic(err) // never happens
+	}
+	return c.aead.Seal(enc, enc, val, ad)
+}
+
+// open sealed value
+func (c *cryptor) open(enc, ad []byte) (val []byte, err error) {
+	ns := c.aead.NonceSize()
+	if len(enc) < ns+c.aead.Overhead() {
+		return nil, ErrMalformedSealed
+	}
+	return c.aead.Open(nil, enc[:ns], enc[ns:], ad)
+}
+
+// opened value or nil if it can't be opened
+func (c *cryptor) opened(enc, ad []byte) (val []byte) {
+	if enc == nil {
+		return
+	}
+	var err error
+	if val, err = c.open(enc, ad); err != nil {
+		return nil
+	}
+	return
+}
+
+// round function of the Feistel cipher
+func (c *cryptor) round(i byte, in, out []byte) {
+	mac := hmac.New(sha256.New, c.feedKey)
+	mac.Write([]byte{i})
+	mac.Write(in)
+	for j, b := range mac.Sum(nil)[:len(out)] {
+		out[j] ^= b
+	}
+}
+
+// encrypt public key of a feed
+func (c *cryptor) encPK(pk cipher.PubKey) (enc cipher.PubKey) {
+	enc = pk
+	l, r := enc[:16], enc[16:]
+	c.round(1, l, r)
+	c.round(2, r, l)
+	c.round(3, l, r)
+	c.round(4, r, l)
+	return
+}
+
+// decrypt public key of a feed
+func (c *cryptor) decPK(enc cipher.PubKey) (pk cipher.PubKey) {
+	pk = enc
+	l, r := pk[:16], pk[16:]
+	c.round(4, r, l)
+	c.round(3, l, r)
+	c.round(2, r, l)
+	c.round(1, l, r)
+	return
+}
+
+// synthetic IV of key of end-user bucket
+func (c *cryptor) keyIV(name, key []byte) []byte {
+	mac := hmac.New(sha256.New, c.keyMAC)
+	mac.Write(bucketAD(name, key))
+	return mac.Sum(nil)[:aes.BlockSize]
+}
+
+// encrypt key of end-user bucket with given name; the
+// result is the same for the same key and name
+func (c *cryptor) encKey(name, key []byte) (enc []byte) {
+	enc = make([]byte, aes.BlockSize+len(key))
+	iv := enc[:aes.BlockSize]
+	copy(iv, c.keyIV(name, key))
+	stdcipher.NewCTR(c.keyBlock, iv).XORKeyStream(enc[aes.BlockSize:], key)
+	return
+}
+
+// decrypt key of end-user bucket with given name
+func (c *cryptor) decKey(name, enc []byte) (key []byte, err error) {
+	if len(enc) < aes.BlockSize {
+		return nil, ErrMalformedSealed
+	}
+	iv := enc[:aes.BlockSize]
+	key = make([]byte, len(enc)-aes.BlockSize)
+	stdcipher.NewCTR(c.keyBlock, iv).XORKeyStream(key, enc[aes.BlockSize:])
+	if !hmac.Equal(iv, c.keyIV(name, key)) {
+		return nil, ErrMalformedSealed
+	}
+	return
+}
+
+// additional data of Root
+func rootAD(enc cipher.PubKey, seq uint64) (ad []byte) {
+	ad = make([]byte, len(enc)+8)
+	copy(ad, enc[:])
+	binary.BigEndian.PutUint64(ad[len(enc):], seq)
+	return
+}
+
+// sealRoot returns copy of given RootPack with encrypted
+// Root field. Underlying DB checks hash of the Root field,
+// thus the Hash of the copy is hash of encrypted Root and
+// original Hash is sealed with the Root
+func (c *cryptor) sealRoot(enc cipher.PubKey, rp *RootPack) *RootPack {
+	val := make([]byte, len(cipher.SHA256{})+len(rp.Root))
+	copy(val, rp.Hash[:])
+	copy(val[len(rp.Hash):], rp.Root)
+	cp := *rp
+	cp.Root = c.seal(val, rootAD(enc, rp.Seq))
+	cp.Hash = cipher.SumSHA256(cp.Root)
+	return &cp
+}
+
+// openRoot is reverse of the sealRoot
+func (c *cryptor) openRoot(enc cipher.PubKey, rp *RootPack) (op *RootPack,
+	err error) {
+
+	var val []byte
+	if val, err = c.open(rp.Root, rootAD(enc, rp.Seq)); err != nil {
+		return
+	}
+	if len(val) < len(cipher.SHA256{}) {
+		return nil, ErrMalformedSealed
+	}
+	cp := *rp
+	copy(cp.Hash[:], val)
+	cp.Root = val[len(cp.Hash):]
+	return &cp, nil
+}
+
+// additional data of a value of end-user bucket
+func bucketAD(name, key []byte) (ad []byte) {
+	ad = make([]byte, 0, len(name)+1+len(key))
+	ad = append(ad, name...)
+	ad = append(ad, 0)
+	return append(ad, key...)
+}
+
+// encryptedDB encrypts values of underlying DB
+type encryptedDB struct {
+	DB
+	cr *cryptor
+}
+
+// NewEncryptedDB wraps given DB encrypting objects, Root objects,
+// public keys of feeds, keys and values of end-user buckets by key
+// derived from given passphrase. Values encrypted using AES-GCM.
+// Hashes of objects, seq numbers, signatures, names of buckets and
+// RootMeta are not encrypted. Encrypted keys of end-user buckets are
+// not ordered, thus Range, Reverse and Prefix methods of the buckets
+// read and sort whole bucket. Given DB must be empty or must be
+// encrypted before, otherwise ErrNotEncrypted returned. If the
+// DB encrypted using another passphrase, then ErrWrongPassphrase
+// returned. Wrap encrypted DB to compress objects (NewCompressedDB),
+// because encrypted objects can't be compressed. Use IsEncrypted
+// to check a DB before using it without the wrapper
+func NewEncryptedDB(db DB, passphrase []byte) (edb DB, err error) {
+	if len(passphrase) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+	var cr *cryptor
+	err = db.Update(func(tx Tu) (err error) {
+		bk := tx.Bucket(encryptionBucket)
+		if salt := bk.Get(saltKey); salt != nil {
+			if cr, err = newCryptor(passphrase, salt); err != nil {
+				return
+			}
+			if _, err = cr.open(bk.Get(checkKey), checkKey); err != nil {
+				return ErrWrongPassphrase
+			}
+			return
+		}
+		var empty = len(tx.Feeds().List()) == 0
+		tx.Objects().Range(func(cipher.SHA256, []byte) error {
+			empty = false
+			return ErrStopRange
+		})
+		if !empty {
+			return ErrNotEncrypted
+		}
+		salt := make([]byte, 32)
+		if _, err = rand.Read(salt); err != nil {
+			return
+		}
+		if cr, err = newCryptor(passphrase, salt); err != nil {
+			return
+		}
+		if err = bk.Set(saltKey, salt); err != nil {
+			return
+		}
+		return bk.Set(checkKey, cr.seal(checkKey, checkKey))
+	})
+	if err != nil {
+		return
+	}
+	edb = &encryptedDB{db, cr}
+	return
+}
+
+// IsEncrypted reports whether given DB has been
+// wrapped using NewEncryptedDB before
+func IsEncrypted(db DB) (yep bool) {
+	db.View(func(tx Tv) (_ error) {
+		yep = tx.Bucket(encryptionBucket).Get(saltKey) != nil
+		return
+	})
+	return
+}
+
+func (d *encryptedDB) View(fn func(t Tv) error) error {
+	return d.DB.View(func(t Tv) error {
+		return fn(&encryptedTv{t, d.cr})
+	})
+}
+
+func (d *encryptedDB) Update(fn func(t Tu) error) error {
+	return d.DB.Update(func(t Tu) error {
+		return fn(&encryptedTu{t, d.cr})
+	})
+}
+
+func (d *encryptedDB) Stat() (s Stat) {
+	s = d.DB.Stat()
+	if s.Feeds == nil {
+		return
+	}
+	feeds := make(map[cipher.PubKey]FeedStat, len(s.Feeds))
+	for enc, fs := range s.Feeds {
+		feeds[d.cr.decPK(enc)] = fs
+	}
+	s.Feeds = feeds
+	return
+}
+
+//
+// transactions
+//
+
+type encryptedTv struct {
+	Tv
+	cr *cryptor
+}
+
+func (e *encryptedTv) Objects() ViewObjects {
+	return &encryptedViewObjects{e.Tv.Objects(), e.cr}
+}
+
+func (e *encryptedTv) Feeds() ViewFeeds {
+	return &encryptedViewFeeds{e.Tv.Feeds(), e.cr}
+}
+
+func (e *encryptedTv) Bucket(name []byte) ViewBucket {
+	bk := e.Tv.Bucket(name)
+	if bk == nil {
+		return nil
+	}
+	return &encryptedViewBucket{bk, name, e.cr}
+}
+
+type encryptedTu struct {
+	Tu
+	cr *cryptor
+}
+
+func (e *encryptedTu) Objects() UpdateObjects {
+	return &encryptedUpdateObjects{e.Tu.Objects(), e.cr}
+}
+
+func (e *encryptedTu) Feeds() UpdateFeeds {
+	return &encryptedUpdateFeeds{e.Tu.Feeds(), e.cr}
+}
+
+func (e *encryptedTu) Bucket(name []byte) UpdateBucket {
+	bk := e.Tu.Bucket(name)
+	if bk == nil {
+		return nil
+	}
+	return &encryptedUpdateBucket{bk, name, e.cr}
+}
+
+//
+// objects
+//
+
+type encryptedViewObjects struct {
+	ViewObjects
+	cr *cryptor
+}
+
+func (e *encryptedViewObjects) Get(key cipher.SHA256) []byte {
+	return e.cr.opened(e.ViewObjects.Get(key), key[:])
+}
+
+func (e *encryptedViewObjects) GetCopy(key cipher.SHA256) []byte {
+	return e.Get(key) // opened value is a copy
+}
+
+func (e *encryptedViewObjects) Range(
+	fn func(key cipher.SHA256, value []byte) error) error {
+
+	return e.ViewObjects.Range(func(key cipher.SHA256, enc []byte) (err error) {
+		var val []byte
+		if val, err = e.cr.open(enc, key[:]); err != nil {
+			return
+		}
+		return fn(key, val)
+	})
+}
+
+func (e *encryptedViewObjects) RangeFrom(from cipher.SHA256, limit int,
+	fn func(key cipher.SHA256, value []byte) error) (cipher.SHA256, bool,
+	error) {
+
+	return e.ViewObjects.RangeFrom(from, limit, e.cr.opening(fn))
+}
+
+func (e *encryptedViewObjects) RangeErr(
+	fn func(key cipher.SHA256, value []byte, err error) error) error {
+
+	return e.ViewObjects.RangeErr(e.cr.openingErr(fn))
+}
+
+// openingErr wraps given function of RangeErr of objects
+func (c *cryptor) openingErr(
+	fn func(key cipher.SHA256, value []byte, err error) error,
+) func(key cipher.SHA256, enc []byte, err error) error {
+
+	return func(key cipher.SHA256, enc []byte, err error) error {
+		var val []byte
+		if err == nil {
+			val, err = c.open(enc, key[:])
+		}
+		return fn(key, val, err)
+	}
+}
+
+// opening wraps given function of RangeFrom of objects
+func (c *cryptor) opening(
+	fn func(key cipher.SHA256, value []byte) error,
+) func(key cipher.SHA256, enc []byte) error {
+
+	return func(key cipher.SHA256, enc []byte) (err error) {
+		var val []byte
+		if val, err = c.open(enc, key[:]); err != nil {
+			return
+		}
+		return fn(key, val)
+	}
+}
+
+type encryptedUpdateObjects struct {
+	UpdateObjects
+	cr *cryptor
+}
+
+func (e *encryptedUpdateObjects) Get(key cipher.SHA256) []byte {
+	return e.cr.opened(e.UpdateObjects.Get(key), key[:])
+}
+
+func (e *encryptedUpdateObjects) GetCopy(key cipher.SHA256) []byte {
+	return e.Get(key) // opened value is a copy
+}
+
+func (e *encryptedUpdateObjects) Range(
+	fn func(key cipher.SHA256, value []byte) error) error {
+
+	return e.UpdateObjects.Range(func(key cipher.SHA256,
+		enc []byte) (err error) {
+
+		var val []byte
+		if val, err = e.cr.open(enc, key[:]); err != nil {
+			return
+		}
+		return fn(key, val)
+	})
+}
+
+func (e *encryptedUpdateObjects) RangeErr(
+	fn func(key cipher.SHA256, value []byte, err error) error) error {
+
+	return e.UpdateObjects.RangeErr(e.cr.openingErr(fn))
+}
+
+func (e *encryptedUpdateObjects) RangeFrom(from cipher.SHA256, limit int,
+	fn func(key cipher.SHA256, value []byte) error) (cipher.SHA256, bool,
+	error) {
+
+	return e.UpdateObjects.RangeFrom(from, limit, e.cr.opening(fn))
+}
+
+func (e *encryptedUpdateObjects) Set(key cipher.SHA256, value []byte) error {
+	return e.UpdateObjects.Set(key, e.cr.seal(value, key[:]))
+}
+
+func (e *encryptedUpdateObjects) Add(value []byte) (key cipher.SHA256,
+	err error) {
+
+	key = cipher.SumSHA256(value)
+	err = e.Set(key, value)
+	return
+}
+
+func (e *encryptedUpdateObjects) SetMap(m map[cipher.SHA256][]byte) error {
+	sealed := make(map[cipher.SHA256][]byte, len(m))
+	for key, val := range m {
+		sealed[key] = e.cr.seal(val, key[:])
+	}
+	return e.UpdateObjects.SetMap(sealed)
+}
+
+func (e *encryptedUpdateObjects) RangeDel(
+	fn func(key cipher.SHA256, value []byte) (bool, error)) error {
+
+	return e.UpdateObjects.RangeDel(func(key cipher.SHA256,
+		enc []byte) (del bool, err error) {
+
+		var val []byte
+		if val, err = e.cr.open(enc, key[:]); err != nil {
+			return
+		}
+		return fn(key, val)
+	})
+}
+
+//
+// feeds
+//
+
+type encryptedViewFeeds struct {
+	ViewFeeds
+	cr *cryptor
+}
+
+func (e *encryptedViewFeeds) IsExist(pk cipher.PubKey) bool {
+	return e.ViewFeeds.IsExist(e.cr.encPK(pk))
+}
+
+func (e *encryptedViewFeeds) List() (list []cipher.PubKey) {
+	list = e.ViewFeeds.List()
+	for i, enc := range list {
+		list[i] = e.cr.decPK(enc)
+	}
+	return
+}
+
+func (e *encryptedViewFeeds) Range(fn func(pk cipher.PubKey) error) error {
+	return e.ViewFeeds.Range(func(enc cipher.PubKey) error {
+		return fn(e.cr.decPK(enc))
+	})
+}
+
+// RangeFrom itterates feeds ordered by encrypted public keys
+func (e *encryptedViewFeeds) RangeFrom(from cipher.PubKey, limit int,
+	fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+	err error) {
+
+	next, more, err = e.ViewFeeds.RangeFrom(e.cr.encPK(from), limit,
+		e.cr.decrypting(fn))
+	if more {
+		next = e.cr.decPK(next)
+	}
+	return
+}
+
+// decrypting wraps given function of RangeFrom of feeds
+func (c *cryptor) decrypting(
+	fn func(pk cipher.PubKey) error) func(enc cipher.PubKey) error {
+
+	return func(enc cipher.PubKey) error {
+		return fn(c.decPK(enc))
+	}
+}
+
+func (e *encryptedViewFeeds) Successor(pk cipher.PubKey) (next cipher.PubKey,
+	ok bool) {
+
+	if next, ok = e.ViewFeeds.Successor(e.cr.encPK(pk)); ok {
+		next = e.cr.decPK(next)
+	}
+	return
+}
+
+func (e *encryptedViewFeeds) Predecessor(pk cipher.PubKey) (
+	prev cipher.PubKey, ok bool) {
+
+	if prev, ok = e.ViewFeeds.Predecessor(e.cr.encPK(pk)); ok {
+		prev = e.cr.decPK(prev)
+	}
+	return
+}
+
+func (e *encryptedViewFeeds) Roots(pk cipher.PubKey) ViewRoots {
+	enc := e.cr.encPK(pk)
+	rs := e.ViewFeeds.Roots(enc)
+	if rs == nil {
+		return nil
+	}
+	return &encryptedViewRoots{rs, pk, enc, e.cr}
+}
+
+type encryptedUpdateFeeds struct {
+	UpdateFeeds
+	cr *cryptor
+}
+
+func (e *encryptedUpdateFeeds) IsExist(pk cipher.PubKey) bool {
+	return e.UpdateFeeds.IsExist(e.cr.encPK(pk))
+}
+
+func (e *encryptedUpdateFeeds) List() (list []cipher.PubKey) {
+	list = e.UpdateFeeds.List()
+	for i, enc := range list {
+		list[i] = e.cr.decPK(enc)
+	}
+	return
+}
+
+func (e *encryptedUpdateFeeds) Range(fn func(pk cipher.PubKey) error) error {
+	return e.UpdateFeeds.Range(func(enc cipher.PubKey) error {
+		return fn(e.cr.decPK(enc))
+	})
+}
+
+// RangeFrom itterates feeds ordered by encrypted public keys
+func (e *encryptedUpdateFeeds) RangeFrom(from cipher.PubKey, limit int,
+	fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+	err error) {
+
+	next, more, err = e.UpdateFeeds.RangeFrom(e.cr.encPK(from), limit,
+		e.cr.decrypting(fn))
+	if more {
+		next = e.cr.decPK(next)
+	}
+	return
+}
+
+func (e *encryptedUpdateFeeds) Successor(pk cipher.PubKey) (
+	next cipher.PubKey, ok bool) {
+
+	if next, ok = e.UpdateFeeds.Successor(e.cr.encPK(pk)); ok {
+		next = e.cr.decPK(next)
+	}
+	return
+}
+
+func (e *encryptedUpdateFeeds) Predecessor(pk cipher.PubKey) (
+	prev cipher.PubKey, ok bool) {
+
+	if prev, ok = e.UpdateFeeds.Predecessor(e.cr.encPK(pk)); ok {
+		prev = e.cr.decPK(prev)
+	}
+	return
+}
+
+func (e *encryptedUpdateFeeds) SetSuccessor(pk, next cipher.PubKey) error {
+	return e.UpdateFeeds.SetSuccessor(e.cr.encPK(pk), e.cr.encPK(next))
+}
+
+func (e *encryptedUpdateFeeds) Add(pk cipher.PubKey) error {
+	return e.UpdateFeeds.Add(e.cr.encPK(pk))
+}
+
+func (e *encryptedUpdateFeeds) Del(pk cipher.PubKey) error {
+	return e.UpdateFeeds.Del(e.cr.encPK(pk))
+}
+
+func (e *encryptedUpdateFeeds) RangeDel(
+	fn func(pk cipher.PubKey) (bool, error)) error {
+
+	return e.UpdateFeeds.RangeDel(func(enc cipher.PubKey) (bool, error) {
+		return fn(e.cr.decPK(enc))
+	})
+}
+
+func (e *encryptedUpdateFeeds) Roots(pk cipher.PubKey) UpdateRoots {
+	enc := e.cr.encPK(pk)
+	rs := e.UpdateFeeds.Roots(enc)
+	if rs == nil {
+		return nil
+	}
+	return &encryptedUpdateRoots{rs, pk, enc, e.cr}
+}
+
+//
+// roots
+//
+
+type encryptedViewRoots struct {
+	ViewRoots
+	pk, enc cipher.PubKey
+	cr      *cryptor
+}
+
+func (e *encryptedViewRoots) Feed() cipher.PubKey {
+	return e.pk
+}
+
+func (e *encryptedViewRoots) opened(rp *RootPack) *RootPack {
+	if rp == nil {
+		return nil
+	}
+	op, err := e.cr.openRoot(e.enc, rp)
+	if err != nil {
+		return nil
+	}
+	return op
+}
+
+func (e *encryptedViewRoots) Last() *RootPack {
+	return e.opened(e.ViewRoots.Last())
+}
+
+func (e *encryptedViewRoots) Get(seq uint64) *RootPack {
+	return e.opened(e.ViewRoots.Get(seq))
+}
+
+func (e *encryptedViewRoots) Range(fn func(rp *RootPack) error) error {
+	return e.ViewRoots.Range(func(rp *RootPack) (err error) {
+		if rp, err = e.cr.openRoot(e.enc, rp); err != nil {
+			return
+		}
+		return fn(rp)
+	})
+}
+
+func (e *encryptedViewRoots) Reverse(fn func(rp *RootPack) error) error {
+	return e.ViewRoots.Reverse(func(rp *RootPack) (err error) {
+		if rp, err = e.cr.openRoot(e.enc, rp); err != nil {
+			return
+		}
+		return fn(rp)
+	})
+}
+
+func (e *encryptedViewRoots) RangeFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (uint64, bool, error) {
+
+	return e.ViewRoots.RangeFrom(seq, limit, e.cr.openingRoots(e.enc, fn))
+}
+
+func (e *encryptedViewRoots) ReverseFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (uint64, bool, error) {
+
+	return e.ViewRoots.ReverseFrom(seq, limit, e.cr.openingRoots(e.enc, fn))
+}
+
+// openingRoots wraps given function of RangeFrom of roots
+func (c *cryptor) openingRoots(enc cipher.PubKey,
+	fn func(rp *RootPack) error) func(rp *RootPack) error {
+
+	return func(rp *RootPack) (err error) {
+		if rp, err = c.openRoot(enc, rp); err != nil {
+			return
+		}
+		return fn(rp)
+	}
+}
+
+type encryptedUpdateRoots struct {
+	UpdateRoots
+	pk, enc cipher.PubKey
+	cr      *cryptor
+}
+
+func (e *encryptedUpdateRoots) Feed() cipher.PubKey {
+	return e.pk
+}
+
+func (e *encryptedUpdateRoots) opened(rp *RootPack) *RootPack {
+	if rp == nil {
+		return nil
+	}
+	op, err := e.cr.openRoot(e.enc, rp)
+	if err != nil {
+		return nil
+	}
+	return op
+}
+
+func (e *encryptedUpdateRoots) Last() *RootPack {
+	return e.opened(e.UpdateRoots.Last())
+}
+
+func (e *encryptedUpdateRoots) Get(seq uint64) *RootPack {
+	return e.opened(e.UpdateRoots.Get(seq))
+}
+
+func (e *encryptedUpdateRoots) Range(fn func(rp *RootPack) error) error {
+	return e.UpdateRoots.Range(func(rp *RootPack) (err error) {
+		if rp, err = e.cr.openRoot(e.enc, rp); err != nil {
+			return
+		}
+		return fn(rp)
+	})
+}
+
+func (e *encryptedUpdateRoots) Reverse(fn func(rp *RootPack) error) error {
+	return e.UpdateRoots.Reverse(func(rp *RootPack) (err error) {
+		if rp, err = e.cr.openRoot(e.enc, rp); err != nil {
+			return
+		}
+		return fn(rp)
+	})
+}
+
+func (e *encryptedUpdateRoots) RangeFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (uint64, bool, error) {
+
+	return e.UpdateRoots.RangeFrom(seq, limit, e.cr.openingRoots(e.enc, fn))
+}
+
+func (e *encryptedUpdateRoots) ReverseFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (uint64, bool, error) {
+
+	return e.UpdateRoots.ReverseFrom(seq, limit,
+		e.cr.openingRoots(e.enc, fn))
+}
+
+func (e *encryptedUpdateRoots) Add(rp *RootPack) error {
+	if cipher.SumSHA256(rp.Root) != rp.Hash {
+		return newRootError(e.pk, rp, "wrong hash of the root")
+	}
+	return e.UpdateRoots.Add(e.cr.sealRoot(e.enc, rp))
+}
+
+func (e *encryptedUpdateRoots) RangeDel(
+	fn func(rp *RootPack) (bool, error)) error {
+
+	return e.UpdateRoots.RangeDel(func(rp *RootPack) (del bool, err error) {
+		if rp, err = e.cr.openRoot(e.enc, rp); err != nil {
+			return
+		}
+		return fn(rp)
+	})
+}
+
+//
+// buckets
+//
+
+type encryptedViewBucket struct {
+	ViewBucket
+	name []byte
+	cr   *cryptor
+}
+
+func (e *encryptedViewBucket) Get(key []byte) []byte {
+	enc := e.ViewBucket.Get(e.cr.encKey(e.name, key))
+	return e.cr.opened(enc, bucketAD(e.name, key))
+}
+
+// decrypted key-value pair of a bucket
+type bucketItem struct {
+	key, value []byte
+}
+
+// items returns decrypted keys and values with given prefix
+// ordered by key; the order of underlying bucket is order
+// of encrypted keys, thus whole bucket is read
+func (e *encryptedViewBucket) items(prefix []byte) (items []bucketItem,
+	err error) {
+
+	err = e.ViewBucket.Range(func(ek, enc []byte) (err error) {
+		var key, val []byte
+		if key, err = e.cr.decKey(e.name, ek); err != nil {
+			return
+		}
+		if !bytes.HasPrefix(key, prefix) {
+			return
+		}
+		if val, err = e.cr.open(enc, bucketAD(e.name, key)); err != nil {
+			return
+		}
+		items = append(items, bucketItem{key, val})
+		return
+	})
+	sort.Slice(items, func(i, j int) bool {
+		return bytes.Compare(items[i].key, items[j].key) < 0
+	})
+	return
+}
+
+// call given function for given items
+func rangeItems(items []bucketItem, reverse bool,
+	fn func(key, value []byte) error) (err error) {
+
+	for i := range items {
+		if reverse {
+			i = len(items) - 1 - i
+		}
+		if err = fn(items[i].key, items[i].value); err != nil {
+			if err == ErrStopRange {
+				err = nil
+			}
+			return
+		}
+	}
+	return
+}
+
+func (e *encryptedViewBucket) Range(fn func(key, value []byte) error) error {
+	items, err := e.items(nil)
+	if err != nil {
+		return err
+	}
+	return rangeItems(items, false, fn)
+}
+
+func (e *encryptedViewBucket) Reverse(fn func(key, value []byte) error) error {
+	items, err := e.items(nil)
+	if err != nil {
+		return err
+	}
+	return rangeItems(items, true, fn)
+}
+
+func (e *encryptedViewBucket) Prefix(prefix []byte,
+	fn func(key, value []byte) error) error {
+
+	items, err := e.items(prefix)
+	if err != nil {
+		return err
+	}
+	return rangeItems(items, false, fn)
+}
+
+type encryptedUpdateBucket struct {
+	UpdateBucket
+	name []byte
+	cr   *cryptor
+}
+
+func (e *encryptedUpdateBucket) view() *encryptedViewBucket {
+	return &encryptedViewBucket{e.UpdateBucket, e.name, e.cr}
+}
+
+func (e *encryptedUpdateBucket) Get(key []byte) []byte {
+	return e.view().Get(key)
+}
+
+func (e *encryptedUpdateBucket) Range(
+	fn func(key, value []byte) error) error {
+
+	return e.view().Range(fn)
+}
+
+func (e *encryptedUpdateBucket) Reverse(
+	fn func(key, value []byte) error) error {
+
+	return e.view().Reverse(fn)
+}
+
+func (e *encryptedUpdateBucket) Prefix(prefix []byte,
+	fn func(key, value []byte) error) error {
+
+	return e.view().Prefix(prefix, fn)
+}
+
+func (e *encryptedUpdateBucket) Set(key, value []byte) error {
+	return e.UpdateBucket.Set(e.cr.encKey(e.name, key),
+		e.cr.seal(value, bucketAD(e.name, key)))
+}
+
+func (e *encryptedUpdateBucket) Del(key []byte) error {
+	return e.UpdateBucket.Del(e.cr.encKey(e.name, key))
+}
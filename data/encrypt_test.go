@@ -0,0 +1,227 @@
+package data
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func Test_cryptor_encPK(t *testing.T) {
+
+	cr, err := newCryptor([]byte("passphrase"), []byte("salt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pk, _ := cipher.GenerateKeyPair()
+
+	enc := cr.encPK(pk)
+	if enc == pk {
+		t.Error("not encrypted")
+	}
+	if cr.encPK(pk) != enc {
+		t.Error("not deterministic")
+	}
+	if cr.decPK(enc) != pk {
+		t.Error("wrong decrypted key")
+	}
+}
+
+func testEncryptedDB(t *testing.T, db DB) {
+
+	defer func(n int) { deriveIterations = n }(deriveIterations)
+	deriveIterations = 16
+
+	pass := []byte("passphrase")
+	pk, _ := cipher.GenerateKeyPair()
+	object := []byte("secret object")
+	rp := getRootPack(0, "secret root")
+
+	err := db.Update(func(tx Tu) error {
+		return tx.Feeds().Add(pk)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewEncryptedDB(db, pass); err != ErrNotEncrypted {
+		t.Fatal("unexpected error:", err)
+	}
+	err = db.Update(func(tx Tu) error {
+		return tx.Feeds().Del(pk)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if IsEncrypted(db) {
+		t.Error("not encrypted DB is encrypted")
+	}
+
+	edb, err := NewEncryptedDB(db, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsEncrypted(db) {
+		t.Error("encrypted DB is not encrypted")
+	}
+
+	var key cipher.SHA256
+	err = edb.Update(func(tx Tu) (err error) {
+		if key, err = tx.Objects().Add(object); err != nil {
+			return
+		}
+		feeds := tx.Feeds()
+		if err = feeds.Add(pk); err != nil {
+			return
+		}
+		if err = feeds.Roots(pk).Add(&rp); err != nil {
+			return
+		}
+		return tx.Bucket([]byte("bucket")).Set([]byte("key"), object)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("decrypted", func(t *testing.T) {
+		edb.View(func(tx Tv) (_ error) {
+			if !bytes.Equal(tx.Objects().Get(key), object) {
+				t.Error("wrong object")
+			}
+			feeds := tx.Feeds()
+			if list := feeds.List(); len(list) != 1 || list[0] != pk {
+				t.Error("wrong list of feeds:", list)
+			}
+			roots := feeds.Roots(pk)
+			if roots == nil {
+				t.Fatal("missing feed")
+			}
+			if got := roots.Get(0); got == nil {
+				t.Error("missing root")
+			} else if got.Hash != rp.Hash || !bytes.Equal(got.Root, rp.Root) {
+				t.Error("wrong root")
+			}
+			val := tx.Bucket([]byte("bucket")).Get([]byte("key"))
+			if !bytes.Equal(val, object) {
+				t.Error("wrong value of bucket")
+			}
+			feeds.RangeFrom(cipher.PubKey{}, 1, func(got cipher.PubKey) error {
+				if got != pk {
+					t.Error("wrong feed of RangeFrom")
+				}
+				return nil
+			})
+			roots.RangeFrom(0, 1, func(got *RootPack) error {
+				if got.Hash != rp.Hash {
+					t.Error("wrong root of RangeFrom")
+				}
+				return nil
+			})
+			return
+		})
+		if _, ok := edb.Stat().Feeds[pk]; !ok {
+			t.Error("missing feed in statistic")
+		}
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		db.View(func(tx Tv) (_ error) {
+			if val := tx.Objects().Get(key); bytes.Contains(val, object) {
+				t.Error("object is not encrypted")
+			}
+			if tx.Feeds().IsExist(pk) {
+				t.Error("feed is not encrypted")
+			}
+			bk := tx.Bucket([]byte("bucket"))
+			if bk.Get([]byte("key")) != nil {
+				t.Error("key of bucket is not encrypted")
+			}
+			bk.Range(func(key, val []byte) (_ error) {
+				if bytes.Contains(key, []byte("key")) {
+					t.Error("key of bucket is not encrypted")
+				}
+				if bytes.Contains(val, object) {
+					t.Error("value of bucket is not encrypted")
+				}
+				return
+			})
+			return
+		})
+	})
+
+	t.Run("bucket", func(t *testing.T) {
+		name := []byte("ordered")
+		err := edb.Update(func(tx Tu) (err error) {
+			bk := tx.Bucket(name)
+			for _, k := range []string{"b", "ab", "c", "a", "x"} {
+				if err = bk.Set([]byte(k), []byte("v"+k)); err != nil {
+					return
+				}
+			}
+			return bk.Del([]byte("x"))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		each := func(key, value []byte) (_ error) {
+			if string(value) != "v"+string(key) {
+				t.Errorf("wrong value of %q: %q", key, value)
+			}
+			got += string(key) + " "
+			return
+		}
+		edb.View(func(tx Tv) (_ error) {
+			bk := tx.Bucket(name)
+			for _, rc := range []struct {
+				method, want string
+				fn           func() error
+			}{
+				{"Range", "a ab b c ", func() error {
+					return bk.Range(each)
+				}},
+				{"Reverse", "c b ab a ", func() error {
+					return bk.Reverse(each)
+				}},
+				{"Prefix", "a ab ", func() error {
+					return bk.Prefix([]byte("a"), each)
+				}},
+			} {
+				got = ""
+				if err := rc.fn(); err != nil {
+					t.Error(rc.method, err)
+				} else if got != rc.want {
+					t.Errorf("wrong %s: %q", rc.method, got)
+				}
+			}
+			return
+		})
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		if _, err := NewEncryptedDB(db, []byte("wrong")); err != ErrWrongPassphrase {
+			t.Error("unexpected error:", err)
+		}
+		if _, err := NewEncryptedDB(db, pass); err != nil {
+			t.Error(err)
+		}
+	})
+
+}
+
+func TestNewEncryptedDB(t *testing.T) {
+	// NewEncryptedDB(db DB, passphrase []byte) (DB, error)
+
+	t.Run("memory", func(t *testing.T) {
+		testEncryptedDB(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testEncryptedDB(t, db)
+	})
+
+}
@@ -224,6 +224,73 @@ func TestViewFeeds_Range(t *testing.T) {
 
 }
 
+func testViewFeedsRangeFrom(t *testing.T, db DB) {
+
+	pks := testOrderedPublicKeys()
+
+	for _, pk := range pks {
+		if testFillWithExampleFeed(t, pk, db); t.Failed() {
+			return
+		}
+	}
+
+	err := db.View(func(tx Tv) (_ error) {
+		feeds := tx.Feeds()
+
+		var list []cipher.PubKey
+		fn := func(pk cipher.PubKey) (_ error) {
+			list = append(list, pk)
+			return
+		}
+
+		next, more, err := feeds.RangeFrom(cipher.PubKey{}, 1, fn)
+		if err != nil {
+			t.Error(err)
+		}
+		if !more || next != pks[1] {
+			t.Error("wrong continuation:", next.Hex(), more)
+		}
+		if next, more, err = feeds.RangeFrom(next, 1, fn); err != nil {
+			t.Error(err)
+		}
+		if more || next != (cipher.PubKey{}) {
+			t.Error("unexpected continuation:", next.Hex(), more)
+		}
+		testComparePublicKeyLists(t, pks, list)
+
+		list = list[:0]
+		if _, more, err = feeds.RangeFrom(pks[0], 0, fn); err != nil {
+			t.Error(err)
+		}
+		if more {
+			t.Error("unexpected continuation")
+		}
+		testComparePublicKeyLists(t, pks, list)
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestViewFeeds_RangeFrom(t *testing.T) {
+	// RangeFrom(from cipher.PubKey, limit int,
+	//     fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+	//     err error)
+
+	t.Run("memory", func(t *testing.T) {
+		testViewFeedsRangeFrom(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testViewFeedsRangeFrom(t, db)
+	})
+
+}
+
 func testViewFeedsRoots(t *testing.T, db DB) {
 
 	pk, _ := cipher.GenerateKeyPair()
@@ -303,6 +370,15 @@ func TestUpdateFeeds_Range(t *testing.T) {
 
 }
 
+func TestUpdateFeeds_RangeFrom(t *testing.T) {
+	// RangeFrom(from cipher.PubKey, limit int,
+	//     fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+	//     err error)
+
+	t.Skip("inherited from ViewFeeds")
+
+}
+
 func testUpdateFeedsAdd(t *testing.T, db DB) {
 
 	pk, _ := cipher.GenerateKeyPair()
@@ -604,3 +680,118 @@ func TestUpdateFeeds_Roots(t *testing.T) {
 	})
 
 }
+
+func testUpdateFeedsSetSuccessor(t *testing.T, db DB) {
+
+	pks := testOrderedPublicKeys()
+	old, next := pks[0], pks[1]
+
+	err := db.View(func(tx Tv) (_ error) {
+		feeds := tx.Feeds()
+		if _, ok := feeds.Successor(old); ok {
+			t.Error("unexpected successor")
+		}
+		if _, ok := feeds.Predecessor(next); ok {
+			t.Error("unexpected predecessor")
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Update(func(tx Tu) error {
+		return tx.Feeds().SetSuccessor(old, next)
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = db.View(func(tx Tv) (_ error) {
+		feeds := tx.Feeds()
+		if pk, ok := feeds.Successor(old); !ok {
+			t.Error("missing successor")
+		} else if pk != next {
+			t.Error("wrong successor")
+		}
+		if pk, ok := feeds.Predecessor(next); !ok {
+			t.Error("missing predecessor")
+		} else if pk != old {
+			t.Error("wrong predecessor")
+		}
+		if _, ok := feeds.Successor(next); ok {
+			t.Error("unexpected successor")
+		}
+		if feeds.List() != nil {
+			t.Error("succession creates feeds")
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// first wins
+	other, _ := cipher.GenerateKeyPair()
+	err = db.Update(func(tx Tu) (_ error) {
+		feeds := tx.Feeds()
+		if err := feeds.SetSuccessor(old, next); err != nil {
+			t.Error(err) // the same
+		}
+		if err := feeds.SetSuccessor(old, other); err != ErrSuccessorExists {
+			t.Error("wrong error:", err)
+		}
+		err := feeds.SetSuccessor(other, next)
+		if err != ErrPredecessorExists {
+			t.Error("wrong error:", err)
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// delete feed
+	err = db.Update(func(tx Tu) (err error) {
+		feeds := tx.Feeds()
+		if err = feeds.Add(old); err != nil {
+			return
+		}
+		return feeds.Del(old)
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = db.View(func(tx Tv) (_ error) {
+		feeds := tx.Feeds()
+		if _, ok := feeds.Successor(old); ok {
+			t.Error("successor of deleted feed")
+		}
+		if _, ok := feeds.Predecessor(next); ok {
+			t.Error("deleted feed is predecessor")
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestUpdateFeeds_SetSuccessor(t *testing.T) {
+	// SetSuccessor(pk, next cipher.PubKey) (err error)
+
+	t.Run("memory", func(t *testing.T) {
+		testUpdateFeedsSetSuccessor(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testUpdateFeedsSetSuccessor(t, db)
+	})
+
+}
@@ -3,6 +3,8 @@ package data
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/tidwall/buntdb"
@@ -12,8 +14,14 @@ import (
 )
 
 // buckets:
-//  - objects hash -> []byte (including schemas)
-//  - feeds   pubkey -> { seq -> RootPack }
+//  - objects    hash -> []byte (including schemas)
+//  - feeds      pubkey -> { seq -> RootPack }
+//  - meta       pubkey -> { seq -> RootMeta }
+//  - time       pubkey -> { time + seq -> "" }
+//  - stat       objects, feed pubkey -> counter
+//  - succession {next, prev} -> pubkey
+//  - end-user   name -> { key -> value }
+//  - info       "version" -> MemoryVersion
 type memoryDB struct {
 	bunt *buntdb.DB
 }
@@ -24,6 +32,62 @@ func NewMemoryDB() (db DB) {
 	if err != nil {
 		panic(err)
 	}
+	if err = upgradeMemory(bunt); err != nil {
+		panic(err)
+	}
+	db = &memoryDB{bunt}
+	return
+}
+
+// NewPersistentMemoryDB creates new database in memory that
+// persisted to append-only log by given path. If the log exists,
+// then the database loaded from it. Changes synced to disk every
+// second, thus a crash loses up to one second of changes. The log
+// is compacted in background when it grows twice after previous
+// compaction (and it's greater than 32MB). Old logs are upgraded
+// to MemoryVersion. If the log is newer, then *VersionError returned
+func NewPersistentMemoryDB(path string) (db DB, err error) {
+	var bunt *buntdb.DB
+	if bunt, err = buntdb.Open(path); err != nil {
+		return
+	}
+	if err = upgradeMemory(bunt); err != nil {
+		bunt.Close()
+		return
+	}
+	db = &memoryDB{bunt}
+	return
+}
+
+// RestorePersistentMemoryDB creates log of DB by given path from
+// backup made by DB.Backup of DB created by NewMemoryDB or by
+// NewPersistentMemoryDB. The log must not exist
+func RestorePersistentMemoryDB(path string, r io.Reader) (db DB, err error) {
+	if err = restoreFile(path, r); err != nil {
+		return
+	}
+	if db, err = NewPersistentMemoryDB(path); err != nil {
+		os.Remove(path) // not a backup of memory DB
+	}
+	return
+}
+
+// RestoreMemoryDB creates new database in memory from backup
+// made by DB.Backup of DB created by NewMemoryDB or by
+// NewPersistentMemoryDB. Old backups are upgraded to MemoryVersion
+func RestoreMemoryDB(r io.Reader) (db DB, err error) {
+	var bunt *buntdb.DB
+	if bunt, err = buntdb.Open(":memory:"); err != nil {
+		return
+	}
+	if err = bunt.Load(r); err != nil {
+		bunt.Close()
+		return
+	}
+	if err = upgradeMemory(bunt); err != nil {
+		bunt.Close()
+		return
+	}
 	db = &memoryDB{bunt}
 	return
 }
@@ -46,43 +110,29 @@ func (m *memoryDB) Stat() (s Stat) {
 
 		// objects
 
-		t.AscendKeys("object:*", func(_, v string) bool {
-			s.Objects++
-			s.Space += Space(len(v))
-			return true // continue
-		})
+		oc := getMemoryCounter(t, memoryObjectsCounter)
+		s.Objects, s.Space = int(oc.amount), Space(oc.volume)
+		s.PhysicalSpace = s.Space
 
 		// feeds (and roots)
 
-		s.Feeds = make(map[cipher.PubKey]FeedStat)
-
-		t.AscendKeys("feed:*", func(k, v string) bool {
-
-			// k is "feed:pub_key:seq" or "feed:pub_key"
+		t.AscendKeys(memoryFeedCounter+"*", func(k, v string) bool {
 
-			if len(v) == 0 {
-				return true // (continue) is not a root object
+			if s.Feeds == nil {
+				s.Feeds = make(map[cipher.PubKey]FeedStat)
 			}
 
-			pk, err := cipher.PubKeyFromHex(strings.Split(k, ":")[1])
+			pk, err := cipher.PubKeyFromHex(strings.TrimPrefix(k,
+				memoryFeedCounter))
 			if err != nil {
 				panic(err)
 			}
 
-			fs := s.Feeds[pk]
-			fs.Roots++
-			fs.Space += Space(len(v))
-
-			s.Feeds[pk] = fs
-
+			s.Feeds[pk] = decodeCounter(decValue(v)).feedStat()
 			return true // continue
 
 		})
 
-		if len(s.Feeds) == 0 {
-			s.Feeds = nil
-		}
-
 		return
 
 	})
@@ -90,6 +140,49 @@ func (m *memoryDB) Stat() (s Stat) {
 	return
 }
 
+func (m *memoryDB) Recount() (err error) {
+	return m.bunt.Update(func(t *buntdb.Tx) (err error) {
+
+		// objects
+
+		var oc counter
+		t.AscendKeys("object:*", func(_, v string) bool {
+			oc.add(1, int64(len(v)/2))
+			return true // continue
+		})
+		if err = setMemoryCounter(t, memoryObjectsCounter, oc); err != nil {
+			return
+		}
+
+		// feeds (and roots)
+
+		fcs := make(map[string]counter)
+		t.AscendKeys("feed:*", func(k, v string) bool {
+
+			// k is "feed:pub_key:seq" or "feed:pub_key"
+
+			pk := strings.Split(k, ":")[1]
+			fc := fcs[pk]
+			if len(v) != 0 {
+				fc.add(1, int64(len(v)/2))
+			}
+			fcs[pk] = fc
+			return true // continue
+
+		})
+		for pk, fc := range fcs {
+			if err = setMemoryCounter(t, memoryFeedCounter+pk, fc); err != nil {
+				return
+			}
+		}
+		return
+	})
+}
+
+func (m *memoryDB) Backup(w io.Writer) error {
+	return m.bunt.Save(w)
+}
+
 func (m *memoryDB) Close() error {
 	return m.bunt.Close()
 }
@@ -106,6 +199,13 @@ func (m *memoryTv) Feeds() ViewFeeds {
 	return &memoryViewFeeds{memoryFeeds{m.tx}}
 }
 
+func (m *memoryTv) Bucket(name []byte) ViewBucket {
+	if len(name) == 0 {
+		return nil
+	}
+	return &memoryBucket{bucketPrefix(name), m.tx}
+}
+
 type memoryTu struct {
 	tx *buntdb.Tx
 }
@@ -118,6 +218,34 @@ func (m *memoryTu) Feeds() UpdateFeeds {
 	return &memoryFeeds{m.tx}
 }
 
+func (m *memoryTu) Bucket(name []byte) UpdateBucket {
+	if len(name) == 0 {
+		return nil
+	}
+	return &memoryBucket{bucketPrefix(name), m.tx}
+}
+
+func (m *memoryTu) DelBucket(name []byte) (err error) {
+	if len(name) == 0 {
+		return
+	}
+
+	// waiting for #24 of buntdb
+	collect := []string{}
+
+	m.tx.AscendKeys(bucketPrefix(name)+"*", func(k, _ string) bool {
+		collect = append(collect, k)
+		return true // continue
+	})
+
+	for _, k := range collect {
+		if _, err = m.tx.Delete(k); err != nil {
+			return
+		}
+	}
+	return
+}
+
 type memoryObjects struct {
 	tx *buntdb.Tx
 }
@@ -127,15 +255,28 @@ func (m *memoryObjects) key(key cipher.SHA256) string {
 }
 
 func (m *memoryObjects) Set(key cipher.SHA256, value []byte) (err error) {
-	_, _, err = m.tx.Set(m.key(key), encValue(value), nil)
-	return
+	var prev string
+	var replaced bool
+	prev, replaced, err = m.tx.Set(m.key(key), encValue(value), nil)
+	if err != nil {
+		return
+	}
+	if replaced {
+		return addMemoryCounter(m.tx, memoryObjectsCounter, 0,
+			int64(len(value))-int64(len(prev)/2))
+	}
+	return addMemoryCounter(m.tx, memoryObjectsCounter, 1, int64(len(value)))
 }
 
 func (m *memoryObjects) Del(key cipher.SHA256) (err error) {
-	if _, err = m.tx.Delete(m.key(key)); err == buntdb.ErrNotFound {
-		err = nil
+	var prev string
+	if prev, err = m.tx.Delete(m.key(key)); err != nil {
+		if err == buntdb.ErrNotFound {
+			err = nil
+		}
+		return
 	}
-	return
+	return addMemoryCounter(m.tx, memoryObjectsCounter, -1, -int64(len(prev)/2))
 }
 
 func (m *memoryObjects) Get(key cipher.SHA256) (p []byte) {
@@ -194,10 +335,39 @@ func (m *memoryObjects) Range(
 	return
 }
 
+func (m *memoryObjects) RangeErr(
+	fn func(key cipher.SHA256, value []byte, err error) error) error {
+
+	return m.Range(func(key cipher.SHA256, value []byte) error {
+		return fn(key, value, nil)
+	})
+}
+
+func (m *memoryObjects) RangeFrom(from cipher.SHA256, limit int,
+	fn func(key cipher.SHA256, value []byte) error) (next cipher.SHA256,
+	more bool, err error) {
+
+	pg := page{limit: limit}
+
+	m.tx.AscendGreaterOrEqual("", m.key(from), func(k, v string) bool {
+		if !strings.HasPrefix(k, "object:") {
+			return false // break
+		}
+		if pg.full() {
+			next, more = m.getKey(k), true
+			return false // break
+		}
+		err = pg.visit(fn(m.getKey(k), decValue(v)))
+		return err == nil
+	})
+	return
+}
+
 func (m *memoryObjects) RangeDel(
 	fn func(key cipher.SHA256, value []byte) (bool, error)) (err error) {
 
 	var del bool
+	var dc counter // deleted
 
 	// See TODO note below
 	collect := []string{}
@@ -222,6 +392,7 @@ func (m *memoryObjects) RangeDel(
 
 			// Until #24 of buntdb is open, use this
 			collect = append(collect, k)
+			dc.add(1, int64(len(v)/2))
 
 		}
 		return true // continue
@@ -233,7 +404,7 @@ func (m *memoryObjects) RangeDel(
 		}
 	}
 
-	return
+	return addMemoryCounter(m.tx, memoryObjectsCounter, -dc.amount, -dc.volume)
 }
 
 type memoryFeeds struct {
@@ -244,11 +415,53 @@ func (m *memoryFeeds) key(pk cipher.PubKey) string {
 	return "feed:" + pk.Hex()
 }
 
-func (m *memoryFeeds) Add(pk cipher.PubKey) (err error) {
-	_, _, err = m.tx.Set(m.key(pk), "", nil)
+func (m *memoryFeeds) succession(kind string,
+	pk cipher.PubKey) (sp cipher.PubKey, ok bool) {
+
+	val, err := m.tx.Get("succession:" + kind + ":" + pk.Hex())
+	if err != nil {
+		return
+	}
+	if sp, err = cipher.PubKeyFromHex(val); err != nil {
+		panic(err)
+	}
+	ok = true
+	return
+}
+
+func (m *memoryFeeds) Successor(pk cipher.PubKey) (cipher.PubKey, bool) {
+	return m.succession("next", pk)
+}
+
+func (m *memoryFeeds) Predecessor(pk cipher.PubKey) (cipher.PubKey, bool) {
+	return m.succession("prev", pk)
+}
+
+func (m *memoryFeeds) SetSuccessor(pk, next cipher.PubKey) (err error) {
+	if sp, ok := m.Successor(pk); ok && sp != next {
+		return ErrSuccessorExists
+	}
+	if pp, ok := m.Predecessor(next); ok && pp != pk {
+		return ErrPredecessorExists
+	}
+	_, _, err = m.tx.Set("succession:next:"+pk.Hex(), next.Hex(), nil)
+	if err != nil {
+		return
+	}
+	_, _, err = m.tx.Set("succession:prev:"+next.Hex(), pk.Hex(), nil)
 	return
 }
 
+func (m *memoryFeeds) Add(pk cipher.PubKey) (err error) {
+	if m.IsExist(pk) {
+		return
+	}
+	if _, _, err = m.tx.Set(m.key(pk), "", nil); err != nil {
+		return
+	}
+	return setMemoryCounter(m.tx, memoryFeedCounter+pk.Hex(), counter{})
+}
+
 func (m *memoryFeeds) Del(pk cipher.PubKey) (err error) {
 	if _, err = m.tx.Delete(m.key(pk)); err != nil {
 		if err == buntdb.ErrNotFound {
@@ -275,6 +488,24 @@ func (m *memoryFeeds) Del(pk cipher.PubKey) (err error) {
 		collect = append(collect, k)
 		return true // continue
 	})
+	m.tx.AscendKeys(metaPrefix(pk)+"*", func(k, _ string) bool {
+		collect = append(collect, k) // metadata of the roots
+		return true                  // continue
+	})
+	m.tx.AscendKeys(timePrefix(pk)+"*", func(k, _ string) bool {
+		collect = append(collect, k) // time index
+		return true                  // continue
+	})
+	collect = append(collect, memoryFeedCounter+pk.Hex())
+	// succession records (both directions)
+	if next, ok := m.Successor(pk); ok {
+		collect = append(collect, "succession:next:"+pk.Hex(),
+			"succession:prev:"+next.Hex())
+	}
+	if prev, ok := m.Predecessor(pk); ok {
+		collect = append(collect, "succession:prev:"+pk.Hex(),
+			"succession:next:"+prev.Hex())
+	}
 
 	// See TODO note above
 	// Until #24 of buntdb is open
@@ -354,13 +585,47 @@ func (m *memoryFeeds) Range(fn func(pk cipher.PubKey) error) (err error) {
 	return
 }
 
+func (m *memoryFeeds) RangeFrom(from cipher.PubKey, limit int,
+	fn func(pk cipher.PubKey) error) (next cipher.PubKey, more bool,
+	err error) {
+
+	// waithing for #24 of buntdb
+	collect := []string{}
+
+	pg := page{limit: limit}
+
+	// collect one feed more to know next one
+	m.tx.AscendGreaterOrEqual("", m.key(from), func(k, v string) bool {
+		if !strings.HasPrefix(k, "feed:") {
+			return false // break
+		}
+		if len(v) != 0 {
+			return true // continue (a root)
+		}
+		collect = append(collect, k)
+		return limit <= 0 || len(collect) <= limit
+	})
+
+	for _, k := range collect {
+		if pg.full() {
+			return m.getKey(k), true, nil
+		}
+		if err = pg.visit(fn(m.getKey(k))); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func (m *memoryFeeds) RangeDel(
 	fn func(pk cipher.PubKey) (bool, error)) (err error) {
 
 	var del bool
+	var pk cipher.PubKey
 
 	// See TODO note below
-	collect := []string{}
+	collect := []cipher.PubKey{}
 
 	m.tx.AscendKeys("feed:*", func(k, v string) bool {
 
@@ -368,7 +633,8 @@ func (m *memoryFeeds) RangeDel(
 			return true // continue
 		}
 
-		if del, err = fn(m.getKey(k)); err != nil {
+		pk = m.getKey(k)
+		if del, err = fn(pk); err != nil {
 			if err == ErrStopRange {
 				err = nil
 			}
@@ -381,15 +647,15 @@ func (m *memoryFeeds) RangeDel(
 			// 	if _, err = m.tx.Delete(k); err != nil {
 			// 		return false // break
 			// 	}
-			collect = append(collect, k)
+			collect = append(collect, pk)
 		}
 
 		return true // continue
 	})
 
 	// see TODO above
-	for _, k := range collect {
-		if _, err = m.tx.Delete(k); err != nil {
+	for _, pk := range collect {
+		if err = m.Del(pk); err != nil {
 			return
 		}
 	}
@@ -428,6 +694,68 @@ func (m *memoryRoots) key(seq uint64) string {
 	return m.prefix + utos(seq)
 }
 
+// "meta:pk:"
+func metaPrefix(pk cipher.PubKey) string {
+	return "meta:" + pk.Hex() + ":"
+}
+
+func (m *memoryRoots) metaKey(seq uint64) string {
+	return metaPrefix(m.feed) + utos(seq)
+}
+
+// "time:pk:"
+func timePrefix(pk cipher.PubKey) string {
+	return "time:" + pk.Hex() + ":"
+}
+
+// key of time index: time (sortable) + seq
+func (m *memoryRoots) timeKey(t int64, seq uint64) string {
+	return timePrefix(m.feed) + utos(uint64(t)^(1<<63)) + utos(seq)
+}
+
+// setMeta stores metadata of a root updating time index
+func (m *memoryRoots) setMeta(seq uint64, rm *RootMeta) (err error) {
+	if err = m.unindex(seq); err != nil {
+		return
+	}
+	data := encValue(encoder.Serialize(rm))
+	if _, _, err = m.tx.Set(m.metaKey(seq), data, nil); err != nil {
+		return
+	}
+	if rm.Time != 0 {
+		_, _, err = m.tx.Set(m.timeKey(rm.Time, seq), "", nil)
+	}
+	return
+}
+
+// unindex removes a root from time index
+func (m *memoryRoots) unindex(seq uint64) (err error) {
+	if rm := m.Meta(seq); rm != nil && rm.Time != 0 {
+		_, err = m.tx.Delete(m.timeKey(rm.Time, seq))
+	}
+	return
+}
+
+// delete root and its metadata by key of the root
+func (m *memoryRoots) del(key string, seq uint64) (err error) {
+	var prev string
+	if prev, err = m.tx.Delete(key); err != nil {
+		return
+	}
+	if err = m.unindex(seq); err != nil {
+		return
+	}
+	if _, err = m.tx.Delete(m.metaKey(seq)); err != nil {
+		return
+	}
+	return m.count(-1, -int64(len(prev)/2))
+}
+
+// update counter of the feed
+func (m *memoryRoots) count(amount, volume int64) error {
+	return addMemoryCounter(m.tx, memoryFeedCounter+m.feed.Hex(), amount, volume)
+}
+
 func (m *memoryRoots) Add(rp *RootPack) (err error) {
 
 	// check
@@ -455,7 +783,13 @@ func (m *memoryRoots) Add(rp *RootPack) (err error) {
 
 		// not found
 
-		_, _, err = m.tx.Set(key, data, nil)
+		if _, _, err = m.tx.Set(key, data, nil); err != nil {
+			return
+		}
+		if err = m.setMeta(rp.Seq, newRootMeta()); err != nil {
+			return
+		}
+		err = m.count(1, int64(len(data)/2))
 		return
 
 	} else if err != nil {
@@ -496,22 +830,39 @@ func (m *memoryRoots) Get(seq uint64) (rp *RootPack) {
 }
 
 func (m *memoryRoots) Del(seq uint64) (err error) {
-	if _, err = m.tx.Delete(m.key(seq)); err == buntdb.ErrNotFound {
+	if err = m.del(m.key(seq), seq); err == buntdb.ErrNotFound {
 		err = nil
 	}
 	return
 }
 
+func (m *memoryRoots) Meta(seq uint64) (rm *RootMeta) {
+	if val, err := m.tx.Get(m.metaKey(seq)); err == nil {
+		rm = new(RootMeta)
+		if err = encoder.DeserializeRaw(decValue(val), rm); err != nil {
+			panic(err) // critical
+		}
+	}
+	return
+}
+
+func (m *memoryRoots) SetMeta(seq uint64, rm *RootMeta) (err error) {
+	if _, err = m.tx.Get(m.key(seq)); err != nil {
+		if err == buntdb.ErrNotFound {
+			err = ErrNotFound
+		}
+		return
+	}
+	return m.setMeta(seq, rm)
+}
+
 func (m *memoryRoots) MarkFull(seq uint64) (err error) {
-	rp := m.Get(seq)
-	if rp == nil {
+	rm := m.Meta(seq)
+	if rm == nil {
 		return ErrNotFound
 	}
-	rp.IsFull = true
-	data := encValue(encoder.Serialize(rp))
-	key := m.key(rp.Seq) // feed:pk:seq
-	_, _, err = m.tx.Set(key, data, nil)
-	return
+	rm.IsFull = true
+	return m.setMeta(seq, rm)
 }
 
 func (m *memoryRoots) Range(fn func(rp *RootPack) error) (err error) {
@@ -558,6 +909,72 @@ func (m *memoryRoots) Reverse(fn func(rp *RootPack) error) (err error) {
 	return
 }
 
+// decode root from a value
+func decodeRootPack(v string) (rp *RootPack) {
+	rp = new(RootPack)
+	if err := encoder.DeserializeRaw(decValue(v), rp); err != nil {
+		panic(err) // critical
+	}
+	return
+}
+
+func (m *memoryRoots) RangeFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (next uint64, more bool, err error) {
+
+	pg := page{limit: limit}
+
+	m.tx.AscendGreaterOrEqual("", m.key(seq), func(k, v string) bool {
+		if !strings.HasPrefix(k, m.prefix) {
+			return false // break
+		}
+		rp := decodeRootPack(v)
+		if pg.full() {
+			next, more = rp.Seq, true
+			return false // break
+		}
+		err = pg.visit(fn(rp))
+		return err == nil
+	})
+	return
+}
+
+func (m *memoryRoots) ReverseFrom(seq uint64, limit int,
+	fn func(rp *RootPack) error) (next uint64, more bool, err error) {
+
+	pg := page{limit: limit}
+
+	m.tx.DescendLessOrEqual("", m.key(seq), func(k, v string) bool {
+		if !strings.HasPrefix(k, m.prefix) {
+			return false // break
+		}
+		rp := decodeRootPack(v)
+		if pg.full() {
+			next, more = rp.Seq, true
+			return false // break
+		}
+		err = pg.visit(fn(rp))
+		return err == nil
+	})
+	return
+}
+
+func (m *memoryRoots) SeqByTime(t int64) (seq uint64, ok bool) {
+	prefix := timePrefix(m.feed)
+	m.tx.AscendGreaterOrEqual("", m.timeKey(t, 0), func(k, _ string) bool {
+		if !strings.HasPrefix(k, prefix) {
+			return false // break
+		}
+		b := decValue(k[len(k)-16:])
+		seq, ok = binary.BigEndian.Uint64(b), true
+		return false // break
+	})
+	return
+}
+
+func (m *memoryRoots) Stat() (fs FeedStat) {
+	return getMemoryCounter(m.tx, memoryFeedCounter+m.feed.Hex()).feedStat()
+}
+
 func (m *memoryRoots) RangeDel(
 	fn func(rp *RootPack) (bool, error)) (err error) {
 
@@ -565,7 +982,7 @@ func (m *memoryRoots) RangeDel(
 	var del bool
 
 	// See TODO note below
-	collect := []string{}
+	collect := []*RootPack{}
 
 	m.tx.AscendKeys(m.prefix+"*", func(k, v string) bool {
 		if len(v) == 0 {
@@ -586,14 +1003,14 @@ func (m *memoryRoots) RangeDel(
 			// if _, err = m.tx.Delete(k); err != nil {
 			// 	return false // break
 			// }
-			collect = append(collect, k)
+			collect = append(collect, rp)
 		}
 		return true // continue
 	})
 
 	// See TODO note above
-	for _, k := range collect {
-		if _, err = m.tx.Delete(k); err != nil {
+	for _, rp := range collect {
+		if err = m.del(m.key(rp.Seq), rp.Seq); err != nil {
 			return // break
 		}
 	}
@@ -617,6 +1034,34 @@ func (m *memoryRoots) DelBefore(seq uint64) (err error) {
 // utilities
 //
 
+// keys of counters
+const (
+	memoryObjectsCounter = "stat:objects"
+	memoryFeedCounter    = "stat:feed:" // + hex of pubkey
+)
+
+func getMemoryCounter(tx *buntdb.Tx, key string) (c counter) {
+	if val, err := tx.Get(key); err == nil {
+		c = decodeCounter(decValue(val))
+	}
+	return
+}
+
+func setMemoryCounter(tx *buntdb.Tx, key string, c counter) (err error) {
+	_, _, err = tx.Set(key, encValue(c.encode()), nil)
+	return
+}
+
+// addMemoryCounter adds given values to counter with given key
+func addMemoryCounter(tx *buntdb.Tx, key string, amount, volume int64) error {
+	if amount == 0 && volume == 0 {
+		return nil
+	}
+	c := getMemoryCounter(tx, key)
+	c.add(amount, volume)
+	return setMemoryCounter(tx, key, c)
+}
+
 func encValue(value []byte) string {
 	return hex.EncodeToString(value)
 }
@@ -642,3 +1087,79 @@ func utos(u uint64) string {
 // 	}
 // 	return binary.BigEndian.Uint64(b)
 // }
+
+// end-user bucket, keys are hex-encoded
+// to keep bytewise order
+
+func bucketPrefix(name []byte) string {
+	return "bucket:" + hex.EncodeToString(name) + ":"
+}
+
+type memoryBucket struct {
+	prefix string
+	tx     *buntdb.Tx
+}
+
+func (m *memoryBucket) key(key []byte) string {
+	return m.prefix + hex.EncodeToString(key)
+}
+
+func (m *memoryBucket) Get(key []byte) (value []byte) {
+	if val, err := m.tx.Get(m.key(key)); err == nil {
+		value = []byte(val)
+	}
+	return
+}
+
+func (m *memoryBucket) Set(key, value []byte) (err error) {
+	_, _, err = m.tx.Set(m.key(key), string(value), nil)
+	return
+}
+
+func (m *memoryBucket) Del(key []byte) (err error) {
+	if _, err = m.tx.Delete(m.key(key)); err == buntdb.ErrNotFound {
+		err = nil
+	}
+	return
+}
+
+// rangeKeys used by Range, Reverse and Prefix
+func (m *memoryBucket) rangeKeys(prefix []byte, reverse bool,
+	fn func(key, value []byte) error) (err error) {
+
+	var (
+		pattern = m.key(prefix) + "*"
+		iter    = m.tx.AscendKeys
+	)
+	if reverse {
+		iter = m.tx.DescendKeys
+	}
+	iter(pattern, func(k, v string) bool {
+		var key []byte
+		if key, err = hex.DecodeString(k[len(m.prefix):]); err != nil {
+			return false // break
+		}
+		if err = fn(key, []byte(v)); err != nil {
+			if err == ErrStopRange {
+				err = nil
+			}
+			return false // break
+		}
+		return true // continue
+	})
+	return
+}
+
+func (m *memoryBucket) Range(fn func(key, value []byte) error) error {
+	return m.rangeKeys(nil, false, fn)
+}
+
+func (m *memoryBucket) Reverse(fn func(key, value []byte) error) error {
+	return m.rangeKeys(nil, true, fn)
+}
+
+func (m *memoryBucket) Prefix(prefix []byte,
+	fn func(key, value []byte) error) error {
+
+	return m.rangeKeys(prefix, false, fn)
+}
@@ -0,0 +1,330 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/tidwall/buntdb"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// DriveVersion is version of format of DB file
+// created by NewDriveDB. Versions:
+//
+//   - 0 initial format, IsFull is a part of RootPack
+//   - 1 metadata of roots (RootMeta) in meta bucket
+//   - 2 statistic kept in stat bucket
+//   - 3 time of roots in RootMeta and time index
+const DriveVersion uint64 = 3
+
+// a migration upgrades DB file from version
+// i to version i+1, where i is index of the
+// migration in the migrations
+var migrations = []func(t *bolt.Tx) error{
+	migrateRootMeta, // 0 -> 1
+	recount,         // 1 -> 2
+	migrateRootTime, // 2 -> 3
+}
+
+// key of version of DB file in info bucket
+var versionKey = []byte("version")
+
+// A VersionError returned by NewDriveDB
+// if DB file is newer than this package
+type VersionError struct {
+	File    uint64 // version of DB file
+	Package uint64 // supported version (DriveVersion)
+}
+
+// Error implements error interface
+func (v *VersionError) Error() string {
+	return fmt.Sprintf("DB file version %d is newer than supported %d,"+
+		" update the binary", v.File, v.Package)
+}
+
+// driveVersion returns version of DB file; files
+// created before the info bucket have no version
+// and the version is detected by buckets
+func driveVersion(t *bolt.Tx) (ver uint64, err error) {
+	if info := t.Bucket(infoBucket); info != nil {
+		val := info.Get(versionKey)
+		if len(val) != 8 {
+			err = fmt.Errorf("malformed version of DB file: %x", val)
+			return
+		}
+		return btou(val), nil
+	}
+	switch {
+	case t.Bucket(metaBucket) == nil:
+		return 0, nil
+	case t.Bucket(statBucket) == nil:
+		return 1, nil
+	}
+	return 2, nil
+}
+
+// upgrade DB file to DriveVersion running all
+// required migrations in given transaction
+func upgrade(t *bolt.Tx) (err error) {
+	var ver uint64
+	if ver, err = driveVersion(t); err != nil {
+		return
+	}
+	if ver > DriveVersion {
+		return &VersionError{ver, DriveVersion}
+	}
+	for _, name := range [][]byte{
+		objectsBucket,
+		feedsBucket,
+		successionBucket,
+		endUserBucket,
+	} {
+		if _, err = t.CreateBucketIfNotExists(name); err != nil {
+			return
+		}
+	}
+	for ; ver < DriveVersion; ver++ {
+		if err = migrations[ver](t); err != nil {
+			return fmt.Errorf("migration %d -> %d: %v", ver, ver+1, err)
+		}
+	}
+	var info *bolt.Bucket
+	if info, err = t.CreateBucketIfNotExists(infoBucket); err != nil {
+		return
+	}
+	return info.Put(versionKey, utob(DriveVersion))
+}
+
+// legacyRootPack is RootPack as it was stored in
+// old DB files, where IsFull was a part of the RootPack
+type legacyRootPack struct {
+	Root []byte
+	Seq  uint64
+	Prev cipher.SHA256
+	Hash cipher.SHA256
+	Sig  cipher.Sig
+
+	IsFull bool
+}
+
+// migrateRootMeta creates meta bucket and moves IsFull
+// flag of all roots of old DB file to local metadata of
+// the roots. Received time of migrated roots is unknown
+// and is 0
+func migrateRootMeta(t *bolt.Tx) (err error) {
+
+	var meta *bolt.Bucket
+	if meta, err = t.CreateBucket(metaBucket); err != nil {
+		return
+	}
+
+	feeds := t.Bucket(feedsBucket)
+
+	return feeds.ForEach(func(pk, _ []byte) (err error) {
+
+		roots := feeds.Bucket(pk)
+
+		var mt *bolt.Bucket
+		if mt, err = meta.CreateBucket(pk); err != nil {
+			return
+		}
+
+		// boltdb doesn't allow to modify a bucket inside ForEach
+		collect := make(map[string]*legacyRootPack)
+
+		err = roots.ForEach(func(seqb, val []byte) (err error) {
+			lrp := new(legacyRootPack)
+			if err = encoder.DeserializeRaw(val, lrp); err != nil {
+				return
+			}
+			collect[string(seqb)] = lrp
+			return
+		})
+		if err != nil {
+			return
+		}
+
+		for seqb, lrp := range collect {
+			rp := RootPack{
+				Root: lrp.Root,
+				Seq:  lrp.Seq,
+				Prev: lrp.Prev,
+				Hash: lrp.Hash,
+				Sig:  lrp.Sig,
+			}
+			if err = roots.Put([]byte(seqb), encoder.Serialize(&rp)); err != nil {
+				return
+			}
+			rm := legacyRootMeta{IsFull: lrp.IsFull}
+			if err = mt.Put([]byte(seqb), encoder.Serialize(&rm)); err != nil {
+				return
+			}
+		}
+
+		return
+	})
+}
+
+// legacyRootMeta is RootMeta as it was stored
+// before version 3, where Time has been added
+type legacyRootMeta struct {
+	IsFull   bool
+	Received int64
+	Peer     string
+	Flags    RootFlag
+}
+
+// migrateRootTime adds Time to metadata of all roots and
+// creates time index. Time of migrated roots is unknown
+// here and is 0, thus they are not indexed until the Time
+// is set by skyobject.Container decoding the roots
+func migrateRootTime(t *bolt.Tx) (err error) {
+
+	var tm *bolt.Bucket
+	if tm, err = t.CreateBucket(timeBucket); err != nil {
+		return
+	}
+
+	meta := t.Bucket(metaBucket)
+
+	return t.Bucket(feedsBucket).ForEach(func(pk, _ []byte) (err error) {
+
+		if _, err = tm.CreateBucket(pk); err != nil {
+			return
+		}
+
+		mt := meta.Bucket(pk)
+		if mt == nil {
+			return
+		}
+
+		// boltdb doesn't allow to modify a bucket inside ForEach
+		collect := make(map[string]*legacyRootMeta)
+
+		err = mt.ForEach(func(seqb, val []byte) (err error) {
+			lrm := new(legacyRootMeta)
+			if err = encoder.DeserializeRaw(val, lrm); err != nil {
+				return
+			}
+			collect[string(seqb)] = lrm
+			return
+		})
+		if err != nil {
+			return
+		}
+
+		for seqb, lrm := range collect {
+			rm := RootMeta{
+				IsFull:   lrm.IsFull,
+				Received: lrm.Received,
+				Peer:     lrm.Peer,
+				Flags:    lrm.Flags,
+			}
+			if err = mt.Put([]byte(seqb), encoder.Serialize(&rm)); err != nil {
+				return
+			}
+		}
+
+		return
+	})
+}
+
+// MemoryVersion is version of format of DB created by
+// NewMemoryDB and NewPersistentMemoryDB. It's kept in
+// log of persistent DB and in backups. Versions:
+//
+//   - 0 initial format, without version
+//   - 1 time of roots in RootMeta and time index
+const MemoryVersion uint64 = 1
+
+// a memory migration upgrades memory DB from
+// version i to version i+1 (see migrations)
+var memoryMigrations = []func(t *buntdb.Tx) error{
+	migrateMemoryRootTime, // 0 -> 1
+}
+
+// key of version of memory DB
+const memoryVersionKey = "info:version"
+
+// memoryVersion returns version of memory DB,
+// DB without version has version 0
+func memoryVersion(t *buntdb.Tx) (ver uint64, err error) {
+	var val string
+	if val, err = t.Get(memoryVersionKey); err != nil {
+		if err == buntdb.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	if len(val) != 16 {
+		err = fmt.Errorf("malformed version of DB: %q", val)
+		return
+	}
+	return btou(decValue(val)), nil
+}
+
+// upgradeMemory upgrades memory DB to MemoryVersion
+// running all required migrations. It returns
+// *VersionError if the DB is newer
+func upgradeMemory(bunt *buntdb.DB) error {
+	return bunt.Update(func(t *buntdb.Tx) (err error) {
+		var ver uint64
+		if ver, err = memoryVersion(t); err != nil {
+			return
+		}
+		if ver > MemoryVersion {
+			return &VersionError{ver, MemoryVersion}
+		}
+		for ; ver < MemoryVersion; ver++ {
+			if err = memoryMigrations[ver](t); err != nil {
+				return fmt.Errorf("migration %d -> %d: %v", ver, ver+1, err)
+			}
+		}
+		_, _, err = t.Set(memoryVersionKey, encValue(utob(MemoryVersion)), nil)
+		return
+	})
+}
+
+// migrateMemoryRootTime adds Time to metadata of all roots,
+// the same as migrateRootTime. Metadata that already has
+// the Time (DB created without version) is kept as is
+func migrateMemoryRootTime(t *buntdb.Tx) (err error) {
+
+	// buntdb doesn't allow to modify DB inside Ascend
+	collect := make(map[string]string)
+
+	t.AscendKeys("meta:*", func(k, v string) bool {
+		val := decValue(v)
+		var rm RootMeta
+		if err = encoder.DeserializeRaw(val, &rm); err == nil {
+			return true // continue, already migrated
+		}
+		lrm := new(legacyRootMeta)
+		if err = encoder.DeserializeRaw(val, lrm); err != nil {
+			err = fmt.Errorf("malformed meta %s: %v",
+				strings.TrimPrefix(k, "meta:"), err)
+			return false // break
+		}
+		rm = RootMeta{
+			IsFull:   lrm.IsFull,
+			Received: lrm.Received,
+			Peer:     lrm.Peer,
+			Flags:    lrm.Flags,
+		}
+		collect[k] = encValue(encoder.Serialize(&rm))
+		return true // continue
+	})
+	if err != nil {
+		return
+	}
+
+	for k, v := range collect {
+		if _, _, err = t.Set(k, v, nil); err != nil {
+			return
+		}
+	}
+	return
+}
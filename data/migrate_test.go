@@ -0,0 +1,219 @@
+package data
+
+import (
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/tidwall/buntdb"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+func Test_migrateRootMeta(t *testing.T) {
+
+	pk, _ := cipher.GenerateKeyPair()
+	dbFile := testPath(t)
+	defer os.Remove(dbFile)
+
+	// create DB file in old format
+
+	b, err := bolt.Open(dbFile, dbMode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Update(func(t *bolt.Tx) (err error) {
+		if _, err = t.CreateBucket(objectsBucket); err != nil {
+			return
+		}
+		var feeds, roots *bolt.Bucket
+		if feeds, err = t.CreateBucket(feedsBucket); err != nil {
+			return
+		}
+		if roots, err = feeds.CreateBucket(pk[:]); err != nil {
+			return
+		}
+		for i, content := range []string{"hey", "hoy"} {
+			rp := getRootPack(uint64(i), content)
+			lrp := legacyRootPack{
+				Root:   rp.Root,
+				Seq:    rp.Seq,
+				Prev:   rp.Prev,
+				Hash:   rp.Hash,
+				Sig:    rp.Sig,
+				IsFull: i == 0,
+			}
+			err = roots.Put(utob(rp.Seq), encoder.Serialize(&lrp))
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// open and migrate
+
+	db, err := NewDriveDB(dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.View(func(tx Tv) (_ error) {
+		roots := tx.Feeds().Roots(pk)
+		if roots == nil {
+			t.Error("missing feed")
+			return
+		}
+		for i, content := range []string{"hey", "hoy"} {
+			want := getRootPack(uint64(i), content)
+			if rp := roots.Get(uint64(i)); rp == nil {
+				t.Error("missing Root", i)
+			} else if string(rp.Root) != string(want.Root) ||
+				rp.Hash != want.Hash {
+
+				t.Error("wrong Root after migration", i)
+			}
+			if rm := roots.Meta(uint64(i)); rm == nil {
+				t.Error("missing metadata", i)
+			} else if rm.IsFull != (i == 0) {
+				t.Error("wrong IsFull after migration", i)
+			}
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+}
+
+func Test_upgrade(t *testing.T) {
+
+	dbFile := testPath(t)
+	defer os.Remove(dbFile)
+
+	db, err := NewDriveDB(dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ver uint64
+	err = db.(*driveDB).bolt.View(func(t *bolt.Tx) (err error) {
+		ver, err = driveVersion(t)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver != DriveVersion {
+		t.Error("wrong version of new DB file:", ver)
+	}
+
+	// make the file newer
+
+	err = db.(*driveDB).bolt.Update(func(t *bolt.Tx) error {
+		return t.Bucket(infoBucket).Put(versionKey, utob(DriveVersion+1))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewDriveDB(dbFile); err == nil {
+		t.Fatal("missing error")
+	} else if ve, ok := err.(*VersionError); !ok {
+		t.Error("unexpected error:", err)
+	} else if ve.File != DriveVersion+1 || ve.Package != DriveVersion {
+		t.Error("wrong versions:", ve.Error())
+	}
+
+}
+
+func Test_upgradeMemory(t *testing.T) {
+
+	logFile := testPath(t)
+	defer os.Remove(logFile)
+
+	// create log in old format
+
+	pk, _ := cipher.GenerateKeyPair()
+	metaKey := metaPrefix(pk) + utos(0)
+
+	bunt, err := buntdb.Open(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bunt.Update(func(t *buntdb.Tx) (err error) {
+		lrm := legacyRootMeta{IsFull: true, Received: 1}
+		_, _, err = t.Set(metaKey, encValue(encoder.Serialize(&lrm)), nil)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = bunt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewPersistentMemoryDB(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.(*memoryDB).bunt.View(func(tx *buntdb.Tx) (err error) {
+		var ver uint64
+		if ver, err = memoryVersion(tx); err != nil {
+			return
+		} else if ver != MemoryVersion {
+			t.Error("wrong version of upgraded log:", ver)
+		}
+		var val string
+		if val, err = tx.Get(metaKey); err != nil {
+			return
+		}
+		var rm RootMeta
+		if err = encoder.DeserializeRaw(decValue(val), &rm); err != nil {
+			return
+		}
+		if !rm.IsFull || rm.Received != 1 || rm.Time != 0 {
+			t.Errorf("wrong meta: %+v", rm)
+		}
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// make the log newer
+
+	err = db.(*memoryDB).bunt.Update(func(tx *buntdb.Tx) (err error) {
+		_, _, err = tx.Set(memoryVersionKey, encValue(utob(MemoryVersion+1)),
+			nil)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewPersistentMemoryDB(logFile); err == nil {
+		t.Fatal("missing error")
+	} else if ve, ok := err.(*VersionError); !ok {
+		t.Error("unexpected error:", err)
+	} else if ve.File != MemoryVersion+1 || ve.Package != MemoryVersion {
+		t.Error("wrong versions:", ve.Error())
+	}
+
+}
@@ -333,6 +333,92 @@ func TestViewObjects_Range(t *testing.T) {
 
 }
 
+func testViewObjectsRangeFrom(t *testing.T, db DB) {
+
+	to := testSortedObjects("one", "two", "three")
+
+	err := db.Update(func(tx Tu) (_ error) {
+		objs := tx.Objects()
+		for _, o := range to {
+			if err := objs.Set(o.key, o.value); err != nil {
+				return err
+			}
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = db.View(func(tx Tv) (_ error) {
+		objs := tx.Objects()
+
+		var got testObjectKeyValues
+		fn := func(key cipher.SHA256, value []byte) (_ error) {
+			got = append(got, testObjectKeyValue{key, value})
+			return
+		}
+
+		var next cipher.SHA256
+		var more = true
+		for pages := 0; more; pages++ {
+			if pages > len(to) {
+				t.Error("too many pages")
+				break
+			}
+			next, more, err = objs.RangeFrom(next, 2, fn)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		if len(got) != len(to) {
+			t.Error("wrong number of objects:", len(got))
+			return
+		}
+		for i, o := range to {
+			if got[i].key != o.key || !bytes.Equal(got[i].value, o.value) {
+				t.Error("wrong order or value", i)
+			}
+		}
+
+		got = got[:0]
+		next, more, err = objs.RangeFrom(to[1].key, 1, fn)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(got) != 1 || got[0].key != to[1].key {
+			t.Error("wrong first object")
+		}
+		if !more || next != to[2].key {
+			t.Error("wrong continuation")
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestViewObjects_RangeFrom(t *testing.T) {
+	// RangeFrom(from cipher.SHA256, limit int,
+	//     fn func(key cipher.SHA256, value []byte) error) (next cipher.SHA256,
+	//     more bool, err error)
+
+	t.Run("memory", func(t *testing.T) {
+		testViewObjectsRangeFrom(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testViewObjectsRangeFrom(t, db)
+	})
+
+}
+
 //
 // UpdateObjects
 //
@@ -414,6 +414,232 @@ func TestViewRoots_Reverse(t *testing.T) {
 
 }
 
+func testViewRootsRangeFrom(t *testing.T, db DB) {
+
+	pk, _ := cipher.GenerateKeyPair()
+
+	if testFillWithExampleFeed(t, pk, db); t.Failed() {
+		return
+	}
+
+	type result struct {
+		seqs []uint64
+		next uint64
+		more bool
+	}
+
+	rangeFrom := func(reverse bool, seq uint64, limit int) (r result) {
+		err := db.View(func(tx Tv) (err error) {
+			roots := tx.Feeds().Roots(pk)
+			fn := func(rp *RootPack) (_ error) {
+				r.seqs = append(r.seqs, rp.Seq)
+				return
+			}
+			if reverse {
+				r.next, r.more, err = roots.ReverseFrom(seq, limit, fn)
+			} else {
+				r.next, r.more, err = roots.RangeFrom(seq, limit, fn)
+			}
+			return
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		return
+	}
+
+	for _, tc := range []struct {
+		reverse bool
+		seq     uint64
+		limit   int
+		want    result
+	}{
+		{false, 0, 0, result{[]uint64{0, 1, 2}, 0, false}},
+		{false, 0, 2, result{[]uint64{0, 1}, 2, true}},
+		{false, 2, 2, result{[]uint64{2}, 0, false}},
+		{false, 3, 2, result{nil, 0, false}},
+		{true, 2, 0, result{[]uint64{2, 1, 0}, 0, false}},
+		{true, 2, 2, result{[]uint64{2, 1}, 0, true}},
+		{true, 1050, 1, result{[]uint64{2}, 1, true}},
+	} {
+		got := rangeFrom(tc.reverse, tc.seq, tc.limit)
+		if len(got.seqs) != len(tc.want.seqs) || got.next != tc.want.next ||
+			got.more != tc.want.more {
+
+			t.Errorf("wrong page (%v, %d, %d): %v", tc.reverse, tc.seq,
+				tc.limit, got)
+			continue
+		}
+		for i, seq := range got.seqs {
+			if seq != tc.want.seqs[i] {
+				t.Errorf("wrong page (%v, %d, %d): %v", tc.reverse, tc.seq,
+					tc.limit, got)
+				break
+			}
+		}
+	}
+
+	t.Run("stop range", func(t *testing.T) {
+		err := db.View(func(tx Tv) (_ error) {
+			next, more, err := tx.Feeds().Roots(pk).RangeFrom(0, 0,
+				func(rp *RootPack) error {
+					return ErrStopRange
+				})
+			if err != nil {
+				t.Error(err)
+			}
+			if !more || next != 1 {
+				t.Error("wrong continuation:", next, more)
+			}
+			return
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+}
+
+func TestViewRoots_RangeFrom(t *testing.T) {
+	// RangeFrom(seq uint64, limit int,
+	//     fn func(rp *RootPack) error) (next uint64, more bool, err error)
+	// ReverseFrom(seq uint64, limit int,
+	//     fn func(rp *RootPack) error) (next uint64, more bool, err error)
+
+	t.Run("memory", func(t *testing.T) {
+		testViewRootsRangeFrom(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testViewRootsRangeFrom(t, db)
+	})
+
+}
+
+func testViewRootsSeqByTime(t *testing.T, db DB) {
+
+	pk, _ := cipher.GenerateKeyPair()
+
+	if testFillWithExampleFeed(t, pk, db); t.Failed() {
+		return
+	}
+
+	err := db.Update(func(tx Tu) (err error) {
+		roots := tx.Feeds().Roots(pk)
+		if _, ok := roots.SeqByTime(0); ok {
+			t.Error("roots without time are indexed")
+		}
+		for _, seq := range []uint64{0, 1, 2} {
+			rm := roots.Meta(seq)
+			rm.Time = int64(seq+1) * 10
+			if err = roots.SetMeta(seq, rm); err != nil {
+				return
+			}
+		}
+		// change time of the last Root
+		rm := roots.Meta(2)
+		rm.Time = 25
+		return roots.SetMeta(2, rm)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.View(func(tx Tv) (_ error) {
+		roots := tx.Feeds().Roots(pk)
+		for _, tc := range []struct {
+			time int64
+			seq  uint64
+			ok   bool
+		}{
+			{-5, 0, true},
+			{10, 0, true},
+			{11, 1, true},
+			{21, 2, true},
+			{26, 0, false},
+			{30, 0, false},
+		} {
+			seq, ok := roots.SeqByTime(tc.time)
+			if seq != tc.seq || ok != tc.ok {
+				t.Errorf("wrong seq by time %d: %d, %v", tc.time, seq, ok)
+			}
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Update(func(tx Tu) (_ error) {
+		return tx.Feeds().Roots(pk).Del(0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.View(func(tx Tv) (_ error) {
+		if seq, ok := tx.Feeds().Roots(pk).SeqByTime(0); !ok || seq != 1 {
+			t.Error("removed Root is not removed from index")
+		}
+		return
+	})
+
+}
+
+func TestViewRoots_SeqByTime(t *testing.T) {
+	// SeqByTime(t int64) (seq uint64, ok bool)
+
+	t.Run("memory", func(t *testing.T) {
+		testViewRootsSeqByTime(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testViewRootsSeqByTime(t, db)
+	})
+
+}
+
+func testViewRootsStat(t *testing.T, db DB) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	if testFillWithExampleFeed(t, pk, db); t.Failed() {
+		return
+	}
+
+	err := db.View(func(tx Tv) (_ error) {
+		fs := tx.Feeds().Roots(pk).Stat()
+		if fs != db.Stat().Feeds[pk] {
+			t.Errorf("wrong stat: %+v", fs)
+		}
+		if fs.Roots != 3 {
+			t.Error("wrong amount of roots:", fs.Roots)
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestViewRoots_Stat(t *testing.T) {
+	// Stat() (fs FeedStat)
+
+	t.Run("memory", func(t *testing.T) {
+		testViewRootsStat(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testViewRootsStat(t, db)
+	})
+
+}
+
 //
 // UpdateRoots
 //
@@ -455,6 +681,21 @@ func TestUpdateRoots_Reverse(t *testing.T) {
 
 }
 
+func TestUpdateRoots_RangeFrom(t *testing.T) {
+	// RangeFrom(seq uint64, limit int,
+	//     fn func(rp *RootPack) error) (next uint64, more bool, err error)
+
+	t.Skip("inherited from ViewRoots")
+
+}
+
+func TestUpdateRoots_SeqByTime(t *testing.T) {
+	// SeqByTime(t int64) (seq uint64, ok bool)
+
+	t.Skip("inherited from ViewRoots")
+
+}
+
 // UpdateRoots
 
 func testUpdateRootsAdd(t *testing.T, db DB) {
@@ -572,9 +813,9 @@ func testUpdateRootsMarkFull(t *testing.T, db DB) {
 		if err := roots.MarkFull(0); err != nil {
 			t.Error(err)
 		}
-		if rp := roots.Get(0); rp == nil {
+		if rm := roots.Meta(0); rm == nil {
 			t.Error("missing Root after MarkFull")
-		} else if rp.IsFull == false {
+		} else if rm.IsFull == false {
 			t.Error("not marked as full")
 		}
 		return
@@ -599,6 +840,63 @@ func TestUpdateRoots_MarkFull(t *testing.T) {
 
 }
 
+func testUpdateRootsSetMeta(t *testing.T, db DB) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	if testFillWithExampleFeed(t, pk, db); t.Failed() {
+		return
+	}
+
+	err := db.Update(func(tx Tu) (_ error) {
+		roots := tx.Feeds().Roots(pk)
+		rm := roots.Meta(0)
+		if rm == nil {
+			t.Error("missing metadata")
+			return
+		} else if rm.IsFull || rm.Received == 0 || rm.IsPinned() {
+			t.Error("unexpected metadata of new Root:", *rm)
+		}
+		rm.Peer = "127.0.0.1:8870"
+		rm.Flags |= RootPinned
+		if err := roots.SetMeta(0, rm); err != nil {
+			t.Error(err)
+		}
+		if err := roots.SetMeta(1050, rm); err != ErrNotFound {
+			t.Error("unexpected error:", err)
+		}
+		if got := roots.Meta(0); got == nil {
+			t.Error("missing metadata")
+		} else if *got != *rm {
+			t.Error("wrong metadata", *got)
+		}
+		if err := roots.Del(0); err != nil {
+			t.Error(err)
+		}
+		if roots.Meta(0) != nil {
+			t.Error("metadata of removed Root")
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateRoots_SetMeta(t *testing.T) {
+	// SetMeta(seq uint64, rm *RootMeta) (err error)
+
+	t.Run("memory", func(t *testing.T) {
+		testUpdateRootsSetMeta(t, NewMemoryDB())
+	})
+
+	t.Run("drive", func(t *testing.T) {
+		db, cleanUp := testDriveDB(t)
+		defer cleanUp()
+		testUpdateRootsSetMeta(t, db)
+	})
+
+}
+
 func testUpdateRootsRangeDel(t *testing.T, db DB) {
 	pk, _ := cipher.GenerateKeyPair()
 
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"encoding/binary"
 	"fmt"
 	"strings"
 
@@ -26,12 +27,22 @@ type Stat struct {
 	// doesn't include space taken by
 	// key and other
 	Space Space `json:"space"`
+	// PhysicalSpace is space really taken
+	// by the Objects. It's less than the
+	// Space if objects are compressed (see
+	// NewCompressedDB), otherwise they are
+	// equal
+	PhysicalSpace Space `json:"physical_space"`
 
 	// Feeds represents statistic
 	// of root objects by feed. This
 	// map is nil if database
 	// doesn't contains feeds
 	Feeds map[cipher.PubKey]FeedStat `json:"feeds"` // feeds
+
+	// Cache is statistic of cache of objects. It's
+	// nil if the DB is not created by NewCacheDB
+	Cache *CacheStat `json:"cache,omitempty"`
 }
 
 // A FeedStat represents statistic
@@ -44,6 +55,40 @@ type FeedStat struct {
 	Space Space `json:"space"`
 }
 
+// A counter represents statistic of objects or roots of a
+// feed kept in DB: amount of items and space taken by them.
+// DB updates counters incrementally
+type counter struct {
+	amount int64
+	volume int64
+}
+
+func (c *counter) add(amount, volume int64) {
+	c.amount += amount
+	c.volume += volume
+}
+
+func (c counter) encode() (b []byte) {
+	b = make([]byte, 16)
+	binary.BigEndian.PutUint64(b, uint64(c.amount))
+	binary.BigEndian.PutUint64(b[8:], uint64(c.volume))
+	return
+}
+
+// decodeCounter returns zero counter if given slice is malformed
+func decodeCounter(b []byte) (c counter) {
+	if len(b) != 16 {
+		return
+	}
+	c.amount = int64(binary.BigEndian.Uint64(b))
+	c.volume = int64(binary.BigEndian.Uint64(b[8:]))
+	return
+}
+
+func (c counter) feedStat() FeedStat {
+	return FeedStat{Roots: int(c.amount), Space: Space(c.volume)}
+}
+
 func shortHex(a string) string {
 	return string([]byte(a)[:7])
 }
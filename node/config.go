@@ -14,6 +14,7 @@ import (
 
 	"fmt"
 
+	"github.com/skycoin/cxo/data"
 	"github.com/skycoin/cxo/node/gnet"
 	"github.com/skycoin/cxo/node/log"
 	"github.com/skycoin/cxo/skyobject"
@@ -29,6 +30,8 @@ const (
 	RemoteClose    bool   = false       // default remote-closing pin
 	RPCAddress     string = "[::]:8878" // default RPC address
 	InMemoryDB     bool   = false       // default database placement pin
+	CacheSize      int    = 0           // default size of cache of objects
+	Compression    bool   = false       // default compression pin
 
 	// PingInterval is default interval by which server send pings
 	// to connections that doesn't communicate. Actually, the
@@ -119,6 +122,30 @@ type Config struct {
 	InMemoryDB bool
 	// DBPath is path to database file
 	DBPath string
+	// DBLogPath is path to append-only log of in-memory
+	// database (see data.NewPersistentMemoryDB). If the
+	// InMemoryDB is true and the DBLogPath is not empty,
+	// then the in-memory database survives restarts
+	DBLogPath string
+	// CacheSize is max size of LRU cache of objects in
+	// bytes (see data.NewCacheDB). Set to 0 to disable
+	CacheSize int
+	// Passphrase used to encrypt DB (see data.NewEncryptedDB).
+	// Encryption is disabled if the Passphrase is empty. An
+	// encrypted DB can't be opened without the Passphrase
+	Passphrase string
+	// PassphraseFile is path to file with the Passphrase,
+	// trailing newline is ignored. If the PassphraseFile is
+	// not empty, then it overrides the Passphrase
+	PassphraseFile string
+	// Compression enables compression of objects (see
+	// data.NewCompressedDB). A non-empty DB created
+	// without compression can't be opened with it, and
+	// a compressed DB can't be opened without it
+	Compression bool
+	// Codec used to compress objects if the
+	// Compression is true
+	Codec data.Codec
 	// DataDir is directory with data files
 	DataDir string
 
@@ -189,8 +216,20 @@ type Config struct {
 	// no reason to remove it. But. This callback also called
 	// if any other error occured, such as DB can't
 	// save received object, the Root it fills malformed
-	// and can't be filled, etc
+	// and can't be filled, etc. If the err is one of
+	// limit errors (see skyobject.IsLimitError), then
+	// connection will be closed after the callback
 	OnFillingBreaks func(n *Node, c *gnet.Conn, root *skyobject.Root, err error)
+	// OnFillingProgress called every time a filling Root
+	// receives an object. The callback must not block
+	OnFillingProgress func(n *Node, c *gnet.Conn, root *skyobject.Root,
+		progress skyobject.FillingProgress)
+	// OnFeedSuccession called when a feed continued by
+	// another public key (key rotation). The Node subscribes
+	// to the new feed automatically before the callback.
+	// The c is nil if the succession Root published
+	// by the Node
+	OnFeedSuccession func(n *Node, c *gnet.Conn, root *skyobject.Root)
 }
 
 // NewConfig returns Config
@@ -206,6 +245,9 @@ func NewConfig() (sc Config) {
 	sc.RemoteClose = RemoteClose
 	sc.PingInterval = PingInterval
 	sc.InMemoryDB = InMemoryDB
+	sc.CacheSize = CacheSize
+	sc.Compression = Compression
+	sc.Codec = data.CodecFlateFast
 	sc.DataDir = dataDir()
 	sc.DBPath = filepath.Join(sc.DataDir, dbFile)
 	sc.ResponseTimeout = ResponseTimeout
@@ -253,10 +295,29 @@ func (s *Config) FromFlags() {
 		"mem-db",
 		s.InMemoryDB,
 		"use in-memory database")
+	flag.IntVar(&s.CacheSize,
+		"cache-size",
+		s.CacheSize,
+		"max size of cache of objects in bytes (0 = disable)")
+	flag.StringVar(&s.PassphraseFile,
+		"passphrase-file",
+		s.PassphraseFile,
+		"file with passphrase to encrypt DB")
+	flag.BoolVar(&s.Compression,
+		"compression",
+		s.Compression,
+		"compress objects")
+	flag.Var(&s.Codec,
+		"codec",
+		"compression codec: none, flate-fast or flate")
 	flag.StringVar(&s.DataDir,
 		"data-dir",
 		s.DataDir,
 		"directory with data")
+	flag.StringVar(&s.DBLogPath,
+		"db-log-path",
+		s.DBLogPath,
+		"path to log of in-memory database (empty = not persistent)")
 	flag.StringVar(&s.DBPath,
 		"db-path",
 		s.DBPath,
@@ -275,6 +336,26 @@ func (s *Config) FromFlags() {
 
 	// TODO: skyobejct.Configs from flags
 
+	if s.Skyobject != nil {
+		flag.IntVar(&s.Skyobject.Quota.MaxRoots,
+			"feed-max-roots",
+			s.Skyobject.Quota.MaxRoots,
+			"default quota of Root objects per feed, new Root objects"+
+				" are rejected until cleanup (0 = no limit)")
+		flag.IntVar((*int)(&s.Skyobject.Quota.MaxSpace),
+			"feed-max-space",
+			int(s.Skyobject.Quota.MaxSpace),
+			"default quota of space per feed in bytes (0 = no limit)")
+		flag.IntVar(&s.Skyobject.CleanUpObjects,
+			"cleanup-objects",
+			s.Skyobject.CleanUpObjects,
+			"objects per slice of incremental clean up (0 = full)")
+		flag.DurationVar(&s.Skyobject.CleanUpSlice,
+			"cleanup-slice",
+			s.Skyobject.CleanUpSlice,
+			"time of slice of incremental clean up (0 = full)")
+	}
+
 	return
 }
 
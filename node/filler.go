@@ -23,7 +23,8 @@ type filler struct {
 
 	// must drain
 	full chan *skyobject.Root
-	drop chan skyobject.DropRootError // root reference with error (reason)
+	drop chan skyobject.DropRootError   // root reference with error (reason)
+	prog chan skyobject.FillingProgress // progress of filling
 
 	// filling Roots (hash of Root -> *Filler)
 	fillers map[cipher.SHA256]*skyobject.Filler
@@ -38,6 +39,7 @@ func (n *Node) newFiller() (f *filler) {
 	f.requests = make(map[cipher.SHA256][]chan []byte)
 	f.full = make(chan *skyobject.Root)
 	f.drop = make(chan skyobject.DropRootError)
+	f.prog = make(chan skyobject.FillingProgress)
 	f.fillers = make(map[cipher.SHA256]*skyobject.Filler)
 	return
 }
@@ -49,6 +51,15 @@ func (f *filler) del(r *skyobject.Root) {
 	delete(f.fillers, r.Hash)
 }
 
+// Root of a filler, the ok is false if the filler removed
+func (f *filler) root(hash cipher.SHA256) (r *skyobject.Root, ok bool) {
+	var fr *skyobject.Filler
+	if fr, ok = f.fillers[hash]; ok {
+		r = fr.Root()
+	}
+	return
+}
+
 func (f *filler) waiting(wcxo skyobject.WCXO) {
 	f.requests[wcxo.Hash] = append(f.requests[wcxo.Hash], wcxo.GotQ)
 }
@@ -77,8 +88,10 @@ func (f *filler) fill(r *skyobject.Root) {
 		// - wanted objects (chan of skyobject.WCXO)
 		// - drop Root (chan of skyobject.DropRootError that is {*Root, err})
 		// - a Root is full (chan of *Root)
+		// - progress of filling (chan of skyobject.FillingProgress)
 		// - wait group
-		f.fillers[r.Hash] = f.c.NewFiller(r, f.wantq, f.full, f.drop, &f.wg)
+		f.fillers[r.Hash] = f.c.NewFiller(r, f.wantq, f.full, f.drop, f.prog,
+			&f.wg)
 	}
 }
 
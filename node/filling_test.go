@@ -0,0 +1,84 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/node/gnet"
+	"github.com/skycoin/cxo/skyobject"
+)
+
+func TestNode_fillingProgress(t *testing.T) {
+
+	type call struct {
+		c  *gnet.Conn
+		fp skyobject.FillingProgress
+	}
+	var calls []call
+
+	conf := newConfig(false)
+	conf.OnFillingProgress = func(_ *Node, c *gnet.Conn, r *skyobject.Root,
+		fp skyobject.FillingProgress) {
+
+		if r.Hash != fp.Hash {
+			t.Error("progress of another Root")
+		}
+		calls = append(calls, call{c, fp})
+	}
+
+	s, err := NewNode(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// a Root without registry is dropped by its Filler
+	// immediately, but the Filler keeps the Root
+	pk, _ := cipher.GenerateKeyPair()
+	r := &skyobject.Root{Pub: pk, Hash: cipher.SumSHA256([]byte("root"))}
+
+	fill := s.newFiller()
+	fill.drop = make(chan skyobject.DropRootError, 1)
+	fill.fill(r)
+	fill.wait()
+
+	// the same Root is filled by two connections
+	c1, c2 := new(gnet.Conn), new(gnet.Conn)
+
+	s.fillingProgress(c1, fill, skyobject.FillingProgress{
+		Hash:       r.Hash,
+		Received:   1,
+		Local:      2,
+		Discovered: 5,
+	})
+	s.fillingProgress(c2, fill, skyobject.FillingProgress{
+		Hash:       r.Hash,
+		Received:   3,
+		Discovered: 4,
+	})
+
+	if len(calls) != 2 || calls[0].c != c1 || calls[1].c != c2 {
+		t.Fatalf("wrong calls of OnFillingProgress: %v", calls)
+	}
+	if fp := calls[0].fp; fp.Done() != 3 || fp.Discovered != 5 {
+		t.Errorf("wrong progress: %+v", fp)
+	}
+	if fps := s.Filling(); len(fps) != 2 {
+		t.Fatalf("wrong number of filling Roots: %d", len(fps))
+	}
+
+	// the first connection is done
+	s.fillingDone(c1, r.Hash)
+	if fps := s.Filling(); len(fps) != 1 || fps[0].Received != 3 {
+		t.Errorf("wrong progress after done: %+v", fps)
+	}
+
+	// progress of removed Filler is ignored
+	fill.del(r)
+	s.fillingProgress(c1, fill, skyobject.FillingProgress{Hash: r.Hash})
+	if len(calls) != 2 || len(s.Filling()) != 1 {
+		t.Error("progress of removed Filler is reported")
+	}
+
+}
@@ -3,6 +3,8 @@ package node
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"strings"
 	"sync"
 	"time"
 
@@ -60,6 +62,14 @@ type Node struct {
 	rpmx      sync.Mutex
 	responses map[uint32]chan Msg
 
+	// progress of filling Roots
+	flmx    sync.Mutex
+	filling map[fillingKey]skyobject.FillingProgress
+
+	// Root objects to fill again (see Refetch)
+	rfmx   sync.Mutex
+	refill map[*gnet.Conn]chan *skyobject.Root
+
 	// connections
 	pool *gnet.Pool
 	rpc  *rpcServer // rpc server
@@ -93,10 +103,23 @@ func NewNode(sc Config) (s *Node,
 
 	// database
 
+	if sc.PassphraseFile != "" {
+		var pf []byte
+		if pf, err = ioutil.ReadFile(sc.PassphraseFile); err != nil {
+			return
+		}
+		sc.Passphrase = strings.TrimRight(string(pf), "\r\n")
+	}
+
 	var db data.DB
-	if sc.InMemoryDB {
+	switch {
+	case sc.InMemoryDB && sc.DBLogPath == "":
 		db = data.NewMemoryDB()
-	} else {
+	case sc.InMemoryDB:
+		if db, err = data.NewPersistentMemoryDB(sc.DBLogPath); err != nil {
+			return
+		}
+	default:
 		if sc.DataDir != "" {
 			if err = initDataDir(sc.DataDir); err != nil {
 				return
@@ -106,6 +129,33 @@ func NewNode(sc Config) (s *Node,
 			return
 		}
 	}
+	if sc.Passphrase != "" {
+		var edb data.DB
+		if edb, err = data.NewEncryptedDB(db, []byte(sc.Passphrase)); err != nil {
+			db.Close()
+			return
+		}
+		db = edb
+	} else if data.IsEncrypted(db) {
+		db.Close()
+		err = data.ErrEncrypted // DB can't be used without the passphrase
+		return
+	}
+	if sc.Compression {
+		var cdb data.DB
+		if cdb, err = data.NewCompressedDB(db, sc.Codec); err != nil {
+			db.Close()
+			return
+		}
+		db = cdb
+	} else if data.IsCompressed(db) {
+		db.Close()
+		err = data.ErrCompressed // objects can't be used as is
+		return
+	}
+	if sc.CacheSize > 0 {
+		db = data.NewCacheDB(db, sc.CacheSize)
+	}
 
 	// container
 
@@ -128,6 +178,10 @@ func NewNode(sc Config) (s *Node,
 
 	s.responses = make(map[uint32]chan Msg)
 
+	s.filling = make(map[fillingKey]skyobject.FillingProgress)
+
+	s.refill = make(map[*gnet.Conn]chan *skyobject.Root)
+
 	// fill up feeds from database
 	s.so.DB().View(func(tx data.Tv) (_ error) {
 		for _, pk := range tx.Feeds().List() {
@@ -219,6 +273,7 @@ func (s *Node) start() (err error) {
     remote close:         %t
 
     in-memory DB:         %v
+    DB log path:          %s
     DB path:              %s
 
     debug:                %#v
@@ -252,6 +307,7 @@ func (s *Node) start() (err error) {
 		s.conf.RemoteClose,
 
 		s.conf.InMemoryDB,
+		s.conf.DBLogPath,
 		s.conf.DBPath,
 
 		s.conf.Log.Debug,
@@ -460,10 +516,50 @@ func (s *Node) close(c *gnet.Conn) {
 	c.Close()
 }
 
+// a Root can be filled by many connections
+type fillingKey struct {
+	c    *gnet.Conn
+	hash cipher.SHA256
+}
+
+func (s *Node) fillingProgress(c *gnet.Conn, fill *filler,
+	fp skyobject.FillingProgress) {
+
+	r, ok := fill.root(fp.Hash)
+	if !ok {
+		return // the filler has been removed
+	}
+
+	s.flmx.Lock()
+	s.filling[fillingKey{c, fp.Hash}] = fp
+	s.flmx.Unlock()
+
+	if ofp := s.conf.OnFillingProgress; ofp != nil {
+		ofp(s, c, r, fp)
+	}
+}
+
+func (s *Node) fillingDone(c *gnet.Conn, hash cipher.SHA256) {
+	s.flmx.Lock()
+	defer s.flmx.Unlock()
+
+	delete(s.filling, fillingKey{c, hash})
+}
+
 func (s *Node) dropRoot(c *gnet.Conn, dre *skyobject.Root, err error) {
+	s.fillingDone(c, dre.Hash)
 	if ofb := s.conf.OnFillingBreaks; ofb != nil {
 		ofb(s, c, dre, err)
 	}
+	if skyobject.IsLimitError(err) {
+		// the peer sends malicious or broken Root, drop the peer
+		s.Printf("[ERR] %s sends bad Root %s: %v, closing connection",
+			c.Address(), dre.Short(), err)
+		c.Close()
+	} else if skyobject.IsQuotaError(err) {
+		s.Printf("[ERR] drop Root %s received from %s: %v", dre.Short(),
+			c.Address(), err)
+	}
 	if s.conf.DropNonFullRotos {
 		s.Debug(RootPin, "can't drop non-full Root: feature is not implemented")
 		// TODO (kostyarin): remove root using Container
@@ -473,10 +569,26 @@ func (s *Node) dropRoot(c *gnet.Conn, dre *skyobject.Root, err error) {
 }
 
 func (s *Node) rootFilled(r *skyobject.Root, c *gnet.Conn) {
+	s.fillingDone(c, r.Hash)
 	if orf := s.conf.OnRootFilled; orf != nil {
 		orf(s, c, r)
 	}
 	s.sendToFeed(r.Pub, s.src.NewRootMsg(r.Pub, *r.Pack()), c)
+	if r.IsSuccession() {
+		s.followSuccessor(c, r)
+	}
+}
+
+// follow to new key of a feed, the c can be nil
+func (s *Node) followSuccessor(c *gnet.Conn, r *skyobject.Root) {
+	s.Debugf(RootPin, "feed %s continued by %s", r.Pub.Hex()[:7],
+		r.Successor.Hex()[:7])
+
+	s.Subscribe(c, r.Successor)
+
+	if ofs := s.conf.OnFeedSuccession; ofs != nil {
+		ofs(s, c, r)
+	}
 }
 
 func (s *Node) handleConnection(c *gnet.Conn) {
@@ -485,11 +597,13 @@ func (s *Node) handleConnection(c *gnet.Conn) {
 
 	defer s.await.Done()
 	defer s.close(c)
+	defer s.delRefill(c)
 
 	var (
 		closed  = c.Closed()
 		receive = c.ReceiveQueue()
 		fill    = s.newFiller()
+		refill  = s.addRefill(c)
 
 		data []byte
 		msg  Msg
@@ -512,6 +626,8 @@ func (s *Node) handleConnection(c *gnet.Conn) {
 			case fr := <-fill.full:
 				s.rootFilled(fr, c)
 				fill.del(fr)
+			case fp := <-fill.prog:
+				s.fillingProgress(c, fill, fp)
 			case <-done:
 				for _, fr := range fill.fillers {
 					s.dropRoot(c, fr.Root(), ErrConnClsoed) // drop
@@ -537,12 +653,81 @@ func (s *Node) handleConnection(c *gnet.Conn) {
 		case fr := <-fill.full:
 			s.rootFilled(fr, c)
 			fill.del(fr)
+		case fp := <-fill.prog:
+			s.fillingProgress(c, fill, fp)
 		case wcxo := <-fill.wantq:
 			fill.waiting(wcxo)
 			s.sendRequestDataMsg(c, wcxo.Hash)
+		case r := <-refill:
+			fill.fill(r)
+		}
+	}
+
+}
+
+func (s *Node) addRefill(c *gnet.Conn) (refill chan *skyobject.Root) {
+	s.rfmx.Lock()
+	defer s.rfmx.Unlock()
+
+	refill = make(chan *skyobject.Root)
+	s.refill[c] = refill
+	return
+}
+
+func (s *Node) delRefill(c *gnet.Conn) {
+	s.rfmx.Lock()
+	defer s.rfmx.Unlock()
+
+	delete(s.refill, c)
+}
+
+// Refetch fills again given Root objects requesting missing objects
+// from connections subscribed to feeds of the Root objects. Use it to
+// refetch Root objects unmarked by (*skyobject.Container).Check. The
+// method returns amount of Root objects that will be filled. A Root
+// can't be refetched if there are no connections subscribed to its feed
+func (s *Node) Refetch(ris []skyobject.RootIssue) (n int) {
+
+	// locks: s.fmx RLock/RUnlock, s.rfmx Lock/Unlock
+
+	for _, ri := range ris {
+		r, err := s.so.Root(ri.Feed, ri.Seq)
+		if err != nil {
+			s.Debugf(RootPin, "can't refetch %s: %v", ri.String(), err)
+			continue
+		}
+		if s.refetch(r) {
+			n++
 		}
 	}
+	return
+}
+
+func (s *Node) refetch(r *skyobject.Root) (ok bool) {
+	s.fmx.RLock()
+	cs := make([]*gnet.Conn, 0, len(s.feeds[r.Pub]))
+	for c := range s.feeds[r.Pub] {
+		cs = append(cs, c)
+	}
+	s.fmx.RUnlock()
 
+	for _, c := range cs {
+		s.rfmx.Lock()
+		refill, has := s.refill[c]
+		s.rfmx.Unlock()
+
+		if !has {
+			continue
+		}
+		select {
+		case refill <- r:
+			return true
+		case <-c.Closed():
+		case <-s.quit:
+			return
+		}
+	}
+	return
 }
 
 func (s *Node) subscribeConn(c *gnet.Conn, feed cipher.PubKey) (accept,
@@ -801,7 +986,7 @@ func (s *Node) handleRootMsg(c *gnet.Conn, fill *filler, msg *RootMsg) {
 		return
 	}
 
-	r, err := s.so.AddRoot(msg.Feed, &msg.RootPack)
+	r, err := s.so.AddRootFrom(msg.Feed, &msg.RootPack, c.Address())
 	if err != nil {
 		if err == data.ErrRootAlreadyExists {
 			rbs, full, err := s.so.RootBySeq(msg.Feed, msg.RootPack.Seq)
@@ -1330,11 +1515,31 @@ func (s *Node) RPCAddress() (address string) {
 // Publish given Root (send to feed)
 func (s *Node) Publish(r *skyobject.Root) {
 	s.sendToFeed(r.Pub, s.src.NewRootMsg(r.Pub, *r.Pack()), nil)
+	if r.IsSuccession() {
+		s.followSuccessor(nil, r)
+	}
 }
 
 // Stat of underlying DB and Container
 func (s *Node) Stat() (st Stat) {
 	st.Data = s.DB().Stat()
 	st.CXO = s.Container().Stat()
+	st.Filling = s.Filling()
+	return
+}
+
+// Filling returns progress of filling Roots. A Root
+// filled by many connections is listed many times
+func (s *Node) Filling() (fps []skyobject.FillingProgress) {
+	s.flmx.Lock()
+	defer s.flmx.Unlock()
+
+	if len(s.filling) == 0 {
+		return
+	}
+	fps = make([]skyobject.FillingProgress, 0, len(s.filling))
+	for _, fp := range s.filling {
+		fps = append(fps, fp)
+	}
 	return
 }
@@ -2,8 +2,13 @@ package node
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/rpc"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
@@ -21,6 +26,8 @@ type RPC struct {
 	l  net.Listener
 	rs *rpc.Server
 	ns *Node
+
+	streams rpcStreams // backups, archives, etc
 }
 
 type rpcServer struct {
@@ -59,6 +66,7 @@ func (r *rpcServer) Close() (err error) {
 	if r.l != nil {
 		err = r.l.Close()
 	}
+	r.streams.Close()
 	return
 }
 
@@ -68,6 +76,17 @@ func (r *rpcServer) Close() (err error) {
 
 // - Subscribe
 // - Unsubscribe
+// - StreamRead
+// - StreamWrite
+// - StreamCloseWrite
+// - StreamAbort
+// - Backup
+// - Export
+// - Import
+// - ImportResult
+// - Summary
+// - Bundle
+// - ImportBundle
 // - Feeds
 // - Stat
 // - Connections
@@ -79,6 +98,7 @@ func (r *rpcServer) Close() (err error) {
 // - ListeningAddress
 // - Roots
 // - Tree
+// - RootFromJSON
 // - Terminate
 
 // A ConnFeed represetns connection->feed pair. The struct used
@@ -120,9 +140,30 @@ func (r *RPC) Feeds(_ struct{}, list *[]cipher.PubKey) (_ error) {
 	return
 }
 
+// A FeedQuota represents feed->quota pair. The
+// struct used by RPC method SetQuota
+type FeedQuota struct {
+	Feed  cipher.PubKey
+	Quota skyobject.Quota
+}
+
+// SetQuota sets storage quota of a feed. Use zero
+// quota to use default quota of a node for the feed
+func (r *RPC) SetQuota(fq FeedQuota, _ *struct{}) error {
+	return r.ns.Container().SetQuota(fq.Feed, fq.Quota)
+}
+
+// Quota returns storage quota of a feed
+func (r *RPC) Quota(feed cipher.PubKey, quota *skyobject.Quota) (err error) {
+	*quota, err = r.ns.Container().Quota(feed)
+	return
+}
+
 type Stat struct {
 	Data data.Stat      // data.DB
 	CXO  skyobject.Stat // skyobject.Container
+
+	Filling []skyobject.FillingProgress // filling Roots
 	// TODO: node stat
 }
 
@@ -132,6 +173,218 @@ func (r *RPC) Stat(_ struct{}, stat *Stat) (_ error) {
 	return
 }
 
+// StreamRead reads next chunk of a stream. The stream
+// is removed after last chunk (that has EOF flag) or error
+func (r *RPC) StreamRead(id uint64, chunk *StreamChunk) error {
+	return r.streams.read(id, chunk)
+}
+
+// StreamWrite uploads next chunk of a stream
+func (r *RPC) StreamWrite(chunk StreamChunk, _ *struct{}) error {
+	return r.streams.write(chunk)
+}
+
+// StreamCloseWrite ends uploading to a stream
+func (r *RPC) StreamCloseWrite(id uint64, _ *struct{}) error {
+	return r.streams.closeWrite(id)
+}
+
+// StreamAbort removes a stream and cancels
+// operation of the stream
+func (r *RPC) StreamAbort(id uint64, _ *struct{}) (_ error) {
+	r.streams.remove(id, ErrStreamAborted)
+	return
+}
+
+// Backup starts streaming of consistent snapshot of
+// database of a node (see data.DB.Backup). Use StreamRead
+// to read the snapshot. The snapshot is made using single
+// read-only transaction, that is held until the end, thus
+// a client should read the stream without delays
+func (r *RPC) Backup(_ struct{}, id *uint64) (err error) {
+	*id, err = r.streams.start(func(_ io.Reader,
+		w io.Writer) (_ interface{}, err error) {
+
+		err = r.ns.Container().DB().Backup(w)
+		return
+	})
+	return
+}
+
+// An ExportArgs used by RPC
+type ExportArgs struct {
+	Feed cipher.PubKey
+	Seqs skyobject.SeqRange
+}
+
+// Export starts streaming of archive of a feed (see
+// skyobject.Container.ExportFeed). Use StreamRead to read
+// the archive
+func (r *RPC) Export(args ExportArgs, id *uint64) (err error) {
+	*id, err = r.streams.start(func(_ io.Reader,
+		w io.Writer) (_ interface{}, err error) {
+
+		err = r.ns.Container().ExportFeed(w, args.Feed, args.Seqs)
+		return
+	})
+	return
+}
+
+// Import starts stream of archive to import (see
+// skyobject.Container.ImportArchive). Use StreamWrite to
+// upload the archive and ImportResult to get result. The
+// node subscribes to feeds of imported Root objects
+func (r *RPC) Import(_ struct{}, id *uint64) (err error) {
+	*id, err = r.streams.start(func(rd io.Reader,
+		_ io.Writer) (reply interface{}, err error) {
+
+		var as skyobject.ArchiveStat
+		as, err = r.ns.Container().ImportArchive(rd)
+		for _, pk := range as.Feeds {
+			r.ns.Subscribe(nil, pk)
+		}
+		return as, err
+	})
+	return
+}
+
+// ImportResult ends uploading of an archive or bundle
+// and waits for result of the Import or ImportBundle
+func (r *RPC) ImportResult(id uint64, as *skyobject.ArchiveStat) error {
+	reply, err := r.streams.result(id)
+	if reply != nil {
+		*as = reply.(skyobject.ArchiveStat)
+	}
+	return err
+}
+
+// Summary starts streaming of summary of given feeds, or of
+// all feeds if the list is empty (see skyobject.Container.Summary).
+// Use StreamRead to read encoded skyobject.Summary
+func (r *RPC) Summary(feeds []cipher.PubKey, id *uint64) (err error) {
+	*id, err = r.streams.start(func(_ io.Reader,
+		w io.Writer) (_ interface{}, err error) {
+
+		var s *skyobject.Summary
+		if s, err = r.ns.Container().Summary(feeds...); err != nil {
+			return
+		}
+		_, err = w.Write(s.Encode())
+		return
+	})
+	return
+}
+
+// Bundle starts stream of bundle for summary of another node
+// (see skyobject.Container.WriteBundle). Use StreamWrite and
+// StreamCloseWrite to upload encoded summary, and StreamRead
+// to read the bundle
+func (r *RPC) Bundle(_ struct{}, id *uint64) (err error) {
+	*id, err = r.streams.start(func(rd io.Reader,
+		w io.Writer) (_ interface{}, err error) {
+
+		var summary []byte
+		if summary, err = ioutil.ReadAll(rd); err != nil {
+			return
+		}
+		var s *skyobject.Summary
+		if s, err = skyobject.DecodeSummary(summary); err != nil {
+			return
+		}
+		err = r.ns.Container().WriteBundle(w, s)
+		return
+	})
+	return
+}
+
+// ImportBundle starts stream of bundle created by another node for
+// summary of this one (see skyobject.Container.ImportBundle). Use
+// StreamWrite to upload the bundle and ImportResult to get result.
+// Non-full Root objects of the bundle are filled from connected
+// peers subscribed to feeds of them (see Node.Refetch)
+func (r *RPC) ImportBundle(_ struct{}, id *uint64) (err error) {
+	*id, err = r.streams.start(func(rd io.Reader,
+		_ io.Writer) (reply interface{}, err error) {
+
+		var as skyobject.ArchiveStat
+		as, err = r.ns.Container().ImportBundle(rd)
+		for _, pk := range as.Feeds {
+			r.ns.Subscribe(nil, pk)
+		}
+		r.ns.Refetch(as.NonFull)
+		return as, err
+	})
+	return
+}
+
+// A CheckArgs used by RPC
+type CheckArgs struct {
+	Repair  bool // repair found problems
+	Refetch bool // fill unmarked Root objects again (requires Repair)
+}
+
+// A CheckReply used by RPC
+type CheckReply struct {
+	Report    skyobject.CheckReport
+	Refetched int // amount of Root objects to be filled again
+}
+
+// Check integrity of DB of a node (see skyobject.Container.Check)
+func (r *RPC) Check(args CheckArgs, reply *CheckReply) (err error) {
+	var rep *skyobject.CheckReport
+	if rep, err = r.ns.Container().Check(args.Repair); err != nil {
+		return
+	}
+	reply.Report = *rep
+	if args.Repair && args.Refetch {
+		reply.Refetched = r.ns.Refetch(rep.NonFullRoots)
+	}
+	return
+}
+
+// CleanUpPlan returns what CleanUp would remove
+// (see skyobject.Container.CleanUpPlan)
+func (r *RPC) CleanUpPlan(keepRoots bool,
+	plan *skyobject.CleanUpPlan) (err error) {
+
+	var p *skyobject.CleanUpPlan
+	if p, err = r.ns.Container().CleanUpPlan(keepRoots); err != nil {
+		return
+	}
+	*plan = *p
+	return
+}
+
+// CleanUp removes unused objects (see skyobject.Container.CleanUp)
+func (r *RPC) CleanUp(keepRoots bool, _ *struct{}) error {
+	return r.ns.Container().CleanUp(keepRoots)
+}
+
+// Registries of a node (see skyobject.Container.Registries)
+func (r *RPC) Registries(_ struct{},
+	ris *[]skyobject.RegistryInfo) (err error) {
+
+	*ris, err = r.ns.Container().Registries()
+	return
+}
+
+// Registry returns encoded registry by reference
+// (see skyobject.Container.FindRegistry)
+func (r *RPC) Registry(rr skyobject.RegistryRef, reg *[]byte) (err error) {
+	var rg *skyobject.Registry
+	if rg, err = r.ns.Container().FindRegistry(rr); err != nil {
+		return
+	}
+	*reg = rg.Encode()
+	return
+}
+
+// DelRegistry removes unused registry
+// (see skyobject.Container.DelRegistry)
+func (r *RPC) DelRegistry(rr skyobject.RegistryRef, _ *struct{}) error {
+	return r.ns.Container().DelRegistry(rr)
+}
+
 // Connections of a node
 func (r *RPC) Connections(_ struct{}, list *[]string) (_ error) {
 	cs := r.ns.pool.Connections()
@@ -209,15 +462,9 @@ func (r *RPC) Roots(feed cipher.PubKey, roots *[]RootInfo) (_ error) {
 		}
 		return roots.Range(func(rp *data.RootPack) (err error) {
 			var ri RootInfo
-			var root *skyobject.Root
-			root, err = r.ns.Container().PackToRoot(feed, rp)
-			if err != nil {
+			if ri, err = r.rootInfo(feed, roots, rp); err != nil {
 				return
 			}
-			ri.Hash = rp.Hash
-			ri.Time = time.Unix(0, root.Time)
-			ri.Seq = rp.Seq
-			ri.IsFull = rp.IsFull
 			rs = append(rs, ri)
 			return
 		})
@@ -226,10 +473,77 @@ func (r *RPC) Roots(feed cipher.PubKey, roots *[]RootInfo) (_ error) {
 	return
 }
 
+func (r *RPC) rootInfo(feed cipher.PubKey, roots data.ViewRoots,
+	rp *data.RootPack) (ri RootInfo, err error) {
+
+	var root *skyobject.Root
+	if root, err = r.ns.Container().PackToRoot(feed, rp); err != nil {
+		return
+	}
+	ri.Hash = rp.Hash
+	ri.Time = time.Unix(0, root.Time)
+	ri.Seq = rp.Seq
+	ri.IsFull = roots.Meta(rp.Seq).IsFull
+	return
+}
+
+// A RootsPage selects page of root objects of a feed
+type RootsPage struct {
+	Feed    cipher.PubKey
+	From    uint64 // first seq
+	Time    int64  // if not zero, then first seq is seq of Root by the time
+	Limit   int    // max roots of the page (0 = no limit)
+	Reverse bool   // from new roots to old
+}
+
+// A RootsPageReply is page of root objects
+type RootsPageReply struct {
+	Roots []RootInfo
+	Next  uint64 // seq to request next page from
+	More  bool   // false if it's the last page
+}
+
+// RootsPage returns basic information about root objects of a feed
+// page by page. Use Next field of the reply as From of next request
+func (r *RPC) RootsPage(sel RootsPage, reply *RootsPageReply) (err error) {
+	rs := make([]RootInfo, 0)
+	err = r.ns.DB().View(func(tx data.Tv) (err error) {
+		roots := tx.Feeds().Roots(sel.Feed)
+		if roots == nil {
+			return skyobject.ErrNoSuchFeed
+		}
+		from := sel.From
+		if sel.Time != 0 {
+			var ok bool
+			if from, ok = roots.SeqByTime(sel.Time); !ok {
+				return
+			}
+		}
+		fn := func(rp *data.RootPack) (err error) {
+			var ri RootInfo
+			if ri, err = r.rootInfo(sel.Feed, roots, rp); err != nil {
+				return
+			}
+			rs = append(rs, ri)
+			return
+		}
+		if sel.Reverse {
+			reply.Next, reply.More, err = roots.ReverseFrom(from, sel.Limit, fn)
+		} else {
+			reply.Next, reply.More, err = roots.RangeFrom(from, sel.Limit, fn)
+		}
+		return
+	})
+	reply.Roots = rs
+	return
+}
+
 type SelectRoot struct {
 	Pub      cipher.PubKey
 	Seq      uint64
 	LastFull bool // ignore the seq and print last full of the feed
+	JSON     bool // JSON instead of tree (see Container.TreeJSON)
+	Inline   bool // inline values of references (JSON only)
 }
 
 // Tree prints objects tree of chosen root object (chosen by pk+seq)
@@ -246,10 +560,69 @@ func (r *RPC) Tree(sel SelectRoot, tree *string) (err error) {
 	if root == nil {
 		*tree = "<not found>"
 	}
+	if sel.JSON {
+		var js []byte
+		if js, err = r.ns.so.TreeJSON(root, sel.Inline); err == nil {
+			*tree = string(js)
+		}
+		return
+	}
 	*tree = r.ns.so.Inspect(root)
 	return
 }
 
+// A RootFromJSON used by RPC to create Root from JSON. The
+// secret key is never sent by RPC, since RPC is not encrypted.
+// Instead, the SecFile is path to file with hex-encoded secret
+// key on host of the node
+type RootFromJSON struct {
+	Pub     cipher.PubKey
+	SecFile string                // file with secret key
+	Reg     skyobject.RegistryRef // registry of the JSON
+	Tree    []byte                // JSON created by Tree with JSON flag
+}
+
+// RootFromJSON creates, saves and publishes new Root of a feed
+// using JSON (see skyobject.Container.RootFromJSON). The Registry
+// must exist
+func (r *RPC) RootFromJSON(args RootFromJSON, ri *RootInfo) (err error) {
+	var sk cipher.SecKey
+	if sk, err = readSecKey(args.SecFile); err != nil {
+		return
+	}
+	var reg *skyobject.Registry
+	if reg, err = r.ns.Container().FindRegistry(args.Reg); err != nil {
+		return
+	}
+	r.ns.Subscribe(nil, args.Pub)
+	var root *skyobject.Root
+	root, err = r.ns.Container().RootFromJSON(args.Pub, sk, reg,
+		args.Tree)
+	if err != nil {
+		return
+	}
+	r.ns.Publish(root)
+	ri.Time = time.Unix(0, root.Time)
+	ri.Seq = root.Seq
+	ri.Hash = root.Hash
+	ri.IsFull = true
+	return
+}
+
+// readSecKey reads hex-encoded secret key from given file
+func readSecKey(path string) (sk cipher.SecKey, err error) {
+	if !filepath.IsAbs(path) {
+		err = fmt.Errorf("path to file with secret key is not absolute: %q",
+			path)
+		return
+	}
+	var hex []byte
+	if hex, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	return cipher.SecKeyFromHex(strings.TrimSpace(string(hex)))
+}
+
 // Terminate remote Node if allowed by it s configurations
 func (r *RPC) Terminate(_ struct{}, _ *struct{}) (err error) {
 	if !r.ns.conf.RemoteClose {
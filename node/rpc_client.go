@@ -1,9 +1,13 @@
 package node
 
 import (
+	"io"
+	"io/ioutil"
 	"net/rpc"
 
 	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/skyobject"
 )
 
 // A RPCClient represents RPC client to
@@ -54,12 +58,195 @@ func (r *RPCClient) Feeds() (list []cipher.PubKey, err error) {
 	return
 }
 
+// SetQuota sets storage quota of given feed. Use zero
+// quota to use default quota of the node for the feed
+func (r *RPCClient) SetQuota(feed cipher.PubKey,
+	quota skyobject.Quota) (err error) {
+
+	err = r.c.Call("cxo.SetQuota", FeedQuota{feed, quota}, &struct{}{})
+	return
+}
+
+// Quota returns storage quota of given feed
+func (r *RPCClient) Quota(feed cipher.PubKey) (quota skyobject.Quota,
+	err error) {
+
+	err = r.c.Call("cxo.Quota", feed, &quota)
+	return
+}
+
 // Stat returns database statistic
 func (r *RPCClient) Stat() (stat Stat, err error) {
 	r.c.Call("cxo.Stat", struct{}{}, &stat)
 	return
 }
 
+// download stream with given ID to given writer
+// chunk by chunk (see RPC.StreamRead)
+func (r *RPCClient) download(id uint64, w io.Writer) (err error) {
+	var chunk StreamChunk
+	for !chunk.EOF {
+		chunk = StreamChunk{}
+		if err = r.c.Call("cxo.StreamRead", id, &chunk); err != nil {
+			return
+		}
+		if _, err = w.Write(chunk.Data); err != nil {
+			r.c.Call("cxo.StreamAbort", id, &struct{}{})
+			return
+		}
+	}
+	return
+}
+
+// upload data from given reader to stream with
+// given ID chunk by chunk (see RPC.StreamWrite)
+func (r *RPCClient) upload(id uint64, rd io.Reader) (err error) {
+	buf := make([]byte, RPCChunkSize)
+	for {
+		var n int
+		n, err = io.ReadFull(rd, buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = nil
+		} else if err != nil {
+			r.c.Call("cxo.StreamAbort", id, &struct{}{})
+			return
+		}
+		if n > 0 {
+			err = r.c.Call("cxo.StreamWrite", StreamChunk{ID: id,
+				Data: buf[:n]}, &struct{}{})
+			if err != nil {
+				return
+			}
+		}
+		if n < len(buf) {
+			break
+		}
+	}
+	err = r.c.Call("cxo.StreamCloseWrite", id, &struct{}{})
+	return
+}
+
+// Backup writes consistent snapshot of database of
+// the node to given writer. Use data.RestoreDriveDB or
+// data.RestoreMemoryDB to restore the database
+func (r *RPCClient) Backup(w io.Writer) (err error) {
+	var id uint64
+	if err = r.c.Call("cxo.Backup", struct{}{}, &id); err != nil {
+		return
+	}
+	return r.download(id, w)
+}
+
+// Export writes archive of given feed to given writer.
+// See RPC.Export for details
+func (r *RPCClient) Export(w io.Writer, feed cipher.PubKey,
+	seqs skyobject.SeqRange) (err error) {
+
+	var id uint64
+	if err = r.c.Call("cxo.Export", ExportArgs{feed, seqs}, &id); err != nil {
+		return
+	}
+	return r.download(id, w)
+}
+
+// Import archive read from given reader. See RPC.Import
+func (r *RPCClient) Import(rd io.Reader) (as skyobject.ArchiveStat,
+	err error) {
+
+	var id uint64
+	if err = r.c.Call("cxo.Import", struct{}{}, &id); err != nil {
+		return
+	}
+	if err = r.upload(id, rd); err != nil {
+		return
+	}
+	err = r.c.Call("cxo.ImportResult", id, &as)
+	return
+}
+
+// Summary writes encoded summary of given feeds (all feeds
+// if the list is empty) to given writer. See RPC.Summary
+func (r *RPCClient) Summary(w io.Writer, feeds ...cipher.PubKey) (err error) {
+	var id uint64
+	if err = r.c.Call("cxo.Summary", feeds, &id); err != nil {
+		return
+	}
+	return r.download(id, w)
+}
+
+// Bundle reads encoded summary of another node from given
+// reader and writes bundle to given writer. See RPC.Bundle
+func (r *RPCClient) Bundle(w io.Writer, summary io.Reader) (err error) {
+	var id uint64
+	if err = r.c.Call("cxo.Bundle", struct{}{}, &id); err != nil {
+		return
+	}
+	if err = r.upload(id, summary); err != nil {
+		return
+	}
+	return r.download(id, w)
+}
+
+// ImportBundle read from given reader. See RPC.ImportBundle
+func (r *RPCClient) ImportBundle(rd io.Reader) (as skyobject.ArchiveStat,
+	err error) {
+
+	var id uint64
+	if err = r.c.Call("cxo.ImportBundle", struct{}{}, &id); err != nil {
+		return
+	}
+	if err = r.upload(id, rd); err != nil {
+		return
+	}
+	err = r.c.Call("cxo.ImportResult", id, &as)
+	return
+}
+
+// Check integrity of DB of remote node. See RPC.Check
+func (r *RPCClient) Check(repair, refetch bool) (reply CheckReply,
+	err error) {
+
+	err = r.c.Call("cxo.Check", CheckArgs{repair, refetch}, &reply)
+	return
+}
+
+// CleanUpPlan returns what CleanUp would remove. See RPC.CleanUpPlan
+func (r *RPCClient) CleanUpPlan(keepRoots bool) (plan skyobject.CleanUpPlan,
+	err error) {
+
+	err = r.c.Call("cxo.CleanUpPlan", keepRoots, &plan)
+	return
+}
+
+// CleanUp removes unused objects. See RPC.CleanUp
+func (r *RPCClient) CleanUp(keepRoots bool) (err error) {
+	err = r.c.Call("cxo.CleanUp", keepRoots, &struct{}{})
+	return
+}
+
+// Registries of remote node. See RPC.Registries
+func (r *RPCClient) Registries() (ris []skyobject.RegistryInfo, err error) {
+	err = r.c.Call("cxo.Registries", struct{}{}, &ris)
+	return
+}
+
+// Registry of remote node by reference. See RPC.Registry
+func (r *RPCClient) Registry(rr skyobject.RegistryRef) (reg *skyobject.Registry,
+	err error) {
+
+	var val []byte
+	if err = r.c.Call("cxo.Registry", rr, &val); err != nil {
+		return
+	}
+	return skyobject.DecodeRegistry(val)
+}
+
+// DelRegistry removes unused registry. See RPC.DelRegistry
+func (r *RPCClient) DelRegistry(rr skyobject.RegistryRef) (err error) {
+	err = r.c.Call("cxo.DelRegistry", rr, &struct{}{})
+	return
+}
+
 // Connections return list of all connections
 func (r *RPCClient) Connections() (list []string, err error) {
 	err = r.c.Call("cxo.Connections", struct{}{}, &list)
@@ -102,12 +289,56 @@ func (r *RPCClient) Roots(feed cipher.PubKey) (ris []RootInfo, err error) {
 	return
 }
 
+// RootsPage returns brief information about root objects
+// of a feed page by page. See RPC.RootsPage for details
+func (r *RPCClient) RootsPage(sel RootsPage) (reply RootsPageReply,
+	err error) {
+
+	err = r.c.Call("cxo.RootsPage", sel, &reply)
+	return
+}
+
 // Tree returns strigified objects tree of a root object. The
 // method useful for inspecting
 func (r *RPCClient) Tree(pk cipher.PubKey, seq uint64,
 	lastFull bool) (tree string, err error) {
 
-	err = r.c.Call("cxo.Tree", SelectRoot{pk, seq, lastFull}, &tree)
+	err = r.c.Call("cxo.Tree", SelectRoot{
+		Pub:      pk,
+		Seq:      seq,
+		LastFull: lastFull,
+	}, &tree)
+	return
+}
+
+// TreeJSON returns JSON of objects tree of a root object.
+// See skyobject.Container.TreeJSON for details
+func (r *RPCClient) TreeJSON(pk cipher.PubKey, seq uint64, lastFull,
+	inline bool) (tree string, err error) {
+
+	err = r.c.Call("cxo.Tree", SelectRoot{
+		Pub:      pk,
+		Seq:      seq,
+		LastFull: lastFull,
+		JSON:     true,
+		Inline:   inline,
+	}, &tree)
+	return
+}
+
+// RootFromJSON creates new root object of a feed from JSON
+// read from given reader. The secFile is absolute path to file
+// with hex-encoded secret key on host of the node. See
+// RPC.RootFromJSON
+func (r *RPCClient) RootFromJSON(pk cipher.PubKey, secFile string,
+	rr skyobject.RegistryRef, rd io.Reader) (ri RootInfo, err error) {
+
+	var tree []byte
+	if tree, err = ioutil.ReadAll(rd); err != nil {
+		return
+	}
+	err = r.c.Call("cxo.RootFromJSON", RootFromJSON{pk, secFile, rr, tree},
+		&ri)
 	return
 }
 
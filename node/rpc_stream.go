@@ -0,0 +1,225 @@
+package node
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// limits of RPC streams
+const (
+	RPCChunkSize     int           = 1 << 20          // max chunk size
+	RPCStreamTimeout time.Duration = 30 * time.Second // idle stream timeout
+	RPCMaxStreams    int           = 16               // max opened streams
+)
+
+// errors of RPC streams
+var (
+	ErrNoSuchStream   = errors.New("no such stream")
+	ErrTooManyStreams = errors.New("too many streams")
+	ErrStreamBusy     = errors.New("stream is busy")
+	ErrStreamTimeout  = errors.New("stream timeout")
+	ErrStreamAborted  = errors.New("stream aborted")
+	ErrStreamClosed   = errors.New("stream closed")
+)
+
+// A StreamChunk used by RPC to read and write streams
+type StreamChunk struct {
+	ID   uint64 // stream
+	Data []byte // up to RPCChunkSize bytes
+	EOF  bool   // end of the stream (read only)
+}
+
+// An rpcStream is result of long operation (e.g. backup) streamed
+// by RPC chunk by chunk. The operation reads data uploaded by
+// client from the in pipe and writes result to the out pipe,
+// thus neither side keeps whole data in memory
+type rpcStream struct {
+	inr  *io.PipeReader // operation side
+	inw  *io.PipeWriter // client side
+	outr *io.PipeReader // client side
+	outw *io.PipeWriter // operation side
+
+	timer *time.Timer // idle timeout
+	busy  bool        // used by a request
+
+	done  chan struct{} // closed after the operation
+	reply interface{}   // result of the operation
+	err   error         // error of the operation
+}
+
+// close all pipes with given error
+func (s *rpcStream) close(err error) {
+	s.inr.CloseWithError(err)
+	s.inw.CloseWithError(err)
+	s.outr.CloseWithError(err)
+	s.outw.CloseWithError(err)
+}
+
+// rpcStreams is set of opened streams
+type rpcStreams struct {
+	mx   sync.Mutex
+	seq  uint64
+	open map[uint64]*rpcStream
+}
+
+// start operation in separate goroutine, the operation reads
+// uploaded data from the rd and writes result to the w
+func (r *rpcStreams) start(fn func(rd io.Reader,
+	w io.Writer) (reply interface{}, err error)) (id uint64, err error) {
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.open == nil {
+		r.open = make(map[uint64]*rpcStream)
+	}
+	if len(r.open) >= RPCMaxStreams {
+		err = ErrTooManyStreams
+		return
+	}
+
+	r.seq++
+	id = r.seq
+
+	s := &rpcStream{done: make(chan struct{})}
+	s.inr, s.inw = io.Pipe()
+	s.outr, s.outw = io.Pipe()
+	s.timer = time.AfterFunc(RPCStreamTimeout, func() {
+		r.remove(id, ErrStreamTimeout)
+	})
+	r.open[id] = s
+
+	go func() {
+		defer close(s.done)
+		s.reply, s.err = fn(s.inr, s.outw)
+		if s.err != nil {
+			s.outw.CloseWithError(s.err) // client reads the error
+			s.inr.CloseWithError(s.err)  // client writes the error
+			return
+		}
+		s.outw.Close()                        // EOF
+		s.inr.CloseWithError(ErrStreamClosed) // no more uploads
+	}()
+	return
+}
+
+// acquire stream and stop its timer, the
+// stream must be released after using
+func (r *rpcStreams) acquire(id uint64) (s *rpcStream, err error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	var ok bool
+	if s, ok = r.open[id]; !ok {
+		return nil, ErrNoSuchStream
+	}
+	if s.busy {
+		return nil, ErrStreamBusy
+	}
+	if !s.timer.Stop() {
+		return nil, ErrNoSuchStream // timed out
+	}
+	s.busy = true
+	return
+}
+
+// release acquired stream
+func (r *rpcStreams) release(s *rpcStream) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	s.busy = false
+	s.timer.Reset(RPCStreamTimeout)
+}
+
+// remove stream, closing it with given error
+// and waiting for end of the operation
+func (r *rpcStreams) remove(id uint64, err error) {
+	r.mx.Lock()
+	s, ok := r.open[id]
+	delete(r.open, id)
+	r.mx.Unlock()
+
+	if ok {
+		s.timer.Stop()
+		s.close(err)
+		<-s.done
+	}
+}
+
+// read next chunk of result of an operation,
+// the stream is removed after the end
+func (r *rpcStreams) read(id uint64, chunk *StreamChunk) (err error) {
+	var s *rpcStream
+	if s, err = r.acquire(id); err != nil {
+		return
+	}
+	buf := make([]byte, RPCChunkSize)
+	var n int
+	n, err = io.ReadFull(s.outr, buf)
+	chunk.ID, chunk.Data = id, buf[:n]
+	switch err {
+	case nil:
+		r.release(s)
+		return
+	case io.EOF, io.ErrUnexpectedEOF:
+		chunk.EOF, err = true, nil
+	}
+	r.remove(id, ErrStreamClosed)
+	return
+}
+
+// write next chunk of data uploaded for an operation
+func (r *rpcStreams) write(chunk StreamChunk) (err error) {
+	var s *rpcStream
+	if s, err = r.acquire(chunk.ID); err != nil {
+		return
+	}
+	if _, err = s.inw.Write(chunk.Data); err != nil {
+		r.remove(chunk.ID, err)
+		return
+	}
+	r.release(s)
+	return
+}
+
+// closeWrite ends uploading
+func (r *rpcStreams) closeWrite(id uint64) (err error) {
+	var s *rpcStream
+	if s, err = r.acquire(id); err != nil {
+		return
+	}
+	s.inw.Close()
+	r.release(s)
+	return
+}
+
+// result ends uploading and waits for reply of
+// the operation, the operation must not write
+func (r *rpcStreams) result(id uint64) (reply interface{}, err error) {
+	var s *rpcStream
+	if s, err = r.acquire(id); err != nil {
+		return
+	}
+	s.inw.Close()
+	s.outr.CloseWithError(ErrStreamClosed)
+	<-s.done
+	r.remove(id, ErrStreamClosed)
+	return s.reply, s.err
+}
+
+// close all streams
+func (r *rpcStreams) Close() {
+	r.mx.Lock()
+	ids := make([]uint64, 0, len(r.open))
+	for id := range r.open {
+		ids = append(ids, id)
+	}
+	r.mx.Unlock()
+
+	for _, id := range ids {
+		r.remove(id, ErrStreamAborted)
+	}
+}
@@ -0,0 +1,548 @@
+package skyobject
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// ArchiveVersion is version of format of archives
+// written by ExportFeed. ImportArchive reads archives
+// of this version and older
+const ArchiveVersion byte = 1
+
+// archive related errors
+var (
+	ErrNotArchive       = errors.New("not an archive")
+	ErrMalformedArchive = errors.New("malformed archive")
+	ErrIncompleteRoot   = errors.New("archive contains incomplete Root")
+)
+
+// archive format:
+//
+//     magic, version
+//     records: kind, uvarint length, payload
+//
+// an archive contains objects of a Root before
+// the Root, every object is sent once
+var archiveMagic = []byte("CXOA")
+
+// kinds of records of archive
+const (
+	archiveEnd    byte = iota // end of archive
+	archiveObject             // object (registry, certificate, etc)
+	archiveRoot               // encoded data.RootPack
+)
+
+// A SeqRange represents range of seq numbers
+// (inclusive) of Root objects of a feed
+type SeqRange struct {
+	From uint64
+	To   uint64
+}
+
+// AllSeqs is SeqRange that contains all Root objects of a feed
+var AllSeqs = SeqRange{0, math.MaxUint64}
+
+// An ArchiveStat represents result of ImportArchive
+// and ImportBundle
+type ArchiveStat struct {
+	Feeds   []cipher.PubKey // feeds of imported Root objects
+	Roots   int             // imported Root objects
+	Objects int             // received objects
+
+	// NonFull Root objects imported without fullness
+	// mark (bundles only), see ImportBundle
+	NonFull []RootIssue
+}
+
+type archiveWriter struct {
+	w    *bufio.Writer
+	head [1 + binary.MaxVarintLen64]byte
+}
+
+// newArchiveWriter writes head of archive
+func newArchiveWriter(w io.Writer) (aw *archiveWriter, err error) {
+	aw = &archiveWriter{w: bufio.NewWriter(w)}
+	if _, err = aw.w.Write(archiveMagic); err != nil {
+		return
+	}
+	err = aw.w.WriteByte(ArchiveVersion)
+	return
+}
+
+// close writes end of archive and flushes buffer
+func (a *archiveWriter) close() (err error) {
+	if err = a.record(archiveEnd, nil); err != nil {
+		return
+	}
+	return a.w.Flush()
+}
+
+func (a *archiveWriter) record(kind byte, payload []byte) (err error) {
+	a.head[0] = kind
+	n := 1 + binary.PutUvarint(a.head[1:], uint64(len(payload)))
+	if _, err = a.w.Write(a.head[:n]); err != nil {
+		return
+	}
+	_, err = a.w.Write(payload)
+	return
+}
+
+// ExportFeed writes full Root objects of given feed with seq numbers in
+// given range and all objects of them to given writer. The archive is
+// written using single read-only transaction and can be imported by
+// ImportArchive. Non-full Root objects are skipped
+func (c *Container) ExportFeed(w io.Writer, feed cipher.PubKey,
+	sr SeqRange) (err error) {
+
+	c.Debugln(VerbosePin, "ExportFeed", feed.Hex()[:7], sr.From, sr.To)
+
+	var aw *archiveWriter
+	if aw, err = newArchiveWriter(w); err != nil {
+		return
+	}
+
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		roots := tx.Feeds().Roots(feed)
+		if roots == nil {
+			return ErrNoSuchFeed
+		}
+		objs := tx.Objects()
+		seen := make(map[cipher.SHA256]struct{})
+		_, _, err = roots.RangeFrom(sr.From, 0, func(rp *data.RootPack) error {
+			if rp.Seq > sr.To {
+				return data.ErrStopRange
+			}
+			if !roots.Meta(rp.Seq).IsFull {
+				return nil // skip
+			}
+			return c.exportRoot(aw, feed, rp, objs, seen, nil)
+		})
+		return
+	})
+	if err != nil {
+		return
+	}
+	return aw.close()
+}
+
+// export objects of a Root and the Root; objects
+// that are in given filter are skipped if the
+// filter is not nil
+func (c *Container) exportRoot(aw *archiveWriter, feed cipher.PubKey,
+	rp *data.RootPack, objs data.ViewObjects,
+	seen map[cipher.SHA256]struct{}, filter *BloomFilter) (err error) {
+
+	var r *Root
+	if r, err = c.unpackRoot(feed, rp); err != nil {
+		return
+	}
+
+	fn := func(hash cipher.SHA256) (deeper bool, err error) {
+		if _, ok := seen[hash]; ok {
+			return
+		}
+		if filter != nil && filter.Has(hash) {
+			return // the receiver has entire subtree
+		}
+		val := objs.Get(hash)
+		if val == nil {
+			return false, fmt.Errorf("missing object [%s] of full Root %s",
+				hash.Hex()[:7], r.Short())
+		}
+		if err = aw.record(archiveObject, val); err != nil {
+			return
+		}
+		seen[hash] = struct{}{}
+		return true, nil
+	}
+
+	// registry and certificate
+	if _, err = fn(cipher.SHA256(r.Reg)); err != nil {
+		return
+	}
+	if r.Cert != (cipher.SHA256{}) {
+		if _, err = fn(r.Cert); err != nil {
+			return
+		}
+	}
+
+	var reg *Registry
+	if reg, err = c.registryOf(r.Reg, objs); err != nil {
+		return
+	}
+	var kn knowsAbout
+	kn.fn = fn
+	kn.g = objs
+	kn.reg = reg
+	for _, dr := range r.Refs {
+		if err = kn.Dynamic(dr); err != nil {
+			return
+		}
+	}
+
+	return aw.record(archiveRoot, encoder.Serialize(rp))
+}
+
+// ImportArchive reads archive written by ExportFeed. It checks
+// hashes of all objects, checks hashes and signatures of Root
+// objects, and checks that every Root is full. Objects of a Root
+// are validated (see Config.Validators) and checked against quota
+// of feed of the Root (see Quota) before they saved. Objects of a
+// Root, the Root and its fullness mark are saved using single
+// transaction, objects that don't belong to the Root are ignored.
+// Feeds of the Root objects added if they don't exist. Root objects
+// that already exist are marked as full. The ImportArchive stops on
+// first error, but Root objects imported before are kept
+func (c *Container) ImportArchive(r io.Reader) (as ArchiveStat, err error) {
+	c.Debugln(VerbosePin, "ImportArchive")
+
+	return c.importArchive(r, false)
+}
+
+// importArchive imports archive or bundle; if the partial is true,
+// then incomplete Root objects are imported without fullness mark
+func (c *Container) importArchive(r io.Reader, partial bool) (as ArchiveStat,
+	err error) {
+
+	br := bufio.NewReader(r)
+
+	head := make([]byte, len(archiveMagic)+1)
+	if _, err = io.ReadFull(br, head); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrNotArchive
+		}
+		return
+	}
+	if string(head[:len(archiveMagic)]) != string(archiveMagic) {
+		err = ErrNotArchive
+		return
+	}
+	if version := head[len(archiveMagic)]; version > ArchiveVersion {
+		err = fmt.Errorf("unsupported version of archive: %d", version)
+		return
+	}
+
+	var (
+		kind    byte
+		payload []byte
+		feeds   = make(map[cipher.PubKey]struct{})
+		pending = make(map[cipher.SHA256][]byte)
+		size    int // size of pending objects
+	)
+
+	for {
+		if kind, payload, err = c.readRecord(br); err != nil {
+			return
+		}
+		switch kind {
+		case archiveEnd:
+			return
+		case archiveObject:
+			pending[cipher.SumSHA256(payload)] = payload
+			as.Objects++
+			// limits of a Root keep the pending objects in memory
+			size += len(payload)
+			if err = c.archiveLimit(len(pending), size); err != nil {
+				return
+			}
+		case archiveRoot:
+			var pk cipher.PubKey
+			var rp *data.RootPack
+			var full bool
+			pk, rp, full, err = c.importRoot(payload, pending, partial)
+			if err != nil {
+				return
+			}
+			if !full {
+				as.NonFull = append(as.NonFull, RootIssue{
+					Feed:   pk,
+					Seq:    rp.Seq,
+					Hash:   rp.Hash,
+					Reason: ErrIncompleteRoot.Error(),
+				})
+			}
+			pending, size = make(map[cipher.SHA256][]byte), 0
+			if _, ok := feeds[pk]; !ok {
+				feeds[pk] = struct{}{}
+				as.Feeds = append(as.Feeds, pk)
+			}
+			as.Roots++
+		default:
+			err = ErrMalformedArchive
+			return
+		}
+	}
+}
+
+// archiveLimit checks limits of a Root (see Config) for
+// given amount and size of objects of the Root received
+func (c *Container) archiveLimit(amount, size int) (err error) {
+	if max := c.conf.MaxRootObjects; max > 0 && amount > max {
+		return ErrTooManyObjects
+	}
+	if max := c.conf.MaxRootSize; max > 0 && size > max {
+		return ErrRootTooLarge
+	}
+	return
+}
+
+func (c *Container) readRecord(br *bufio.Reader) (kind byte, payload []byte,
+	err error) {
+
+	if kind, err = br.ReadByte(); err != nil {
+		if err == io.EOF {
+			err = ErrMalformedArchive // unexpected end
+		}
+		return
+	}
+	var ln uint64
+	if ln, err = binary.ReadUvarint(br); err != nil {
+		err = ErrMalformedArchive
+		return
+	}
+	if max := c.conf.MaxObjectSize; max > 0 && ln > uint64(max) {
+		err = ErrObjectTooLarge
+		return
+	}
+	payload = make([]byte, ln)
+	if _, err = io.ReadFull(br, payload); err != nil {
+		err = ErrMalformedArchive
+	}
+	return
+}
+
+// import Root with given objects
+func (c *Container) importRoot(val []byte, objects map[cipher.SHA256][]byte,
+	partial bool) (pk cipher.PubKey, rp *data.RootPack, full bool,
+	err error) {
+
+	rp = new(data.RootPack)
+	if err = encoder.DeserializeRaw(val, rp); err != nil {
+		return
+	}
+	var r *Root
+	if r, err = DecodeRoot(rp.Root); err != nil {
+		return
+	}
+	pk = r.Pub
+	if r, err = c.unpackRoot(pk, rp); err != nil {
+		return
+	}
+	if r.Seq != rp.Seq || r.Prev != rp.Prev {
+		err = ErrMalformedArchive
+		return
+	}
+
+	if err = c.verifyRoot(r, rp); err == ErrMissingCertificate {
+		err = c.verifyArchived(r, objects)
+	}
+	if err != nil {
+		return
+	}
+
+	// check the Root before writing anything
+
+	var (
+		reg    *Registry
+		save   map[cipher.SHA256][]byte // new objects of the Root
+		exists bool                     // the Root already exists
+	)
+
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		if roots := tx.Feeds().Roots(pk); roots != nil {
+			if erp := roots.Get(rp.Seq); erp != nil {
+				if erp.Hash != rp.Hash {
+					return fmt.Errorf("Root %s differs from existing one",
+						r.Short())
+				}
+				exists = true
+			}
+		}
+		reg, save, full, err = c.archivedTree(r, objects, tx.Objects())
+		return
+	})
+	if err != nil {
+		return
+	}
+	if !full && !partial {
+		err = ErrIncompleteRoot
+		return
+	}
+	if err = c.checkArchivedQuota(pk, exists, save); err != nil {
+		return
+	}
+
+	// save objects, the Root and its meta using single transaction
+
+	for hash := range save {
+		c.inc.shade(hash) // keep them from incremental CleanUp
+	}
+
+	err = c.DB().Update(func(tx data.Tu) (err error) {
+		feeds := tx.Feeds()
+		if err = feeds.Add(pk); err != nil {
+			return
+		}
+		objs := tx.Objects()
+		if err = objs.SetMap(save); err != nil {
+			return
+		}
+		roots := feeds.Roots(pk)
+		if err = roots.Add(rp); err != nil && err != data.ErrRootAlreadyExists {
+			return
+		}
+		rm := roots.Meta(rp.Seq)
+		rm.IsFull = rm.IsFull || full
+		rm.Time = r.Time
+		if err = roots.SetMeta(rp.Seq, rm); err != nil {
+			return
+		}
+		if r.IsSuccession() {
+			if err = feeds.SetSuccessor(r.Pub, r.Successor); err != nil {
+				return
+			}
+		}
+		if err = saveRevoked(tx, r); err != nil {
+			return
+		}
+		if full {
+			err = c.countSpaceTx(tx, r)
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	if reg != nil && c.Registry(r.Reg) == nil {
+		c.addRegistry(reg)
+	}
+	if r.IsOwn() {
+		c.revoked.add(pk, r.Revoked)
+	}
+	return
+}
+
+// an archiveGetter gets objects received from
+// archive or from given getter
+type archiveGetter struct {
+	objects map[cipher.SHA256][]byte
+	objs    getter
+}
+
+func (a *archiveGetter) Get(key cipher.SHA256) []byte {
+	if val, ok := a.objects[key]; ok {
+		return val
+	}
+	return a.objs.Get(key)
+}
+
+// archivedTree walks through objects of given Root looking for them in
+// received objects and in DB. It validates received objects that are
+// not in DB and returns them. Received objects that don't belong to
+// the Root are ignored. The reg is nil if the Registry of the Root is
+// missing
+func (c *Container) archivedTree(r *Root, objects map[cipher.SHA256][]byte,
+	objs data.ViewObjects) (reg *Registry, save map[cipher.SHA256][]byte,
+	full bool, err error) {
+
+	save = make(map[cipher.SHA256][]byte)
+	full = true
+
+	var seen = make(map[cipher.SHA256]struct{})
+
+	fn := func(hash cipher.SHA256) (deeper bool, _ error) {
+		if _, ok := seen[hash]; ok {
+			return
+		}
+		seen[hash] = struct{}{}
+		if objs.IsExist(hash) {
+			return true, nil
+		}
+		var val []byte
+		if val, deeper = objects[hash]; deeper {
+			save[hash] = val
+		} else {
+			full = false
+		}
+		return
+	}
+
+	// registry and certificate
+	if deeper, _ := fn(cipher.SHA256(r.Reg)); !deeper {
+		return // missing registry
+	}
+	if r.Cert != (cipher.SHA256{}) {
+		fn(r.Cert)
+	}
+
+	g := &archiveGetter{objects, objs}
+	if reg, err = c.registryOf(r.Reg, g); err != nil {
+		return
+	}
+
+	var kn knowsAbout
+	kn.fn = fn
+	kn.g = g
+	kn.reg = reg
+	kn.vfn = func(sch Schema, hash cipher.SHA256, val []byte) (_ error) {
+		if _, ok := save[hash]; !ok {
+			return // already validated
+		}
+		return c.validate(sch, hash, val)
+	}
+	for _, dr := range r.Refs {
+		if err = kn.Dynamic(dr); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// checkArchivedQuota checks quota of given feed before
+// importing a Root with given new objects
+func (c *Container) checkArchivedQuota(pk cipher.PubKey, exists bool,
+	save map[cipher.SHA256][]byte) (err error) {
+
+	var q Quota
+	if q, err = c.Quota(pk); err != nil || q.IsZero() {
+		return
+	}
+	roots, used := c.feedUsage(pk)
+	if q.MaxRoots > 0 && !exists && roots >= q.MaxRoots {
+		return ErrRootsQuota
+	}
+	for _, val := range save {
+		used += data.Space(len(val))
+	}
+	if q.MaxSpace > 0 && used > q.MaxSpace {
+		return ErrSpaceQuota
+	}
+	return
+}
+
+// verify Root signed by a writer using
+// certificate of the writer from archive
+func (c *Container) verifyArchived(r *Root,
+	objects map[cipher.SHA256][]byte) (err error) {
+
+	val, ok := objects[r.Cert]
+	if !ok {
+		return ErrMissingCertificate
+	}
+	var cert *Certificate
+	if cert, err = DecodeCertificate(val); err != nil {
+		return
+	}
+	return c.verifyDelegated(r, r.Cert, cert)
+}
@@ -0,0 +1,179 @@
+package skyobject
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestContainer_ExportFeed(t *testing.T) {
+
+	c1 := getCont()
+	defer c1.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := c1.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c1.NewRoot(pk, sk, 0, c1.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rs []*Root
+	for i, name := range []string{"Alice", "Eva", "Ammy"} {
+		usr := &User{Name: name, Age: uint32(51 * i)} // 102 is invalid
+		pack.Append(&Group{Name: name + "'s group", Leader: pack.Ref(usr)})
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+		r := *pack.Root()
+		rs = append(rs, &r)
+	}
+
+	var archive bytes.Buffer
+	if err = c1.ExportFeed(&archive, pk, AllSeqs); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("import", func(t *testing.T) {
+		// without registry
+		c2 := NewContainer(data.NewMemoryDB(), nil)
+		defer c2.Close()
+
+		as, err := c2.ImportArchive(bytes.NewReader(archive.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if as.Roots != len(rs) || len(as.Feeds) != 1 || as.Feeds[0] != pk {
+			t.Errorf("wrong result: %+v", as)
+		}
+		for _, r := range rs {
+			if _, full, err := c2.RootBySeq(pk, r.Seq); err != nil {
+				t.Error(err)
+			} else if !full {
+				t.Error("imported Root is not full")
+			}
+		}
+		if c2.Registry(rs[0].Reg) == nil {
+			t.Error("registry is not loaded")
+		}
+		if rep, err := c2.Check(false); err != nil {
+			t.Error(err)
+		} else if !rep.IsClean() || rep.Roots != len(rs) {
+			t.Errorf("wrong check report: %+v", rep)
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		var part bytes.Buffer
+		if err := c1.ExportFeed(&part, pk, SeqRange{1, 1}); err != nil {
+			t.Fatal(err)
+		}
+		if part.Len() >= archive.Len() {
+			t.Error("range is ignored")
+		}
+		c2 := getCont()
+		defer c2.Close()
+		as, err := c2.ImportArchive(&part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if as.Roots != 1 {
+			t.Error("wrong number of imported roots:", as.Roots)
+		}
+		if r, err := c2.Last(pk); err != nil {
+			t.Error(err)
+		} else if r.Seq != 1 {
+			t.Error("wrong seq of imported root:", r.Seq)
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		conf := NewConfig()
+		conf.Validators = Validators{"cxo.User": validateUser}
+		c2 := NewContainer(data.NewMemoryDB(), conf)
+		defer c2.Close()
+
+		as, err := c2.ImportArchive(bytes.NewReader(archive.Bytes()))
+		if _, ok := err.(*ValidationError); !ok {
+			t.Fatal("unexpected error:", err)
+		}
+		if as.Roots != 2 {
+			t.Error("wrong number of imported roots:", as.Roots)
+		}
+		if _, _, err = c2.RootBySeq(pk, rs[2].Seq); err == nil {
+			t.Error("invalid Root imported")
+		}
+		invalid := encoder.Serialize(User{Name: "Ammy", Age: 102})
+		if c2.Get(cipher.SumSHA256(invalid)) != nil {
+			t.Error("invalid object saved")
+		}
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		c2 := NewContainer(data.NewMemoryDB(), nil)
+		defer c2.Close()
+
+		err := c2.SetQuota(pk, Quota{MaxSpace: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c2.ImportArchive(bytes.NewReader(archive.Bytes()))
+		if err != ErrSpaceQuota {
+			t.Fatal("unexpected error:", err)
+		}
+		if stat := c2.DB().Stat(); stat.Objects != 0 || len(stat.Feeds) != 0 {
+			t.Errorf("objects saved: %+v", stat)
+		}
+		if c2.Registry(rs[0].Reg) != nil {
+			t.Error("registry of rejected Root is loaded")
+		}
+
+		err = c2.SetQuota(pk, Quota{MaxRoots: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		as, err := c2.ImportArchive(bytes.NewReader(archive.Bytes()))
+		if err != ErrRootsQuota {
+			t.Fatal("unexpected error:", err)
+		} else if as.Roots != 1 {
+			t.Error("wrong number of imported roots:", as.Roots)
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		conf := NewConfig()
+		conf.Registry = getRegisty()
+		conf.MaxRootObjects = 1
+		c2 := NewContainer(data.NewMemoryDB(), conf)
+		defer c2.Close()
+
+		_, err := c2.ImportArchive(bytes.NewReader(archive.Bytes()))
+		if err != ErrTooManyObjects {
+			t.Error("unexpected error:", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		c2 := getCont()
+		defer c2.Close()
+
+		_, err := c2.ImportArchive(bytes.NewReader([]byte("junk")))
+		if err != ErrNotArchive {
+			t.Error("unexpected error:", err)
+		}
+		cut := archive.Bytes()[:archive.Len()-1]
+		_, err = c2.ImportArchive(bytes.NewReader(cut))
+		if err != ErrMalformedArchive {
+			t.Error("unexpected error:", err)
+		}
+	})
+
+}
@@ -0,0 +1,268 @@
+package skyobject
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// SummaryVersion is version of encoded Summary
+const SummaryVersion byte = 1
+
+// SummaryFalsePositive is false positive rate of
+// BloomFilter of Summary. A false positive means
+// that a bundle misses an object and a Root of the
+// bundle will be imported as non-full
+const SummaryFalsePositive float64 = 1e-6
+
+// MaxBloomHashes is max number of hash functions of BloomFilter.
+// Optimal number of them for SummaryFalsePositive is 20, but a
+// decoded Summary can require any number of them per hash
+const MaxBloomHashes uint32 = 64
+
+// ErrMalformedSummary occurs when encoded Summary is malformed
+var ErrMalformedSummary = errors.New("malformed summary")
+
+// A BloomFilter represents set of hashes of objects
+type BloomFilter struct {
+	Bits   []byte
+	Hashes uint32 // number of hash functions
+}
+
+// NewBloomFilter creates BloomFilter for n
+// elements with given false positive rate
+func NewBloomFilter(n int, fp float64) (b BloomFilter) {
+	if n < 1 {
+		n = 1
+	}
+	m := math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2))
+	if m < 64 {
+		m = 64
+	}
+	b.Bits = make([]byte, (int(m)+7)/8)
+	b.Hashes = uint32(math.Ceil(m / float64(n) * math.Ln2))
+	if b.Hashes > MaxBloomHashes {
+		b.Hashes = MaxBloomHashes
+	}
+	return
+}
+
+// a hash is already random, thus two
+// halves of it used for double hashing
+func (b *BloomFilter) index(hash cipher.SHA256, i uint32) (byt int,
+	bit byte) {
+
+	h1 := binary.BigEndian.Uint64(hash[:8])
+	h2 := binary.BigEndian.Uint64(hash[8:16]) | 1
+	n := (h1 + uint64(i)*h2) % uint64(len(b.Bits)*8)
+	return int(n / 8), 1 << (n % 8)
+}
+
+// Add given hash to the filter
+func (b *BloomFilter) Add(hash cipher.SHA256) {
+	for i := uint32(0); i < b.Hashes; i++ {
+		byt, bit := b.index(hash, i)
+		b.Bits[byt] |= bit
+	}
+}
+
+// Has returns true if the filter probably has
+// given hash, and false if it definitely hasn't
+func (b *BloomFilter) Has(hash cipher.SHA256) bool {
+	if len(b.Bits) == 0 {
+		return false
+	}
+	for i := uint32(0); i < b.Hashes; i++ {
+		byt, bit := b.index(hash, i)
+		if b.Bits[byt]&bit == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// A FeedSummary represents full Root objects of a feed
+type FeedSummary struct {
+	Feed cipher.PubKey
+	Full []uint64 // seq numbers of full Root objects
+}
+
+// A Summary represents what a Container holds for
+// some feeds: full Root objects and objects of them.
+// Another Container uses the Summary to write a bundle
+// with missing Root objects and objects only
+type Summary struct {
+	Version byte
+	Feeds   []FeedSummary
+	Objects BloomFilter // objects of the full Root objects
+}
+
+// Encode the Summary
+func (s *Summary) Encode() []byte {
+	return encoder.Serialize(s)
+}
+
+// DecodeSummary decodes encoded Summary
+func DecodeSummary(b []byte) (s *Summary, err error) {
+	s = new(Summary)
+	if err = encoder.DeserializeRaw(b, s); err != nil {
+		return nil, ErrMalformedSummary
+	}
+	if s.Version > SummaryVersion {
+		return nil, fmt.Errorf("unsupported version of summary: %d",
+			s.Version)
+	}
+	if s.Objects.Hashes == 0 && len(s.Objects.Bits) != 0 ||
+		s.Objects.Hashes > MaxBloomHashes {
+
+		return nil, ErrMalformedSummary
+	}
+	return
+}
+
+// Summary of given feeds. If the feeds is empty,
+// then the Summary contains all feeds of the Container
+func (c *Container) Summary(feeds ...cipher.PubKey) (s *Summary, err error) {
+	c.Debugln(VerbosePin, "Summary", len(feeds))
+
+	s = &Summary{Version: SummaryVersion}
+	objects := make(map[cipher.SHA256]struct{})
+
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		fs := tx.Feeds()
+		if len(feeds) == 0 {
+			feeds = fs.List()
+		}
+		objs := tx.Objects()
+		for _, pk := range feeds {
+			fsum := FeedSummary{Feed: pk}
+			if roots := fs.Roots(pk); roots != nil {
+				err = roots.Range(func(rp *data.RootPack) (err error) {
+					if !roots.Meta(rp.Seq).IsFull {
+						return
+					}
+					fsum.Full = append(fsum.Full, rp.Seq)
+					return c.summaryRoot(pk, rp, objs, objects)
+				})
+				if err != nil {
+					return
+				}
+			}
+			s.Feeds = append(s.Feeds, fsum)
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Objects = NewBloomFilter(len(objects), SummaryFalsePositive)
+	for hash := range objects {
+		s.Objects.Add(hash)
+	}
+	return
+}
+
+// collect objects of a full Root
+func (c *Container) summaryRoot(pk cipher.PubKey, rp *data.RootPack,
+	objs data.ViewObjects, objects map[cipher.SHA256]struct{}) (err error) {
+
+	var r *Root
+	if r, err = c.unpackRoot(pk, rp); err != nil {
+		return
+	}
+
+	fn := func(hash cipher.SHA256) (deeper bool, _ error) {
+		if _, ok := objects[hash]; ok {
+			return // already have
+		}
+		objects[hash] = struct{}{}
+		return true, nil
+	}
+
+	fn(cipher.SHA256(r.Reg))
+	if r.Cert != (cipher.SHA256{}) {
+		fn(r.Cert)
+	}
+
+	var reg *Registry
+	if reg, err = c.registryOf(r.Reg, objs); err != nil {
+		return
+	}
+	var kn knowsAbout
+	kn.fn = fn
+	kn.g = objs
+	kn.reg = reg
+	for _, dr := range r.Refs {
+		if err = kn.Dynamic(dr); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// WriteBundle writes bundle for given Summary of another Container.
+// The bundle contains full Root objects of feeds of the Summary that
+// the Summary doesn't have as full, and objects of the Root objects
+// that are not in the Summary. Use ImportBundle to import it. Feeds
+// of the Summary that the Container doesn't have are skipped. The
+// bundle has the same format as archive created by ExportFeed
+func (c *Container) WriteBundle(w io.Writer, s *Summary) (err error) {
+	c.Debugln(VerbosePin, "WriteBundle", len(s.Feeds))
+
+	var aw *archiveWriter
+	if aw, err = newArchiveWriter(w); err != nil {
+		return
+	}
+
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		fs := tx.Feeds()
+		objs := tx.Objects()
+		seen := make(map[cipher.SHA256]struct{})
+		for _, fsum := range s.Feeds {
+			roots := fs.Roots(fsum.Feed)
+			if roots == nil {
+				continue // don't have the feed
+			}
+			have := make(map[uint64]struct{}, len(fsum.Full))
+			for _, seq := range fsum.Full {
+				have[seq] = struct{}{}
+			}
+			err = roots.Range(func(rp *data.RootPack) error {
+				if _, ok := have[rp.Seq]; ok {
+					return nil
+				}
+				if !roots.Meta(rp.Seq).IsFull {
+					return nil
+				}
+				return c.exportRoot(aw, fsum.Feed, rp, objs, seen,
+					&s.Objects)
+			})
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+	return aw.close()
+}
+
+// ImportBundle imports bundle created by WriteBundle. It's the same as
+// ImportArchive, but if a Root of the bundle is not full (because of
+// false positive of BloomFilter of Summary), then the Root imported
+// without fullness mark and should be filled by a node
+func (c *Container) ImportBundle(r io.Reader) (as ArchiveStat, err error) {
+	c.Debugln(VerbosePin, "ImportBundle")
+
+	return c.importArchive(r, true)
+}
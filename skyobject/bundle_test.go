@@ -0,0 +1,121 @@
+package skyobject
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+func TestBloomFilter_Has(t *testing.T) {
+
+	b := NewBloomFilter(1000, SummaryFalsePositive)
+
+	for i := 0; i < 1000; i++ {
+		b.Add(cipher.SumSHA256([]byte(fmt.Sprint(i))))
+	}
+	for i := 0; i < 1000; i++ {
+		if !b.Has(cipher.SumSHA256([]byte(fmt.Sprint(i)))) {
+			t.Fatal("missing element", i)
+		}
+	}
+	var fp int
+	for i := 1000; i < 11000; i++ {
+		if b.Has(cipher.SumSHA256([]byte(fmt.Sprint(i)))) {
+			fp++
+		}
+	}
+	if fp > 1 {
+		t.Error("too many false positives:", fp)
+	}
+
+	var empty BloomFilter
+	if empty.Has(cipher.SHA256{}) {
+		t.Error("empty filter has an element")
+	}
+}
+
+func TestContainer_WriteBundle(t *testing.T) {
+
+	cb := getCont() // has all Root objects
+	defer cb.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := cb.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := cb.NewRoot(pk, sk, 0, cb.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Alice", "Eva", "Ammy"} {
+		pack.Append(&User{Name: name})
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ca := getCont() // has first Root only
+	defer ca.Close()
+
+	var archive bytes.Buffer
+	if err = cb.ExportFeed(&archive, pk, SeqRange{0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ca.ImportArchive(&archive); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := ca.Summary(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum, err = DecodeSummary(sum.Encode()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sum.Feeds) != 1 || len(sum.Feeds[0].Full) != 1 {
+		t.Fatalf("wrong summary: %+v", sum.Feeds)
+	}
+
+	evil := *sum
+	evil.Objects.Hashes = 1 << 30 // too many hash functions
+	if _, err = DecodeSummary(evil.Encode()); err != ErrMalformedSummary {
+		t.Error("unexpected error:", err)
+	}
+
+	var bundle, full bytes.Buffer
+	if err = cb.WriteBundle(&bundle, sum); err != nil {
+		t.Fatal(err)
+	}
+	if err = cb.ExportFeed(&full, pk, SeqRange{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Len() >= full.Len() {
+		t.Error("bundle is not delta:", bundle.Len(), full.Len())
+	}
+
+	as, err := ca.ImportBundle(&bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if as.Roots != 2 || len(as.NonFull) != 0 {
+		t.Errorf("wrong result: %+v", as)
+	}
+	for _, seq := range []uint64{1, 2} {
+		if _, full, err := ca.RootBySeq(pk, seq); err != nil {
+			t.Error(err)
+		} else if !full {
+			t.Error("imported Root is not full", seq)
+		}
+	}
+	if rep, err := ca.Check(false); err != nil {
+		t.Error(err)
+	} else if !rep.IsClean() {
+		t.Errorf("wrong check report: %+v", rep)
+	}
+
+}
@@ -0,0 +1,370 @@
+package skyobject
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// delegation related errors
+var (
+	ErrInvalidRootHash      = errors.New("invalid hash of Root")
+	ErrMissingCertificate   = errors.New("missing certificate")
+	ErrCertificateFeed      = errors.New("certificate of another feed")
+	ErrCertificateWriter    = errors.New("certificate of another writer")
+	ErrCertificateExpired   = errors.New("certificate is not valid for time")
+	ErrCertificateRevoked   = errors.New("certificate revoked")
+	ErrUnexpectedCertficate = errors.New("unexpected certificate")
+	ErrSuccessionByWriter   = errors.New("succession Root signed by writer")
+	ErrBackdatedRoot        = errors.New("Root is older than previous one")
+)
+
+// A Certificate represents delegation certificate. Owner of a
+// feed signs the Certificate to authorize another key (the Writer)
+// to publish Root objects of the feed. The Certificate can be
+// time-limited and revoked by owner (see Root.Revoked). The
+// Certificate is stored in DB as usual object and Root objects
+// refer to it by hash
+type Certificate struct {
+	Feed   cipher.PubKey // feed
+	Writer cipher.PubKey // authorized key
+
+	NotBefore int64 // unix nano, 0 - no limit
+	NotAfter  int64 // unix nano, 0 - no limit
+
+	Sig cipher.Sig // signature of the feed owner
+}
+
+// NewCertificate creates Certificate that authorizes given writer
+// to publish Root objects of given feed. Use zero time to create
+// Certificate without a limit. Use Sign to sign the Certificate
+func NewCertificate(feed, writer cipher.PubKey,
+	notBefore, notAfter time.Time) (c *Certificate) {
+
+	c = new(Certificate)
+	c.Feed = feed
+	c.Writer = writer
+	if !notBefore.IsZero() {
+		c.NotBefore = notBefore.UnixNano()
+	}
+	if !notAfter.IsZero() {
+		c.NotAfter = notAfter.UnixNano()
+	}
+	return
+}
+
+// DecodeCertificate decodes encoded Certificate
+func DecodeCertificate(val []byte) (c *Certificate, err error) {
+	c = new(Certificate)
+	if err = encoder.DeserializeRaw(val, c); err != nil {
+		c = nil
+	}
+	return
+}
+
+// Encode the Certificate
+func (c *Certificate) Encode() []byte {
+	return encoder.Serialize(c)
+}
+
+// Hash of the Certificate. Root objects
+// refer to Certificate using the hash
+func (c *Certificate) Hash() cipher.SHA256 {
+	return cipher.SumSHA256(c.Encode())
+}
+
+// signed part of the Certificate
+func (c *Certificate) body() cipher.SHA256 {
+	x := *c
+	x.Sig = cipher.Sig{}
+	return cipher.SumSHA256(x.Encode())
+}
+
+// Sign the Certificate using secret key of owner of the feed
+func (c *Certificate) Sign(sk cipher.SecKey) {
+	c.Sig = cipher.SignHash(c.body(), sk)
+}
+
+// Verify signature of the Certificate
+func (c *Certificate) Verify() error {
+	return cipher.VerifySignature(c.Feed, c.Sig, c.body())
+}
+
+// IsValidAt reports whether the Certificate is
+// valid at given time (unix nano)
+func (c *Certificate) IsValidAt(tm int64) bool {
+	if c.NotBefore != 0 && tm < c.NotBefore {
+		return false
+	}
+	if c.NotAfter != 0 && tm > c.NotAfter {
+		return false
+	}
+	return true
+}
+
+// Short returns string like "{1a2ef33->ffe0a12}" ({feed->writer})
+func (c *Certificate) Short() string {
+	return fmt.Sprintf("{%s->%s}", c.Feed.Hex()[:7], c.Writer.Hex()[:7])
+}
+
+// AddCertificate saves given Certificate in DB. The
+// Certificate must be signed by owner of its feed
+func (c *Container) AddCertificate(cert *Certificate) (hash cipher.SHA256,
+	err error) {
+
+	if err = cert.Verify(); err != nil {
+		return
+	}
+	val := cert.Encode()
+	hash = cipher.SumSHA256(val)
+	err = c.Set(hash, val)
+	return
+}
+
+// Certificate by hash. It returns nil if
+// the Certificate not found in DB
+func (c *Container) Certificate(hash cipher.SHA256) (cert *Certificate,
+	err error) {
+
+	var val []byte
+	if val = c.Get(hash); val == nil {
+		return
+	}
+	return DecodeCertificate(val)
+}
+
+// A revoked represents sets of revoked certificates of
+// feeds. The sets are collected from Root objects signed
+// by owners of the feeds and kept in DB (see revokedBucket).
+// A revocation affects only certificates of the same feed
+type revoked struct {
+	mx      sync.RWMutex
+	revoked map[cipher.PubKey]map[cipher.SHA256]struct{} // feed -> certs
+}
+
+func (r *revoked) init() {
+	r.revoked = make(map[cipher.PubKey]map[cipher.SHA256]struct{})
+}
+
+func (r *revoked) add(pk cipher.PubKey, hs []cipher.SHA256) {
+	if len(hs) == 0 {
+		return
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	certs, ok := r.revoked[pk]
+	if !ok {
+		certs = make(map[cipher.SHA256]struct{})
+		r.revoked[pk] = certs
+	}
+	for _, hash := range hs {
+		certs[hash] = struct{}{}
+	}
+}
+
+func (r *revoked) isRevoked(pk cipher.PubKey, hash cipher.SHA256) (yep bool) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	_, yep = r.revoked[pk][hash]
+	return
+}
+
+// IsRevoked reports whether a Certificate of given feed revoked
+// by owner of the feed. A revocation is known if the Container
+// has Root of the feed with the revocation
+func (c *Container) IsRevoked(pk cipher.PubKey, cert cipher.SHA256) bool {
+	return c.revoked.isRevoked(pk, cert)
+}
+
+// name of end-user bucket of DB that keeps revoked certificates,
+// the bucket is never pruned by CleanUp, since Root objects
+// with revocations can be removed
+var revokedBucket = []byte("skyobject.revoked")
+
+// keys of the revoked bucket
+//
+//  - {'r'} + feed + certificate -> {1}
+//  - {'b'}                      -> the bucket is built
+//
+const revokedPrefix byte = 'r'
+
+var revokedBuiltKey = []byte{'b'}
+
+func revokedKey(pk cipher.PubKey, hash cipher.SHA256) []byte {
+	key := make([]byte, 0, 1+len(pk)+len(hash))
+	key = append(key, revokedPrefix)
+	key = append(key, pk[:]...)
+	return append(key, hash[:]...)
+}
+
+// saveRevoked saves revocations of given Root in
+// DB; the revoked set should be updated after
+// successful commit of the transaction
+func saveRevoked(tx data.Tu, r *Root) (err error) {
+	if !r.IsOwn() || len(r.Revoked) == 0 {
+		return
+	}
+	bk := tx.Bucket(revokedBucket)
+	for _, hash := range r.Revoked {
+		if err = bk.Set(revokedKey(r.Pub, hash), []byte{1}); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// loadRevoked loads revoked certificates from DB. If
+// the bucket is not built yet (new or old DB file), then
+// it's built from all Root objects of DB
+func (c *Container) loadRevoked() error {
+	return c.DB().Update(func(tx data.Tu) (err error) {
+		bk := tx.Bucket(revokedBucket)
+
+		if bk.Get(revokedBuiltKey) == nil {
+			feeds := tx.Feeds()
+			err = feeds.Range(func(pk cipher.PubKey) error {
+				return feeds.Roots(pk).Range(func(rp *data.RootPack) error {
+					r, err := c.unpackRoot(pk, rp)
+					if err != nil {
+						return nil // ignore malformed Root
+					}
+					return saveRevoked(tx, r)
+				})
+			})
+			if err != nil {
+				return
+			}
+			if err = bk.Set(revokedBuiltKey, []byte{1}); err != nil {
+				return
+			}
+		}
+
+		return bk.Prefix([]byte{revokedPrefix}, func(key, _ []byte) (_ error) {
+			var pk cipher.PubKey
+			var hash cipher.SHA256
+			if len(key) != 1+len(pk)+len(hash) {
+				return // malformed
+			}
+			copy(pk[:], key[1:])
+			copy(hash[:], key[1+len(pk):])
+			c.revoked.add(pk, []cipher.SHA256{hash})
+			return
+		})
+	})
+}
+
+// timeOfPrev returns Time of previous Root of given
+// one. The ok is false if the previous Root is not
+// stored in DB or can't be decoded
+func (c *Container) timeOfPrev(r *Root) (tm int64, ok bool) {
+	if r.Seq == 0 {
+		return
+	}
+	c.DB().View(func(tx data.Tv) (_ error) {
+		roots := tx.Feeds().Roots(r.Pub)
+		if roots == nil {
+			return
+		}
+		rp := roots.Get(r.Seq - 1)
+		if rp == nil || rp.Hash != r.Prev {
+			return
+		}
+		if prev, err := c.unpackRoot(r.Pub, rp); err == nil {
+			tm, ok = prev.Time, true
+		}
+		return
+	})
+	return
+}
+
+// verifyRoot checks hash and signature of given Root. The
+// signature is checked using public key of feed if the Root
+// signed by owner, or using certificate of the Root. If the
+// certificate not found in DB, the method returns
+// ErrMissingCertificate
+func (c *Container) verifyRoot(r *Root, rp *data.RootPack) (err error) {
+	if cipher.SumSHA256(rp.Root) != rp.Hash {
+		return ErrInvalidRootHash
+	}
+	if r.IsOwn() {
+		if r.Cert != (cipher.SHA256{}) {
+			return ErrUnexpectedCertficate
+		}
+		return cipher.VerifySignature(r.Pub, rp.Sig, rp.Hash)
+	}
+	var cert *Certificate
+	if cert, err = c.Certificate(r.Cert); err != nil {
+		return
+	} else if cert == nil {
+		return ErrMissingCertificate
+	}
+	return c.verifyDelegated(r, r.Cert, cert)
+}
+
+// verifyDelegated checks signature of Root signed by a writer
+func (c *Container) verifyDelegated(r *Root, hash cipher.SHA256,
+	cert *Certificate) (err error) {
+
+	if cert.Feed != r.Pub {
+		return ErrCertificateFeed
+	}
+	if cert.Writer != r.Signer {
+		return ErrCertificateWriter
+	}
+	if r.Successor != (cipher.PubKey{}) {
+		return ErrSuccessionByWriter // only owner can do that
+	}
+	if err = cert.Verify(); err != nil {
+		return
+	}
+	if !cert.IsValidAt(r.Time) {
+		return ErrCertificateExpired
+	}
+	// the Time is set by the writer, thus it can't be
+	// older than Time of previous Root; the check is
+	// skipped if the previous Root is not stored
+	if tm, ok := c.timeOfPrev(r); ok && r.Time < tm {
+		return ErrBackdatedRoot
+	}
+	if c.IsRevoked(r.Pub, hash) {
+		return ErrCertificateRevoked
+	}
+	return cipher.VerifySignature(r.Signer, r.Sig, r.Hash)
+}
+
+// UseCertificate makes the Pack to sign Root objects using
+// given Certificate. The Pack must be created with secret
+// key of writer of the Certificate
+func (p *Pack) UseCertificate(cert *Certificate) (err error) {
+	if cert.Feed != p.r.Pub {
+		return ErrCertificateFeed
+	}
+	if cert.Writer != cipher.PubKeyFromSecKey(p.sk) {
+		return ErrCertificateWriter
+	}
+	if err = cert.Verify(); err != nil {
+		return
+	}
+	p.r.Signer = cert.Writer
+	p.r.Cert = p.add(cert.Encode())
+	return
+}
+
+// Revoke certificates. Revocations published with next Root.
+// The Pack must be created with secret key of owner of the feed
+func (p *Pack) Revoke(certs ...cipher.SHA256) (err error) {
+	if !p.r.IsOwn() {
+		return fmt.Errorf("only owner of feed %s can revoke certificates",
+			p.r.Pub.Hex()[:7])
+	}
+	p.r.Revoked = append(p.r.Revoked, certs...)
+	return
+}
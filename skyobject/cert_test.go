@@ -0,0 +1,299 @@
+package skyobject
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestCertificate_Verify(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	wp, ws := cipher.GenerateKeyPair()
+
+	cert := NewCertificate(pk, wp, time.Time{}, time.Time{})
+	cert.Sign(sk)
+	if err := cert.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	cert.Sign(ws) // signed by writer
+	if err := cert.Verify(); err == nil {
+		t.Error("missing error")
+	}
+}
+
+func TestCertificate_IsValidAt(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+	wp, _ := cipher.GenerateKeyPair()
+
+	now := time.Now()
+	cert := NewCertificate(pk, wp, now, now.Add(time.Minute))
+
+	if cert.IsValidAt(now.Add(-time.Second).UnixNano()) {
+		t.Error("valid before")
+	}
+	if !cert.IsValidAt(now.Add(time.Second).UnixNano()) {
+		t.Error("invalid")
+	}
+	if cert.IsValidAt(now.Add(2 * time.Minute).UnixNano()) {
+		t.Error("valid after")
+	}
+}
+
+func TestContainer_delegation(t *testing.T) {
+
+	pk, sk := cipher.GenerateKeyPair() // owner
+	wp, ws := cipher.GenerateKeyPair() // writer
+
+	// publisher and receiver
+	c1, c2 := getCont(), getCont()
+	defer c1.Close()
+	defer c2.Close()
+
+	for _, c := range []*Container{c1, c2} {
+		if err := c.AddFeed(pk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cert := NewCertificate(pk, wp, time.Time{}, time.Time{})
+	cert.Sign(sk)
+
+	// publish a Root using the certificate
+	var publish = func(t *testing.T, c *Container, cert *Certificate,
+		sk cipher.SecKey) *Root {
+
+		pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert != nil {
+			if err = pack.UseCertificate(cert); err != nil {
+				t.Fatal(err)
+			}
+		}
+		pack.Append(&User{Name: "Alice"})
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return pack.Root()
+	}
+
+	t.Run("wrong writer", func(t *testing.T) {
+		pack, err := c1.NewRoot(pk, sk, 0, c1.CoreRegistry().Types())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pack.UseCertificate(cert); err != ErrCertificateWriter {
+			t.Error("wrong error:", err)
+		}
+	})
+
+	r := publish(t, c1, cert, ws)
+
+	if r.IsOwn() {
+		t.Fatal("signed by owner")
+	}
+
+	t.Run("missing certificate", func(t *testing.T) {
+		if _, err := c2.AddRoot(pk, r.Pack()); err != nil {
+			t.Error(err) // must be checked by Filler
+		}
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		if _, err := c2.AddCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+		if err := c2.verifyRoot(r, r.Pack()); err != nil {
+			t.Error(err)
+		}
+		fake := *r
+		fake.Signer, _ = cipher.GenerateKeyPair()
+		if err := c2.verifyRoot(&fake, fake.Pack()); err == nil {
+			t.Error("missing error")
+		}
+	})
+
+	t.Run("backdated", func(t *testing.T) {
+		next := *r
+		next.Seq, next.Prev = r.Seq+1, r.Hash
+		next.Time = r.Time - 1
+		next.Hash = cipher.SumSHA256(next.Encode())
+		next.Sig = cipher.SignHash(next.Hash, ws)
+		if err := c2.verifyRoot(&next, next.Pack()); err != ErrBackdatedRoot {
+			t.Error("wrong error:", err)
+		}
+		next.Time = r.Time + 1
+		next.Hash = cipher.SumSHA256(next.Encode())
+		next.Sig = cipher.SignHash(next.Hash, ws)
+		if err := c2.verifyRoot(&next, next.Pack()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("forged", func(t *testing.T) {
+		c := getCont()
+		defer c.Close()
+		if err := c.AddFeed(pk); err != nil {
+			t.Fatal(err)
+		}
+		fake := *r
+		fake.Signer, _ = cipher.GenerateKeyPair()
+		rp := fake.Pack()
+		rp.Hash = cipher.SumSHA256(rp.Root)
+		fr, err := c.AddRoot(pk, rp)
+		if err != nil {
+			t.Fatal(err) // must be checked by Filler
+		}
+
+		wantq := make(chan WCXO)
+		fullq := make(chan *Root, 1)
+		dropq := make(chan DropRootError, 1)
+
+		var wg sync.WaitGroup
+		fl := c.NewFiller(fr, wantq, fullq, dropq, nil, &wg)
+		defer wg.Wait()
+		defer fl.Close()
+
+		wc := <-wantq
+		if wc.Hash != cert.Hash() {
+			t.Fatal("unexpected request:", wc.Hash.Hex()[:7])
+		}
+		wc.GotQ <- cert.Encode()
+		if drop := <-dropq; drop.Err != ErrCertificateWriter {
+			t.Error("wrong error:", drop.Err)
+		}
+		if _, err = c.Root(pk, fr.Seq); err == nil {
+			t.Error("forged Root is not removed")
+		}
+	})
+
+	t.Run("revoke by another feed", func(t *testing.T) {
+		op, os := cipher.GenerateKeyPair()
+		for _, c := range []*Container{c1, c2} {
+			if err := c.AddFeed(op); err != nil {
+				t.Fatal(err)
+			}
+		}
+		pack, err := c1.NewRoot(op, os, 0, c1.CoreRegistry().Types())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = pack.Revoke(cert.Hash()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = c2.AddRoot(op, pack.Root().Pack()); err != nil {
+			t.Fatal(err)
+		}
+		if err = c2.verifyRoot(r, r.Pack()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		pack, err := c1.NewRoot(pk, sk, 0, c1.CoreRegistry().Types())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = pack.Revoke(cert.Hash()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = c2.AddRoot(pk, pack.Root().Pack()); err != nil {
+			t.Fatal(err)
+		}
+		if err = c2.verifyRoot(r, r.Pack()); err != ErrCertificateRevoked {
+			t.Error("wrong error:", err)
+		}
+	})
+
+}
+
+func TestContainer_loadRevoked(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "cxo-revoked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.db")
+
+	db, err := data.NewDriveDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+
+	c := NewContainer(db, conf)
+
+	pk, sk := cipher.GenerateKeyPair() // owner
+	wp, ws := cipher.GenerateKeyPair() // writer
+
+	if err = c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	cert := NewCertificate(pk, wp, time.Time{}, time.Time{})
+	cert.Sign(sk)
+
+	var save = func(sk cipher.SecKey, fn func(*Pack) error) *Root {
+		pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = fn(pack); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return pack.Root()
+	}
+
+	r := save(ws, func(p *Pack) error { return p.UseCertificate(cert) })
+	rr := save(sk, func(p *Pack) error { return p.Revoke(cert.Hash()) })
+	save(sk, func(*Pack) error { return nil })
+
+	// the Root with revocation is removed
+	if err = c.CleanUp(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Root(pk, rr.Seq); err == nil {
+		t.Fatal("Root with revocation is not removed")
+	}
+
+	// reopen
+	c.Close()
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if db, err = data.NewDriveDB(path); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	c = NewContainer(db, conf)
+	defer c.Close()
+
+	if err = c.verifyDelegated(r, cert.Hash(), cert); err != ErrCertificateRevoked {
+		t.Error("wrong error:", err)
+	}
+
+}
@@ -0,0 +1,276 @@
+package skyobject
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// check related errors
+var (
+	ErrBrokenChain = errors.New("Prev of Root doesn't match previous Root")
+	ErrNotFull     = errors.New("Root marked as full, but it is not full")
+)
+
+// A RootIssue represents a broken Root object
+// found by Check. The Reason is string to be
+// sent through RPC
+type RootIssue struct {
+	Feed   cipher.PubKey
+	Seq    uint64
+	Hash   cipher.SHA256
+	Reason string
+}
+
+// String implements fmt.Stringer interface
+func (r RootIssue) String() string {
+	return fmt.Sprintf("{%s:%d} %s: %s", r.Feed.Hex()[:7], r.Seq,
+		r.Hash.Hex()[:7], r.Reason)
+}
+
+// A CheckReport represents result of Check
+type CheckReport struct {
+	Objects int // checked objects
+	Roots   int // checked Root objects
+
+	// CorruptObjects don't match their hashes
+	CorruptObjects []cipher.SHA256
+	// InvalidRoots have wrong hash, signature or Prev
+	InvalidRoots []RootIssue
+	// NonFullRoots marked as full, but some
+	// objects of them are missing or corrupt
+	NonFullRoots []RootIssue
+	// MissingObjects of Root objects marked as full
+	MissingObjects []cipher.SHA256
+	// OrphanedRegistries are not used by any Root
+	OrphanedRegistries []RegistryRef
+
+	Repaired bool // true if the Check repairs found problems
+}
+
+// IsClean returns true if the Check
+// found nothing (except orphaned registries
+// that will be removed by CleanUp)
+func (c *CheckReport) IsClean() bool {
+	return len(c.CorruptObjects) == 0 &&
+		len(c.InvalidRoots) == 0 &&
+		len(c.NonFullRoots) == 0 &&
+		len(c.MissingObjects) == 0
+}
+
+// Check verifies integrity of DB. It checks that objects
+// match their hashes, checks hashes, signatures and Prev
+// chains of Root objects and that every Root marked as full
+// has all its objects. If repair is true, then the Check
+// unmarks non-full Root objects and removes corrupt objects.
+// Invalid Root objects are reported, but never removed. The
+// unmarked Root objects can be filled again by a node (see
+// node.Node.Refetch). The Check walks through entire DB and
+// doesn't run simultaneously with CleanUp
+func (c *Container) Check(repair bool) (rep *CheckReport, err error) {
+	c.Debugln(VerbosePin, "Check, repair:", repair)
+
+	c.cleanmx.Lock()
+	defer c.cleanmx.Unlock()
+
+	rep = new(CheckReport)
+
+	corrupt := make(map[cipher.SHA256]struct{})
+	missing := make(map[cipher.SHA256]struct{})
+	used := make(map[RegistryRef]struct{})
+
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		objs := tx.Objects()
+		err = objs.RangeErr(func(key cipher.SHA256, val []byte,
+			rerr error) (_ error) {
+
+			rep.Objects++
+			// the rerr is not nil if the object can't be
+			// decompressed or decrypted
+			if rerr != nil || cipher.SumSHA256(val) != key {
+				corrupt[key] = struct{}{}
+				rep.CorruptObjects = append(rep.CorruptObjects, key)
+			}
+			return
+		})
+		if err != nil {
+			return
+		}
+		feeds := tx.Feeds()
+		return feeds.Range(func(pk cipher.PubKey) error {
+			return c.checkFeed(pk, feeds.Roots(pk), objs, corrupt, missing,
+				used, rep)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for hash := range missing {
+		rep.MissingObjects = append(rep.MissingObjects, hash)
+	}
+
+	c.rmx.RLock()
+	for rr := range c.regs {
+		if _, ok := used[rr]; ok {
+			continue
+		}
+		if cr := c.coreRegistry; cr != nil && cr.Reference() == rr {
+			continue
+		}
+		rep.OrphanedRegistries = append(rep.OrphanedRegistries, rr)
+	}
+	c.rmx.RUnlock()
+
+	if !repair || rep.IsClean() {
+		return
+	}
+
+	if err = c.repair(rep); err != nil {
+		return
+	}
+	rep.Repaired = true
+	return
+}
+
+// check Root objects of a feed
+func (c *Container) checkFeed(pk cipher.PubKey, roots data.ViewRoots,
+	objs data.ViewObjects, corrupt, missing map[cipher.SHA256]struct{},
+	used map[RegistryRef]struct{}, rep *CheckReport) error {
+
+	var prev *data.RootPack
+
+	return roots.Range(func(rp *data.RootPack) (_ error) {
+		rep.Roots++
+
+		issue := RootIssue{Feed: pk, Seq: rp.Seq, Hash: rp.Hash}
+
+		r, err := c.checkRoot(pk, rp, prev)
+		prev = rp
+		if err != nil {
+			issue.Reason = err.Error()
+			rep.InvalidRoots = append(rep.InvalidRoots, issue)
+			return
+		}
+		used[r.Reg] = struct{}{}
+
+		if !roots.Meta(rp.Seq).IsFull {
+			return
+		}
+		if err = c.checkTree(r, objs, corrupt, missing); err != nil {
+			issue.Reason = err.Error()
+			rep.NonFullRoots = append(rep.NonFullRoots, issue)
+		}
+		return
+	})
+}
+
+// check hash, signature and Prev of a Root
+func (c *Container) checkRoot(pk cipher.PubKey, rp,
+	prev *data.RootPack) (r *Root, err error) {
+
+	if r, err = c.unpackRoot(pk, rp); err != nil {
+		return
+	}
+	if r.Pub != pk {
+		return nil, fmt.Errorf("Root of another feed: %s", r.Pub.Hex()[:7])
+	}
+	if r.Seq != rp.Seq || r.Prev != rp.Prev {
+		return nil, errors.New("Root doesn't match its RootPack")
+	}
+	if err = c.verifyRoot(r, rp); err != nil {
+		return nil, err
+	}
+	if prev != nil && prev.Seq+1 == rp.Seq && prev.Hash != rp.Prev {
+		return nil, ErrBrokenChain
+	}
+	if rp.Seq == 0 && rp.Prev != (cipher.SHA256{}) {
+		return nil, ErrBrokenChain
+	}
+	return
+}
+
+// checkTree walks through all objects of given Root looking for missing
+// and corrupt objects. It returns ErrNotFull if any object is missing
+// or corrupt, or an error if the tree is malformed
+func (c *Container) checkTree(r *Root, objs data.ViewObjects,
+	corrupt, missing map[cipher.SHA256]struct{}) (err error) {
+
+	var full = true
+	var seen = make(map[cipher.SHA256]struct{})
+
+	fn := func(hash cipher.SHA256) (deeper bool, _ error) {
+		if _, ok := seen[hash]; ok {
+			return
+		}
+		seen[hash] = struct{}{}
+		if _, ok := corrupt[hash]; ok {
+			full = false
+			return
+		}
+		if !objs.IsExist(hash) {
+			missing[hash], full = struct{}{}, false
+			return
+		}
+		return true, nil
+	}
+
+	// registry and certificate
+	fn(cipher.SHA256(r.Reg))
+	if r.Cert != (cipher.SHA256{}) {
+		fn(r.Cert)
+	}
+
+	if full {
+		var reg *Registry
+		if reg, err = c.registryOf(r.Reg, objs); err != nil {
+			return
+		}
+		var kn knowsAbout
+		kn.fn = fn
+		kn.g = objs
+		kn.reg = reg
+		for _, dr := range r.Refs {
+			if err = kn.Dynamic(dr); err != nil {
+				return
+			}
+		}
+	}
+
+	if !full {
+		err = ErrNotFull
+	}
+	return
+}
+
+// repair removes corrupt objects and
+// unmarks non-full Root objects
+func (c *Container) repair(rep *CheckReport) error {
+	return c.DB().Update(func(tx data.Tu) (err error) {
+		objs := tx.Objects()
+		for _, key := range rep.CorruptObjects {
+			if err = objs.Del(key); err != nil {
+				return
+			}
+		}
+		feeds := tx.Feeds()
+		for _, ri := range rep.NonFullRoots {
+			roots := feeds.Roots(ri.Feed)
+			if roots == nil {
+				continue
+			}
+			rm := roots.Meta(ri.Seq)
+			if rm == nil {
+				continue // removed
+			}
+			rm.IsFull = false
+			if err = roots.SetMeta(ri.Seq, rm); err != nil {
+				return
+			}
+		}
+		return
+	})
+}
@@ -0,0 +1,193 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestContainer_Check(t *testing.T) {
+
+	c := getCont()
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack.Append(&User{Name: "Alice"})
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	r := pack.Root()
+	user := r.Refs[0].Object
+
+	t.Run("clean", func(t *testing.T) {
+		rep, err := c.Check(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rep.IsClean() {
+			t.Errorf("unexpected problems: %+v", rep)
+		}
+		if rep.Roots != 1 || rep.Objects == 0 {
+			t.Errorf("wrong report: %+v", rep)
+		}
+	})
+
+	if err = c.Set(user, []byte("corrupt")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("corrupt", func(t *testing.T) {
+		rep, err := c.Check(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rep.CorruptObjects) != 1 || rep.CorruptObjects[0] != user {
+			t.Error("corrupt object not found:", rep.CorruptObjects)
+		}
+		if len(rep.NonFullRoots) != 1 || rep.NonFullRoots[0].Seq != r.Seq {
+			t.Error("non-full root not found:", rep.NonFullRoots)
+		}
+		if rep.Repaired {
+			t.Error("repaired without repair")
+		}
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		rep, err := c.Check(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rep.Repaired {
+			t.Error("not repaired")
+		}
+		if c.Get(user) != nil {
+			t.Error("corrupt object not removed")
+		}
+		if rm, err := c.RootMeta(pk, r.Seq); err != nil {
+			t.Error(err)
+		} else if rm.IsFull {
+			t.Error("non-full root is not unmarked")
+		}
+		if rep, err = c.Check(false); err != nil {
+			t.Fatal(err)
+		} else if !rep.IsClean() {
+			t.Errorf("unexpected problems after repair: %+v", rep)
+		}
+	})
+
+	t.Run("invalid root", func(t *testing.T) {
+		next := *r
+		next.Seq, next.Prev = r.Seq+1, cipher.SHA256{1}
+		rp := next.Pack()
+		rp.Hash = cipher.SumSHA256(rp.Root)
+		rp.Sig = cipher.SignHash(rp.Hash, sk)
+		err := c.DB().Update(func(tx data.Tu) error {
+			return tx.Feeds().Roots(pk).Add(rp)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rep, err := c.Check(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rep.InvalidRoots) != 1 || rep.InvalidRoots[0].Seq != next.Seq {
+			t.Error("invalid root not found:", rep.InvalidRoots)
+		} else if rep.InvalidRoots[0].Reason != ErrBrokenChain.Error() {
+			t.Error("wrong reason:", rep.InvalidRoots[0].Reason)
+		}
+	})
+
+}
+
+func TestContainer_Check_unreadable(t *testing.T) {
+
+	raw := data.NewMemoryDB()
+	edb, err := data.NewEncryptedDB(raw, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cdb, err := data.NewCompressedDB(edb, data.CodecFlate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+
+	c := NewContainer(cdb, conf)
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err = c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack.Append(&User{Name: "Alice"}, &User{Name: "Eva"})
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	r := pack.Root()
+
+	// the first can't be decompressed, the second can't be decrypted
+	alice, eva := r.Refs[0].Object, r.Refs[1].Object
+	err = edb.Update(func(tx data.Tu) error {
+		return tx.Objects().Set(alice, []byte{0xff, 0x01, 0x00})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = raw.Update(func(tx data.Tu) error {
+		return tx.Objects().Set(eva, []byte("corrupt"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := c.Check(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.CorruptObjects) != 2 {
+		t.Error("corrupt objects not found:", rep.CorruptObjects)
+	}
+	if len(rep.NonFullRoots) != 1 || !rep.Repaired {
+		t.Errorf("not repaired: %+v", rep)
+	}
+
+	err = raw.View(func(tx data.Tv) (_ error) {
+		for _, key := range []cipher.SHA256{alice, eva} {
+			if tx.Objects().IsExist(key) {
+				t.Error("corrupt object not removed:", key.Hex()[:7])
+			}
+		}
+		return
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if rep, err = c.Check(false); err != nil {
+		t.Fatal(err)
+	} else if !rep.IsClean() {
+		t.Errorf("unexpected problems after repair: %+v", rep)
+	}
+
+}
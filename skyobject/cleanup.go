@@ -0,0 +1,515 @@
+package skyobject
+
+import (
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// phases of incremental CleanUp
+const (
+	CleanUpIdle  = "idle"  // there is no cycle in progress
+	CleanUpMark  = "mark"  // marking objects of Root objects
+	CleanUpSweep = "sweep" // removing unmarked objects
+)
+
+// objects per RangeFrom call of sweep phase
+const sweepChunk int = 256
+
+// A CleanUpProgress represents progress
+// of incremental CleanUp
+type CleanUpProgress struct {
+	Phase   string // CleanUpIdle, CleanUpMark or CleanUpSweep
+	Cycles  int    // finished cycles
+	Slices  int    // slices of current cycle
+	Marked  int    // objects marked by current cycle
+	Swept   int    // objects checked by current cycle
+	Removed int    // objects removed by current cycle
+}
+
+// a Root of a feed
+type rootSeq struct {
+	pk  cipher.PubKey
+	seq uint64
+}
+
+// cycle of incremental CleanUp
+type cleanUpCycle struct {
+	prog      CleanUpProgress
+	keepRoots bool
+	took      time.Duration // total time of slices
+
+	coll map[cipher.SHA256]int      // marked objects
+	keep map[cipher.SHA256]struct{} // saved during the cycle
+	sp   space                      // new index of space
+
+	// mark phase
+
+	feeds       []cipher.PubKey // feeds to mark
+	feed        int             // index of current feed
+	inFeed      bool            // the seq is valid
+	seq         uint64          // next Root of current feed
+	hasLastFull bool            // current feed has full Root
+	lastFull    uint64          // last full Root of current feed
+
+	collRoots map[cipher.PubKey]uint64 // remove Root objects before
+	lastSeq   map[cipher.PubKey]uint64 // last marked Root objects
+	nonFull   []rootSeq                // can be filled during the cycle
+
+	// sweep phase
+
+	removed bool          // Root objects removed
+	next    cipher.SHA256 // next object to sweep
+}
+
+// used returns true if given object is used
+func (c *cleanUpCycle) used(hash cipher.SHA256) (ok bool) {
+	if _, ok = c.coll[hash]; ok {
+		return
+	}
+	_, ok = c.keep[hash]
+	return
+}
+
+// state of incremental CleanUp; the cur and
+// cycles are guarded by cleanmx of Container
+type incremental struct {
+	cur    *cleanUpCycle // nil if there is no cycle
+	cycles int           // finished cycles
+
+	// write barrier
+	mx     sync.Mutex
+	shaded map[cipher.SHA256]struct{} // nil if there is no cycle
+}
+
+// shade keeps given object from removing by current cycle.
+// An object should be shaded before it is saved, because
+// it can be unreachable from Root objects for a while
+func (i *incremental) shade(hash cipher.SHA256) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	if i.shaded != nil {
+		i.shaded[hash] = struct{}{}
+	}
+}
+
+// take shaded objects
+func (i *incremental) takeShaded() (shaded map[cipher.SHA256]struct{}) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	shaded, i.shaded = i.shaded, make(map[cipher.SHA256]struct{})
+	return
+}
+
+// begin new cycle
+func (i *incremental) begin(feeds []cipher.PubKey,
+	keepRoots bool) (cy *cleanUpCycle) {
+
+	i.mx.Lock()
+	i.shaded = make(map[cipher.SHA256]struct{})
+	i.mx.Unlock()
+
+	cy = new(cleanUpCycle)
+	cy.prog = CleanUpProgress{Phase: CleanUpMark, Cycles: i.cycles}
+	cy.keepRoots = keepRoots
+	cy.coll = make(map[cipher.SHA256]int)
+	cy.keep = make(map[cipher.SHA256]struct{})
+	cy.sp.init()
+	cy.feeds = feeds
+	cy.collRoots = make(map[cipher.PubKey]uint64)
+	cy.lastSeq = make(map[cipher.PubKey]uint64)
+
+	i.cur = cy
+	return
+}
+
+// drop current cycle if any
+func (i *incremental) reset() {
+	i.mx.Lock()
+	i.shaded = nil
+	i.mx.Unlock()
+
+	i.cur = nil
+}
+
+// finish current cycle
+func (i *incremental) finish() {
+	i.reset()
+	i.cycles++
+}
+
+func (i *incremental) progress() CleanUpProgress {
+	if i.cur == nil {
+		return CleanUpProgress{Phase: CleanUpIdle, Cycles: i.cycles}
+	}
+	return i.cur.prog
+}
+
+// budget of a slice
+type budget struct {
+	n        int       // visited objects
+	max      int       // max objects (0 = no limit)
+	deadline time.Time // zero if not limited
+}
+
+func (b *budget) exceeded() bool {
+	if b.max > 0 && b.n >= b.max {
+		return true
+	}
+	return !b.deadline.IsZero() && time.Now().After(b.deadline)
+}
+
+// CleanUpStep performs one slice of incremental CleanUp. It begins new
+// cycle if there is no cycle in progress and returns done = true when
+// the cycle is finished. The keepRoots is used by new cycle only. A
+// cycle marks objects of Root objects slice by slice, and then removes
+// unmarked objects slice by slice. Objects saved during the cycle and
+// objects of Root objects added or filled during the cycle are never
+// removed by the cycle. See Config.CleanUpObjects for details. The
+// CleanUp drops current cycle, since it's not needed anymore
+func (c *Container) CleanUpStep(keepRoots bool) (done bool, err error) {
+
+	c.Debugln(VerbosePin, "CleanUpStep, keep roots:", keepRoots)
+
+	c.cleanmx.Lock()
+	defer c.cleanmx.Unlock()
+
+	tp := time.Now()
+
+	cy := c.inc.cur
+	if cy == nil {
+		var feeds []cipher.PubKey
+		err = c.DB().View(func(tx data.Tv) (_ error) {
+			feeds = tx.Feeds().List()
+			return
+		})
+		if err != nil {
+			return
+		}
+		cy = c.inc.begin(feeds, keepRoots)
+		if cr := c.CoreRegistry(); cr != nil {
+			cy.keep[cipher.SHA256(cr.Reference())] = struct{}{}
+		}
+	}
+
+	b := budget{max: c.conf.CleanUpObjects}
+	if c.conf.CleanUpSlice > 0 {
+		b.deadline = tp.Add(c.conf.CleanUpSlice)
+	}
+
+	if cy.prog.Phase == CleanUpMark {
+		err = c.cleanUpMarkSlice(cy, &b)
+	} else {
+		done, err = c.cleanUpSweepSlice(cy, &b)
+	}
+
+	elapsed := time.Now().Sub(tp)
+	cy.took += elapsed
+	cy.prog.Slices++
+
+	switch {
+	case err != nil:
+		c.Print("[ERR] incremental CleanUp failed: ", err)
+		c.inc.reset()
+	case done:
+		c.inc.finish()
+		c.stat.addCleanUp(cy.took)
+		c.Debugln(CleanUpPin, "incremental CleanUp", cy.took,
+			cy.prog.Slices, "slices")
+	}
+
+	c.stat.addCleanUpSlice(elapsed, c.inc.progress())
+	return
+}
+
+// cleanUpIncremental performs a cycle of incremental CleanUp
+// slice by slice until the cycle is finished or the Container
+// is closed
+func (c *Container) cleanUpIncremental() (err error) {
+	var done bool
+	for {
+		if done, err = c.CleanUpStep(c.conf.KeepRoots); err != nil || done {
+			return
+		}
+		select {
+		case <-c.closeq:
+			return
+		default:
+		}
+	}
+}
+
+// mark Root objects of feeds from newest to oldest
+func (c *Container) cleanUpMarkSlice(cy *cleanUpCycle, b *budget) error {
+	return c.DB().View(func(tx data.Tv) (err error) {
+		feeds := tx.Feeds()
+		objs := tx.Objects()
+
+		for cy.feed < len(cy.feeds) {
+			pk := cy.feeds[cy.feed]
+			roots := feeds.Roots(pk)
+
+			if roots == nil {
+				cy.feed, cy.inFeed = cy.feed+1, false // removed
+				continue
+			}
+
+			if !cy.inFeed {
+				last := roots.Last()
+				if last == nil {
+					cy.feed++ // empty feed
+					continue
+				}
+				cy.lastSeq[pk] = last.Seq
+				cy.seq, cy.inFeed, cy.hasLastFull = last.Seq, true, false
+			}
+
+			var more bool
+			cy.seq, more, err = roots.ReverseFrom(cy.seq, 1,
+				func(rp *data.RootPack) error {
+					return c.cleanUpMarkRoot(cy, pk, roots, objs, rp, b)
+				})
+			if err != nil {
+				return
+			}
+
+			if !more {
+				if cy.hasLastFull && !cy.keepRoots {
+					cy.collRoots[pk] = cy.lastFull
+				}
+				cy.feed, cy.inFeed = cy.feed+1, false
+			}
+
+			if b.exceeded() {
+				return
+			}
+		}
+
+		cy.prog.Phase = CleanUpSweep
+		return
+	})
+}
+
+// mark objects of a Root, if the Root is not going to be removed
+func (c *Container) cleanUpMarkRoot(cy *cleanUpCycle, pk cipher.PubKey,
+	roots data.ViewRoots, objs data.ViewObjects, rp *data.RootPack,
+	b *budget) (err error) {
+
+	b.n++
+
+	rm := roots.Meta(rp.Seq)
+
+	if cy.hasLastFull && !rm.IsPinned() {
+		return // will be removed
+	}
+
+	if err = c.cleanUpWalk(cy, pk, objs, rp, b); err != nil {
+		return
+	}
+
+	if !rm.IsFull {
+		cy.nonFull = append(cy.nonFull, rootSeq{pk, rp.Seq})
+	} else if !cy.keepRoots && !cy.hasLastFull {
+		// we will delete roots below last full, except pinned
+		cy.lastFull, cy.hasLastFull = rp.Seq, true
+	}
+	return
+}
+
+// mark objects of a Root skipping marked ones
+func (c *Container) cleanUpWalk(cy *cleanUpCycle, pk cipher.PubKey,
+	objs getter, rp *data.RootPack, b *budget) (err error) {
+
+	var r *Root
+	if r, err = c.unpackRoot(pk, rp); err != nil {
+		return
+	}
+
+	kerr := c.knowsAbout(r, objs, c.cleanUpMark(pk, objs, cy.coll, &cy.sp,
+		&b.n))
+	if kerr != nil {
+		c.Printf("[ERR] knowsAbout of %s error: %v", r.Short(), kerr)
+	}
+
+	cy.prog.Marked = len(cy.coll)
+	return
+}
+
+// remove unmarked objects
+func (c *Container) cleanUpSweepSlice(cy *cleanUpCycle,
+	b *budget) (done bool, err error) {
+
+	err = c.DB().Update(func(tx data.Tu) (err error) {
+
+		if !cy.removed {
+			if err = c.cleanUpRemoveRoots(cy, tx, b); err != nil {
+				return
+			}
+			cy.removed = true
+		}
+
+		if err = c.cleanUpBarrier(cy, tx, b); err != nil {
+			return
+		}
+
+		objs := tx.Objects()
+
+		var dead []cipher.SHA256
+
+		for {
+			limit := sweepChunk
+			if b.max > 0 && b.max-b.n < limit {
+				if limit = b.max - b.n; limit < 1 {
+					limit = 1 // at least one object per slice
+				}
+			}
+
+			var more bool
+			cy.next, more, err = objs.RangeFrom(cy.next, limit,
+				func(key cipher.SHA256, _ []byte) (_ error) {
+					b.n++
+					cy.prog.Swept++
+					if !cy.used(key) {
+						dead = append(dead, key)
+					}
+					return
+				})
+			if err != nil {
+				return
+			}
+
+			if !more {
+				done = true
+				break
+			}
+
+			if b.exceeded() {
+				break
+			}
+		}
+
+		for _, key := range dead {
+			if err = objs.Del(key); err != nil {
+				return
+			}
+		}
+		cy.prog.Removed += len(dead)
+
+		if done {
+			// don't keep space of removed feeds
+			feeds := tx.Feeds()
+			for pk := range cy.sp.feeds {
+				if !feeds.IsExist(pk) {
+					delete(cy.sp.feeds, pk)
+				}
+			}
+			return cy.sp.store(tx) // new index of space
+		}
+		return
+	})
+
+	if err == nil && done {
+		c.cleanUpRemoveRegistries(cy.used)
+	}
+	return
+}
+
+// remove Root objects before last full; Root objects pinned
+// after the mark phase are kept and marked
+func (c *Container) cleanUpRemoveRoots(cy *cleanUpCycle, tx data.Tu,
+	b *budget) (err error) {
+
+	feeds := tx.Feeds()
+	objs := tx.Objects()
+
+	for pk, before := range cy.collRoots {
+		roots := feeds.Roots(pk)
+		if roots == nil {
+			continue
+		}
+
+		var pinned []*data.RootPack
+
+		err = roots.RangeDel(func(rp *data.RootPack) (del bool, _ error) {
+			if rp.Seq >= before {
+				return false, data.ErrStopRange
+			}
+			if roots.Meta(rp.Seq).IsPinned() {
+				pinned = append(pinned, rp)
+				return
+			}
+			return true, nil
+		})
+		if err != nil {
+			return
+		}
+
+		for _, rp := range pinned {
+			if err = c.cleanUpWalk(cy, pk, objs, rp, b); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// cleanUpBarrier keeps objects saved during the cycle and marks
+// objects of Root objects added during the cycle and objects of
+// non-full Root objects, that can be filled during the cycle.
+// The barrier called by every slice of sweep phase
+func (c *Container) cleanUpBarrier(cy *cleanUpCycle, tx data.Tu,
+	b *budget) (err error) {
+
+	for hash := range c.inc.takeShaded() {
+		cy.keep[hash] = struct{}{}
+	}
+
+	feeds := tx.Feeds()
+	objs := tx.Objects()
+
+	// non-full Root objects
+
+	var nonFull []rootSeq
+	for _, rs := range cy.nonFull {
+		roots := feeds.Roots(rs.pk)
+		if roots == nil {
+			continue // removed
+		}
+		rp := roots.Get(rs.seq)
+		if rp == nil {
+			continue // removed
+		}
+		if err = c.cleanUpWalk(cy, rs.pk, objs, rp, b); err != nil {
+			return
+		}
+		if !roots.Meta(rs.seq).IsFull {
+			nonFull = append(nonFull, rs) // walk again next time
+		}
+	}
+	cy.nonFull = nonFull
+
+	// new Root objects
+
+	return feeds.Range(func(pk cipher.PubKey) (err error) {
+		roots := feeds.Roots(pk)
+
+		var from uint64
+		if last, ok := cy.lastSeq[pk]; ok {
+			from = last + 1
+		}
+
+		_, _, err = roots.RangeFrom(from, 0, func(rp *data.RootPack) error {
+			cy.lastSeq[pk] = rp.Seq
+			if !roots.Meta(rp.Seq).IsFull {
+				cy.nonFull = append(cy.nonFull, rootSeq{pk, rp.Seq})
+			}
+			return c.cleanUpWalk(cy, pk, objs, rp, b)
+		})
+		return
+	})
+}
@@ -0,0 +1,213 @@
+package skyobject
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestContainer_CleanUpStep(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+	conf.CleanUpObjects = 2
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashOf := func(u User) cipher.SHA256 {
+		return cipher.SumSHA256(encoder.Serialize(u))
+	}
+
+	save := func(u User) {
+		pack.Clear()
+		pack.Append(&u)
+		if _, err := pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users := []User{{Name: "Alice"}, {Name: "Eva"}, {Name: "Ammy"}}
+	for _, u := range users {
+		save(u)
+	}
+
+	step := func() (done bool) {
+		var err error
+		if done, err = c.CleanUpStep(false); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// mark phase
+	for c.Stat().CleanUpProgress.Phase != CleanUpSweep {
+		if step() {
+			t.Fatal("unexpected end of cycle")
+		}
+	}
+
+	// added during the cycle
+	bob := User{Name: "Bob"}
+	save(bob)
+	orphan := []byte("orphan")
+	if err = c.Set(cipher.SumSHA256(orphan), orphan); err != nil {
+		t.Fatal(err)
+	}
+
+	for !step() {
+	}
+
+	st := c.Stat()
+	if p := st.CleanUpProgress; p.Phase != CleanUpIdle || p.Cycles != 1 {
+		t.Errorf("wrong progress: %+v", p)
+	}
+	if st.CleanUpPause == 0 || st.CleanUpMaxPause == 0 {
+		t.Error("wrong pause times:", st.CleanUpPause, st.CleanUpMaxPause)
+	}
+
+	for i, u := range users {
+		if got := c.Get(hashOf(u)) != nil; got != (i == 2) {
+			t.Errorf("%s: exists %t", u.Name, got)
+		}
+	}
+	if c.Get(hashOf(bob)) == nil {
+		t.Error("object of Root added during the cycle removed")
+	}
+	if c.Get(cipher.SumSHA256(orphan)) == nil {
+		t.Error("object saved during the cycle removed")
+	}
+	for _, seq := range []uint64{0, 1} {
+		if _, _, err = c.RootBySeq(pk, seq); err == nil {
+			t.Error("Root is not removed:", seq)
+		}
+	}
+	if rep, err := c.Check(false); err != nil {
+		t.Error(err)
+	} else if !rep.IsClean() {
+		t.Errorf("wrong check report: %+v", rep)
+	}
+	if fs := c.Stat().Feeds[pk]; fs.Objects != 3 {
+		// registry, Ammy and Bob
+		t.Errorf("wrong space: %+v", fs)
+	}
+
+	// next cycle removes the orphan
+	for !step() {
+	}
+	if c.Get(cipher.SumSHA256(orphan)) != nil {
+		t.Error("orphan is not removed")
+	}
+
+}
+
+func TestContainer_CleanUpStep_filler(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+	conf.CleanUpObjects = 1
+
+	src := NewContainer(data.NewMemoryDB(), conf)
+	defer src.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+	if err := src.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := src.NewRoot(pk, sk, 0, src.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	leader := &User{Name: "Alice"}
+	pack.Append(&Group{Name: "Mates", Leader: pack.Ref(leader)})
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	r := pack.Root()
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	if err = c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+	// the leader is in DB before the cycle, but
+	// it's not reachable from Root objects of DB
+	lh := pack.Ref(leader).Hash
+	if err = c.Set(lh, encoder.Serialize(leader)); err != nil {
+		t.Fatal(err)
+	}
+
+	step := func() (done bool) {
+		var err error
+		if done, err = c.CleanUpStep(false); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// mark phase
+	for c.Stat().CleanUpProgress.Phase != CleanUpSweep {
+		if step() {
+			t.Fatal("unexpected end of cycle")
+		}
+	}
+
+	// the Root is not saved yet, thus barrier of
+	// the cycle can't walk it; the group is received
+	// and saved, and the leader is found locally
+
+	wantq := make(chan WCXO)
+	fullq := make(chan *Root, 1)
+	dropq := make(chan DropRootError, 1)
+
+	var wg sync.WaitGroup
+	fl := c.NewFiller(r, wantq, fullq, dropq, nil, &wg)
+
+Loop:
+	for {
+		select {
+		case wc := <-wantq:
+			if wc.Hash == lh {
+				t.Fatal("local object requested")
+			}
+			val := src.Get(wc.Hash)
+			if err = c.Set(wc.Hash, val); err != nil {
+				t.Fatal(err)
+			}
+			wc.GotQ <- val
+		case <-fullq:
+			break Loop
+		case <-dropq:
+			break Loop // can't mark as full, since it's not saved
+		}
+	}
+	fl.Close()
+	wg.Wait()
+
+	for !step() {
+	}
+
+	if c.Get(lh) == nil {
+		t.Error("object found by Filler during the cycle removed")
+	}
+
+}
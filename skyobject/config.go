@@ -17,6 +17,9 @@ const (
 	KeepRoots   bool          = false            // remove
 	KeepNonFull bool          = false            // remove
 
+	CleanUpObjects int           = 0 // full CleanUp by interval
+	CleanUpSlice   time.Duration = 0 // full CleanUp by interval
+
 	CleanUpPin        log.Pin = 1 << iota // show time of CleanUp in logs
 	PackSavePin                           // show time of (*Pack).Save in logs
 	CleanUpVerbosePin                     // show collecting and removing times
@@ -24,6 +27,15 @@ const (
 	VerbosePin // to many logs to show
 )
 
+// default limits of filling
+const (
+	MaxRefsDepth   int = 32       // max depth of Refs tree
+	MaxRefsLength  int = 0        // max length of Refs (0 = no limit)
+	MaxObjectSize  int = 16 << 20 // max size of an object (16M)
+	MaxRootObjects int = 0        // max objects per Root (0 = no limit)
+	MaxRootSize    int = 0        // max bytes per Root (0 = no limit)
+)
+
 // A Config represents oconfigurations
 // and options of Container
 type Config struct {
@@ -48,6 +60,17 @@ type Config struct {
 	// cleaning can be stopped by (*Container).Close() only
 	CleanUp time.Duration
 
+	// CleanUpObjects and CleanUpSlice turn CleanUp by interval
+	// into incremental one. The incremental CleanUp works in slices,
+	// every slice is a transaction that visits up to CleanUpObjects
+	// objects or takes up to CleanUpSlice time. Between the slices
+	// other transactions are not blocked. Set both to 0 to perform
+	// full CleanUp in single transaction. A slice can visit more
+	// objects or take more time, since every Root is marked in one
+	// slice
+	CleanUpObjects int
+	CleanUpSlice   time.Duration
+
 	// KeepRoots instead of removing. By default (e.g. if it is false)
 	// (*Container).CleanUp removes all Root obejcts of a feed before
 	// last full
@@ -57,6 +80,33 @@ type Config struct {
 	// false) all non-full root objects will be removed from database
 	// before shutdown
 	KeepNonFull bool
+
+	// Limits of filling. A Root that exceeds any of the limits
+	// will be dropped by Filler with appropriate error. Set a
+	// limit to 0 to disable it
+
+	// MaxRefsDepth is max depth of Merkle tree of Refs
+	MaxRefsDepth int
+	// MaxRefsLength is max length of Refs
+	MaxRefsLength int
+	// MaxObjectSize is max size of encoded object in bytes
+	MaxObjectSize int
+	// MaxRootObjects is max number of objects of a Root
+	// including registry and nodes of Refs
+	MaxRootObjects int
+	// MaxRootSize is max total size of all objects of a Root
+	MaxRootSize int
+
+	// Quota is default storage quota of every feed. Use
+	// (*Container).SetQuota to set quota of a particular
+	// feed. Zero Quota means no limits
+	Quota Quota
+
+	// Validators of objects by registered names of schemas.
+	// The Validators used by Filler for every received object
+	// and by (*Pack).Save before signing a Root. Can be nil.
+	// Don't modify the map after creating a Container
+	Validators Validators
 }
 
 // NewConfig returns pointer to Config with default values
@@ -82,6 +132,16 @@ func NewConfig() (conf *Config) {
 	conf.CleanUp = CleanUp
 	conf.KeepRoots = KeepRoots
 	conf.KeepNonFull = KeepNonFull
+	conf.CleanUpObjects = CleanUpObjects
+	conf.CleanUpSlice = CleanUpSlice
+
+	// limits
+
+	conf.MaxRefsDepth = MaxRefsDepth
+	conf.MaxRefsLength = MaxRefsLength
+	conf.MaxObjectSize = MaxObjectSize
+	conf.MaxRootObjects = MaxRootObjects
+	conf.MaxRootSize = MaxRootSize
 	return
 }
 
@@ -91,5 +151,30 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("skyobject.Config.MerkleDegree too small: %d",
 			c.MerkleDegree)
 	}
+	for _, l := range []struct {
+		name string
+		val  int
+	}{
+		{"CleanUpObjects", c.CleanUpObjects},
+		{"CleanUpSlice", int(c.CleanUpSlice)},
+		{"MaxRefsDepth", c.MaxRefsDepth},
+		{"MaxRefsLength", c.MaxRefsLength},
+		{"MaxObjectSize", c.MaxObjectSize},
+		{"MaxRootObjects", c.MaxRootObjects},
+		{"MaxRootSize", c.MaxRootSize},
+		{"Quota.MaxRoots", c.Quota.MaxRoots},
+		{"Quota.MaxSpace", int(c.Quota.MaxSpace)},
+	} {
+		if l.val < 0 {
+			return fmt.Errorf("skyobject.Config.%s is negative: %d",
+				l.name, l.val)
+		}
+	}
 	return nil
 }
+
+// isIncremental returns true if CleanUp by
+// interval should be incremental
+func (c *Config) isIncremental() bool {
+	return c.CleanUpObjects > 0 || c.CleanUpSlice > 0
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
-	"github.com/skycoin/skycoin/src/cipher/encoder"
 
 	"github.com/skycoin/cxo/data"
 	"github.com/skycoin/cxo/node/log"
@@ -36,8 +35,13 @@ type Container struct {
 	rmx  sync.RWMutex
 	regs map[RegistryRef]*Registry
 
+	// revoked certificates
+	revoked revoked
+
+
 	// clean up
-	cleanmx sync.Mutex // clean up mutex
+	cleanmx sync.Mutex  // clean up mutex
+	inc     incremental // state of incremental CleanUp
 
 	closeq chan struct{}  //
 	closeo sync.Once      // clean up by interval
@@ -62,6 +66,7 @@ func NewContainer(db data.DB, conf *Config) (c *Container) {
 	c.closeq = make(chan struct{})
 	c.Logger = log.NewLogger(conf.Log)
 	c.regs = make(map[RegistryRef]*Registry)
+	c.revoked.init()
 	// copy configs
 	c.conf = *conf
 	c.stat.init(c.conf.StatSamples)
@@ -74,6 +79,21 @@ func NewContainer(db data.DB, conf *Config) (c *Container) {
 		}
 	}
 
+	if err := c.loadRevoked(); err != nil {
+		c.db.Close() // to be safe
+		panic(err)   // fatality
+	}
+
+	if err := c.loadSpace(); err != nil {
+		c.db.Close() // to be safe
+		panic(err)   // fatality
+	}
+
+	if err := c.loadRootTime(); err != nil {
+		c.db.Close() // to be safe
+		panic(err)   // fatality
+	}
+
 	if c.conf.CleanUp > 0 {
 		c.await.Add(1)
 		go c.cleanUpByInterval()
@@ -132,6 +152,8 @@ func (c *Container) DB() data.DB {
 func (c *Container) Set(hash cipher.SHA256, val []byte) (err error) {
 	c.Debugln(VerbosePin, "Set", hash.Hex()[:7])
 
+	c.inc.shade(hash) // keep it from incremental CleanUp
+
 	return c.DB().Update(func(tx data.Tu) error {
 		return tx.Objects().Set(hash, val)
 	})
@@ -171,6 +193,22 @@ func (c *Container) Registry(rr RegistryRef) *Registry {
 	return c.regs[rr]
 }
 
+// registryOf returns Registry of the Container or
+// Registry decoded from given getter if the Container
+// doesn't have the Registry loaded
+func (c *Container) registryOf(rr RegistryRef, g getter) (reg *Registry,
+	err error) {
+
+	if reg = c.Registry(rr); reg != nil {
+		return
+	}
+	var val []byte
+	if val = g.Get(cipher.SHA256(rr)); val == nil {
+		return nil, fmt.Errorf("missing registry [%s]", rr.Short())
+	}
+	return DecodeRegistry(val)
+}
+
 // Root of a feed by seq. If err is nil then the Root is not
 func (c *Container) Root(pk cipher.PubKey, seq uint64) (r *Root, err error) {
 	c.Debugln(VerbosePin, "Root", pk.Hex()[:7], seq)
@@ -329,6 +367,10 @@ func (c *Container) CleanUp(keepRoots bool) (err error) {
 	// remove roots before (seq of last full)
 	collRoots := make(map[cipher.PubKey]uint64)
 
+	// new index of space taken by feeds
+	var sp space
+	sp.init()
+
 	// we have to use single transaction
 
 	err = c.DB().Update(func(tx data.Tu) (err error) {
@@ -337,7 +379,7 @@ func (c *Container) CleanUp(keepRoots bool) (err error) {
 		// collect
 		//
 
-		err = c.cleanUpCollect(tx, coll, collRoots, keepRoots)
+		err = c.cleanUpCollect(tx, coll, collRoots, &sp, keepRoots)
 
 		if err != nil {
 			c.Debugf(CleanUpPin,
@@ -361,11 +403,13 @@ func (c *Container) CleanUp(keepRoots bool) (err error) {
 			coll[cipher.SHA256(cr.Reference())] = 1
 		}
 
-		if len(coll) != 0 && len(collRoots) != 0 {
-			err = c.cleanUpRemove(tx, coll, collRoots, keepRoots)
+		// the same rule as incremental CleanUp uses:
+		// all objects that are not collected are removed
+		if err = c.cleanUpRemove(tx, coll, collRoots, keepRoots); err != nil {
+			return
 		}
 
-		return
+		return sp.store(tx) // new index of space
 	})
 
 	elapsed = time.Now().Sub(tp)
@@ -376,6 +420,8 @@ func (c *Container) CleanUp(keepRoots bool) (err error) {
 		return
 	}
 
+	c.inc.reset() // the cycle is not needed anymore
+
 	if c.Logger.Pins()&CleanUpVerbosePin != 0 {
 		verboseElapsed = time.Now().Sub(tp) - verboseElapsed
 		c.Debug(CleanUpVerbosePin, "CleanUp removing took: ",
@@ -387,7 +433,7 @@ func (c *Container) CleanUp(keepRoots bool) (err error) {
 }
 
 func (c *Container) cleanUpCollect(tx data.Tu, coll map[cipher.SHA256]int,
-	collRoots map[cipher.PubKey]uint64, keepRoots bool) error {
+	collRoots map[cipher.PubKey]uint64, sp *space, keepRoots bool) error {
 
 	feeds := tx.Feeds()
 	objs := tx.Objects()
@@ -395,55 +441,87 @@ func (c *Container) cleanUpCollect(tx data.Tu, coll map[cipher.SHA256]int,
 	// range over roots
 
 	return feeds.Range(func(pk cipher.PubKey) (err error) {
+		return c.cleanUpCollectFeed(pk, feeds.Roots(pk), objs, coll,
+			collRoots, sp, keepRoots)
+	})
 
-		roots := feeds.Roots(pk)
+}
 
-		var lastFull uint64
-		var hasLastFull bool
+// cleanUpCollectFeed collects objects of Root objects of a feed
+func (c *Container) cleanUpCollectFeed(pk cipher.PubKey,
+	roots data.ViewRoots, objs data.ViewObjects, coll map[cipher.SHA256]int,
+	collRoots map[cipher.PubKey]uint64, sp *space, keepRoots bool) (err error) {
 
-		err = roots.Reverse(func(rp *data.RootPack) (err error) {
+	var lastFull uint64
+	var hasLastFull bool
 
-			var r *Root
-			if r, err = c.unpackRoot(pk, rp); err != nil {
-				return
-			}
+	err = roots.Reverse(func(rp *data.RootPack) (err error) {
 
-			kerr := c.knowsAbout(r, objs, func(hash cipher.SHA256) (deeper bool,
-				_ error) {
+		rm := roots.Meta(rp.Seq)
 
-				if _, ok := coll[hash]; !ok {
-					coll[hash] = 1
-					return true, nil // go deeper
-				}
-				return // already known the object
-			})
-			if kerr != nil {
-				c.Printf("[ERR] knowsAbout of %s error: %v",
-					r.Short(),
-					kerr)
-			}
+		if hasLastFull && !rm.IsPinned() {
+			return // will be removed
+		}
 
-			// ignore all possible errors of the knowsAbout
-			// becasue we need to walk through all Roots
-			//
-			// TOTH (kostyarin): ignore? or be strict?
+		var r *Root
+		if r, err = c.unpackRoot(pk, rp); err != nil {
+			return
+		}
 
-			if rp.IsFull && !keepRoots {
-				lastFull, hasLastFull = rp.Seq, true
-				return data.ErrStopRange // we will delete roots below last full
-			}
+		kerr := c.knowsAbout(r, objs, c.cleanUpMark(pk, objs, coll, sp,
+			nil))
+		if kerr != nil {
+			c.Printf("[ERR] knowsAbout of %s error: %v",
+				r.Short(),
+				kerr)
+		}
 
-			return
-		})
+		// ignore all possible errors of the knowsAbout
+		// becasue we need to walk through all Roots
+		//
+		// TOTH (kostyarin): ignore? or be strict?
 
-		if hasLastFull && !keepRoots {
-			collRoots[pk] = lastFull
+		if rm.IsFull && !keepRoots && !hasLastFull {
+			// we will delete roots below last full, except pinned
+			lastFull, hasLastFull = rp.Seq, true
 		}
 
 		return
-
 	})
 
+	if hasLastFull && !keepRoots {
+		collRoots[pk] = lastFull
+	}
+
+	return
+}
+
+// cleanUpMark returns knowsAboutFunc that marks objects of a Root of
+// given feed as used and counts space taken by them. It skips missing
+// objects. If n is not nil, then it's increased for every visited
+// object
+func (c *Container) cleanUpMark(pk cipher.PubKey, objs getter,
+	coll map[cipher.SHA256]int, sp *space, n *int) knowsAboutFunc {
+
+	return func(hash cipher.SHA256) (deeper bool, err error) {
+		if n != nil {
+			*n++
+		}
+		val := objs.Get(hash)
+		if val == nil {
+			return // missing object
+		}
+		if _, ok := coll[hash]; !ok {
+			coll[hash] = 1
+			deeper = true // go deeper
+		}
+		// the object can be known, but not owned by the feed
+		var added bool
+		if added, err = sp.own(pk, hash, data.Space(len(val))); added {
+			deeper = true
+		}
+		return
+	}
 }
 
 func (c *Container) cleanUpRemove(tx data.Tu, coll map[cipher.SHA256]int,
@@ -451,7 +529,10 @@ func (c *Container) cleanUpRemove(tx data.Tu, coll map[cipher.SHA256]int,
 
 	// remove unused registries
 
-	c.cleanUpRemoveRegistries(coll)
+	c.cleanUpRemoveRegistries(func(hash cipher.SHA256) (ok bool) {
+		_, ok = coll[hash]
+		return
+	})
 
 	// remove roots
 
@@ -459,7 +540,16 @@ func (c *Container) cleanUpRemove(tx data.Tu, coll map[cipher.SHA256]int,
 		feeds := tx.Feeds()
 		for pk, before := range collRoots {
 			if roots := feeds.Roots(pk); roots != nil {
-				if err = roots.DelBefore(before); err != nil {
+				err = roots.RangeDel(func(rp *data.RootPack) (del bool,
+					_ error) {
+
+					if rp.Seq >= before {
+						return false, data.ErrStopRange
+					}
+					del = !roots.Meta(rp.Seq).IsPinned()
+					return
+				})
+				if err != nil {
 					return
 				}
 			}
@@ -468,27 +558,26 @@ func (c *Container) cleanUpRemove(tx data.Tu, coll map[cipher.SHA256]int,
 
 	// remove objects
 
-	if len(coll) > 0 {
-		objs := tx.Objects()
-		return objs.RangeDel(func(key cipher.SHA256, _ []byte) (del bool,
-			_ error) {
-
-			if _, ok := coll[key]; !ok {
-				del = true
-			}
-			return
-		})
-	}
+	objs := tx.Objects()
+	return objs.RangeDel(func(key cipher.SHA256, _ []byte) (del bool,
+		_ error) {
 
-	return
+		if _, ok := coll[key]; !ok {
+			del = true
+		}
+		return
+	})
 }
 
-func (c *Container) cleanUpRemoveRegistries(coll map[cipher.SHA256]int) {
+// remove registries that are not used
+func (c *Container) cleanUpRemoveRegistries(
+	used func(hash cipher.SHA256) bool) {
+
 	c.rmx.Lock()
 	defer c.rmx.Unlock()
 
 	for k := range c.regs {
-		if _, ok := coll[cipher.SHA256(k)]; !ok {
+		if !used(cipher.SHA256(k)) {
 			delete(c.regs, k)
 			c.stat.addRegistry(-1)
 		}
@@ -511,7 +600,12 @@ func (c *Container) cleanUpByInterval() {
 	for {
 		select {
 		case <-tick:
-			if err = c.CleanUp(c.conf.KeepRoots); err != nil {
+			if c.conf.isIncremental() {
+				err = c.cleanUpIncremental()
+			} else {
+				err = c.CleanUp(c.conf.KeepRoots)
+			}
+			if err != nil {
 				c.Print("[ERR] CleanUp error: ", err)
 			}
 		case <-c.closeq:
@@ -525,8 +619,7 @@ func (c *Container) unpackRoot(pk cipher.PubKey, rp *data.RootPack) (r *Root,
 
 	c.Debugln(VerbosePin, "unpackRoot", pk.Hex()[:7], rp.Seq)
 
-	r = new(Root)
-	if err = encoder.DeserializeRaw(rp.Root, r); err != nil {
+	if r, err = DecodeRoot(rp.Root); err != nil {
 		// detailed error
 		err = fmt.Errorf("error decoding root"+
 			" (feed %s, seq %d, hash %s): %v",
@@ -535,6 +628,7 @@ func (c *Container) unpackRoot(pk cipher.PubKey, rp *data.RootPack) (r *Root,
 			rp.Hash.Hex()[:7],
 			err)
 		r = nil
+		return
 	}
 	r.Sig = rp.Sig
 	r.Hash = rp.Hash
@@ -555,7 +649,8 @@ func (c *Container) removeNonFullRoots() error {
 		return feeds.Range(func(pk cipher.PubKey) error {
 			roots := feeds.Roots(pk)
 			return roots.RangeDel(func(rp *data.RootPack) (del bool, _ error) {
-				del = !rp.IsFull
+				rm := roots.Meta(rp.Seq)
+				del = !rm.IsFull && !rm.IsPinned()
 				return
 			})
 		})
@@ -601,8 +696,13 @@ func (c *Container) AddFeed(pk cipher.PubKey) error {
 func (c *Container) DelFeed(pk cipher.PubKey) (err error) {
 	c.Debugln(VerbosePin, "DelFeed", pk.Hex()[:7])
 
-	err = c.DB().Update(func(tx data.Tu) error {
-		return tx.Feeds().Del(pk)
+	err = c.DB().Update(func(tx data.Tu) (err error) {
+		if err = tx.Feeds().Del(pk); err != nil {
+			return
+		}
+		// objects of the feed will be removed
+		// from the index of space by CleanUp
+		return tx.Bucket(spaceBucket).Del(spaceFeedKey(pk))
 	})
 	return
 }
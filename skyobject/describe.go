@@ -0,0 +1,351 @@
+package skyobject
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// kinds of references used by SchemaDesc
+const (
+	KindRef     = "ref"     // Ref
+	KindRefs    = "refs"    // Refs
+	KindDynamic = "dynamic" // Dynamic
+)
+
+// A SchemaDesc is human readable description of a Schema.
+// It's designed to be encoded to JSON
+type SchemaDesc struct {
+	Name string `json:"name,omitempty"` // name of named or registered type
+
+	// Kind is reflect.Kind in lower case (e.g. "uint32", "slice",
+	// "struct") or one of KindRef, KindRefs and KindDynamic
+	Kind string `json:"kind"`
+
+	Schema string      `json:"schema,omitempty"` // schema of ref and refs
+	Len    int         `json:"len,omitempty"`    // length of array
+	Elem   *SchemaDesc `json:"elem,omitempty"`   // element of array and slice
+
+	// Fields of a struct. Fields of registered struct are
+	// described only on top level (see RegistryDesc)
+	Fields []FieldDesc `json:"fields,omitempty"`
+}
+
+// A FieldDesc is human readable description of a Field
+type FieldDesc struct {
+	Name   string     `json:"name"`
+	Tag    string     `json:"tag,omitempty"`
+	Schema SchemaDesc `json:"schema"`
+}
+
+// A RegistryDesc is human readable description of a Registry
+type RegistryDesc struct {
+	Ref     string       `json:"ref"`
+	Schemas []SchemaDesc `json:"schemas"` // ordered by name
+}
+
+// Describe the Registry
+func (r *Registry) Describe() (rd RegistryDesc) {
+	rd.Ref = r.Reference().String()
+	for _, s := range r.Schemas() {
+		rd.Schemas = append(rd.Schemas, describeSchema(s, true))
+	}
+	return
+}
+
+// describe Schema, fields of registered struct are
+// described only if the top is true
+func describeSchema(s Schema, top bool) (sd SchemaDesc) {
+	if s.IsReference() {
+		switch s.ReferenceType() {
+		case ReferenceTypeSingle:
+			sd.Kind = KindRef
+		case ReferenceTypeSlice:
+			sd.Kind = KindRefs
+		default:
+			sd.Kind = KindDynamic
+			return
+		}
+		sd.Schema = s.Elem().Name()
+		return
+	}
+	sd.Kind = s.Kind().String()
+	if sd.Name = s.Name(); sd.Name == sd.Kind {
+		sd.Name = "" // predeclared type (e.g. string)
+	}
+	switch s.Kind() {
+	case reflect.Array:
+		sd.Len = s.Len()
+		fallthrough
+	case reflect.Slice:
+		el := describeSchema(s.Elem(), false)
+		sd.Elem = &el
+	case reflect.Struct:
+		if s.IsRegistered() && !top {
+			return
+		}
+		for _, f := range s.Fields() {
+			sd.Fields = append(sd.Fields, FieldDesc{
+				Name:   f.Name(),
+				Tag:    string(f.RawTag()),
+				Schema: describeSchema(f.Schema(), false),
+			})
+		}
+	}
+	return
+}
+
+// IDL returns human readable representation of the Registry, e.g.
+//
+//     registry 2c3b4e5
+//
+//     cxo.Group {
+//         Name    string
+//         Leader  Ref<cxo.User>
+//         Members Refs<cxo.User>
+//         Curator Dynamic
+//     }
+//
+// Named types that are not structures are shown as
+// name(underlying type), e.g. Age(uint32)
+func (r *Registry) IDL() string {
+	var b bytes.Buffer
+	rd := r.Describe()
+	fmt.Fprintln(&b, "registry", rd.Ref)
+	for _, sd := range rd.Schemas {
+		fmt.Fprintln(&b)
+		fmt.Fprint(&b, sd.Name, " ")
+		idlStruct(&b, sd.Fields, "")
+		fmt.Fprintln(&b)
+	}
+	return b.String()
+}
+
+func idlStruct(b *bytes.Buffer, fs []FieldDesc, indent string) {
+	if len(fs) == 0 {
+		b.WriteString("{}")
+		return
+	}
+	var max int
+	for _, f := range fs {
+		if len(f.Name) > max {
+			max = len(f.Name)
+		}
+	}
+	b.WriteString("{\n")
+	for _, f := range fs {
+		fmt.Fprintf(b, "%s    %-*s ", indent, max, f.Name)
+		idlType(b, f.Schema, indent+"    ")
+		b.WriteByte('\n')
+	}
+	b.WriteString(indent + "}")
+}
+
+func idlType(b *bytes.Buffer, sd SchemaDesc, indent string) {
+	switch sd.Kind {
+	case KindRef:
+		fmt.Fprintf(b, "Ref<%s>", sd.Schema)
+		return
+	case KindRefs:
+		fmt.Fprintf(b, "Refs<%s>", sd.Schema)
+		return
+	case KindDynamic:
+		b.WriteString("Dynamic")
+		return
+	}
+	if sd.Name != "" {
+		b.WriteString(sd.Name)
+		if sd.Kind == reflect.Struct.String() {
+			return // registered
+		}
+		b.WriteByte('(')
+		defer b.WriteByte(')')
+	}
+	switch sd.Kind {
+	case reflect.Array.String():
+		fmt.Fprintf(b, "[%d]", sd.Len)
+		idlType(b, *sd.Elem, indent)
+	case reflect.Slice.String():
+		b.WriteString("[]")
+		idlType(b, *sd.Elem, indent)
+	case reflect.Struct.String():
+		idlStruct(b, sd.Fields, indent)
+	default:
+		b.WriteString(sd.Kind)
+	}
+}
+
+// GoSource generates Go source code of types of the Registry
+// and NewRegistry function that returns the Registry. Reference
+// of the Registry created by the function will be the same.
+// Registered names are converted to exported Go identifiers
+// (e.g. "cxo.User" becomes User). The pkg is package name
+// of the source
+func (r *Registry) GoSource(pkg string) (src []byte, err error) {
+
+	rd := r.Describe()
+
+	g := goGen{
+		types: make(map[string]string),
+		named: make(map[string]string),
+	}
+
+	// identifiers of registered types
+	used := make(map[string]string)
+	for _, sd := range rd.Schemas {
+		id := goIdent(sd.Name, true)
+		if _, ok := used[id]; ok {
+			id = goIdent(sd.Name, false)
+		}
+		used[id] = sd.Name
+		g.types[sd.Name] = id
+	}
+
+	var body bytes.Buffer
+	for _, sd := range rd.Schemas {
+		fmt.Fprintf(&body, "\n// %s is registered as %q\n", g.types[sd.Name],
+			sd.Name)
+		fmt.Fprintf(&body, "type %s ", g.types[sd.Name])
+		g.goStruct(&body, sd.Fields)
+		body.WriteByte('\n')
+	}
+
+	// named types that are not structures
+	names := make([]string, 0, len(g.named))
+	for name := range g.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&body, "\n// %s is named type\n", name)
+		fmt.Fprintf(&body, "type %s %s\n", name, g.named[name])
+	}
+
+	fmt.Fprintln(&body, "\n// NewRegistry returns Registry", rd.Ref)
+	fmt.Fprintln(&body, "func NewRegistry() *skyobject.Registry {")
+	fmt.Fprintln(&body, "return skyobject.NewRegistry(func(r *skyobject.Reg) {")
+	for _, sd := range rd.Schemas {
+		fmt.Fprintf(&body, "r.Register(%q, %s{})\n", sd.Name,
+			g.types[sd.Name])
+	}
+	fmt.Fprintln(&body, "})")
+	fmt.Fprintln(&body, "}")
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated from skyobject.Registry", rd.Ref)
+	fmt.Fprintln(&b, "// DO NOT EDIT.")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "package", pkg)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, `import "github.com/skycoin/cxo/skyobject"`)
+	b.Write(body.Bytes())
+
+	return format.Source(b.Bytes())
+}
+
+type goGen struct {
+	types map[string]string // registered name -> Go identifier
+	named map[string]string // named type -> underlying type
+}
+
+func (g *goGen) goStruct(b *bytes.Buffer, fs []FieldDesc) {
+	b.WriteString("struct {\n")
+	for _, f := range fs {
+		fmt.Fprintf(b, "%s ", f.Name)
+		g.goType(b, f.Schema)
+		if tag := goTag(f); tag != "" {
+			fmt.Fprintf(b, " `%s`", tag)
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("}")
+}
+
+func (g *goGen) goType(b *bytes.Buffer, sd SchemaDesc) {
+	switch sd.Kind {
+	case KindRef:
+		b.WriteString("skyobject.Ref")
+		return
+	case KindRefs:
+		b.WriteString("skyobject.Refs")
+		return
+	case KindDynamic:
+		b.WriteString("skyobject.Dynamic")
+		return
+	}
+	if sd.Name != "" {
+		if sd.Kind == reflect.Struct.String() {
+			b.WriteString(g.types[sd.Name])
+			return
+		}
+		b.WriteString(sd.Name)
+		if _, ok := g.named[sd.Name]; !ok {
+			var u bytes.Buffer
+			ud := sd
+			ud.Name = ""
+			g.goType(&u, ud)
+			g.named[sd.Name] = u.String()
+		}
+		return
+	}
+	switch sd.Kind {
+	case reflect.Array.String():
+		fmt.Fprintf(b, "[%d]", sd.Len)
+		g.goType(b, *sd.Elem)
+	case reflect.Slice.String():
+		b.WriteString("[]")
+		g.goType(b, *sd.Elem)
+	case reflect.Struct.String():
+		g.goStruct(b, sd.Fields)
+	default:
+		b.WriteString(sd.Kind)
+	}
+}
+
+// goTag returns tag of the field, the tag of a Ref or Refs
+// always contains proper schema name
+func goTag(f FieldDesc) (tag string) {
+	tag = f.Tag
+	if f.Schema.Kind != KindRef && f.Schema.Kind != KindRefs {
+		return
+	}
+	if name, err := TagSchemaName(reflect.StructTag(tag)); err == nil &&
+		name == f.Schema.Schema {
+		return
+	}
+	st := fmt.Sprintf(`skyobject:"schema=%s"`, f.Schema.Schema)
+	if tag == "" {
+		return st
+	}
+	return tag + " " + st
+}
+
+// goIdent converts registered name to exported Go identifier;
+// if the last is true, then only last part of the name used
+// (e.g. "cxo.User" -> User), otherwise all parts are joined
+// (e.g. "cxo.User" -> CxoUser)
+func goIdent(name string, last bool) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if len(parts) == 0 {
+		return "Type"
+	}
+	if last {
+		parts = parts[len(parts)-1:]
+	}
+	for i, p := range parts {
+		rs := []rune(p)
+		rs[0] = unicode.ToUpper(rs[0])
+		parts[i] = string(rs)
+	}
+	id := strings.Join(parts, "")
+	if r := []rune(id)[0]; !unicode.IsUpper(r) {
+		id = "T" + id // starts with a digit or underscore
+	}
+	return id
+}
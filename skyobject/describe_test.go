@@ -0,0 +1,164 @@
+package skyobject
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type describeLevel uint8
+
+type describeTeam struct {
+	Name   string
+	Level  describeLevel
+	Leader Ref  `skyobject:"schema=cxo.User" json:"leader"`
+	Groups Refs `skyobject:"schema=cxo.Group"`
+	Places [2]struct {
+		Lat, Lng float64
+	}
+	Devs []Developer
+}
+
+func TestRegistry_Describe(t *testing.T) {
+
+	reg := getRegisty()
+
+	dec, err := DecodeRegistry(reg.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rd := reg.Describe()
+	if !reflect.DeepEqual(rd, dec.Describe()) {
+		t.Error("different description of decoded registry")
+	}
+
+	if rd.Ref != reg.Reference().String() || len(rd.Schemas) != 3 {
+		t.Fatalf("wrong description: %+v", rd)
+	}
+
+	group := rd.Schemas[1]
+	if group.Name != "cxo.Group" || group.Kind != "struct" ||
+		len(group.Fields) != 4 {
+
+		t.Fatalf("wrong description of cxo.Group: %+v", group)
+	}
+	for i, want := range []SchemaDesc{
+		{Kind: "string"},
+		{Kind: KindRef, Schema: "cxo.User"},
+		{Kind: KindRefs, Schema: "cxo.User"},
+		{Kind: KindDynamic},
+	} {
+		if got := group.Fields[i].Schema; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong schema of %s: %+v", group.Fields[i].Name, got)
+		}
+	}
+
+	idl := reg.IDL()
+	for _, line := range []string{
+		"registry " + rd.Ref,
+		"cxo.Group {",
+		"    Leader  Ref<cxo.User>",
+		"    Members Refs<cxo.User>",
+		"    Curator Dynamic",
+	} {
+		if !strings.Contains(idl, line+"\n") {
+			t.Errorf("missing %q in IDL:\n%s", line, idl)
+		}
+	}
+
+}
+
+// types of the source generated by TestRegistry_GoSource
+
+type genDeveloper struct {
+	Name   string
+	GitHub string
+}
+
+type genGroup struct {
+	Name    string
+	Leader  Ref  `skyobject:"schema=cxo.User"`
+	Members Refs `skyobject:"schema=cxo.User"`
+	Curator Dynamic
+}
+
+type genTeam struct {
+	Name   string
+	Level  describeLevel
+	Leader Ref  `skyobject:"schema=cxo.User" json:"leader"`
+	Groups Refs `skyobject:"schema=cxo.Group"`
+	Places [2]struct {
+		Lat float64
+		Lng float64
+	}
+	Devs []genDeveloper
+}
+
+type genUser struct {
+	Name string
+	Age  uint32
+}
+
+func TestRegistry_GoSource(t *testing.T) {
+
+	reg := NewRegistry(func(r *Reg) {
+		r.Register("cxo.User", User{})
+		r.Register("cxo.Group", Group{})
+		r.Register("cxo.Developer", Developer{})
+		r.Register("cxo.Team", describeTeam{})
+	})
+
+	src, err := reg.GoSource("types")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"package types",
+		`import "github.com/skycoin/cxo/skyobject"`,
+		"type Team struct {",
+		"\tLevel  describeLevel\n",
+		"\tLeader skyobject.Ref  `skyobject:\"schema=cxo.User\" json:\"leader\"`",
+		"\tGroups skyobject.Refs `skyobject:\"schema=cxo.Group\"`",
+		"\tPlaces [2]struct {",
+		"\tDevs []Developer\n",
+		"type describeLevel uint8\n",
+		"func NewRegistry() *skyobject.Registry {",
+		"\t\tr.Register(\"cxo.Team\", Team{})",
+	} {
+		if !strings.Contains(string(src), line) {
+			t.Errorf("missing %q in source:\n%s", line, src)
+		}
+	}
+
+	// registry built from the generated source
+	gen := NewRegistry(func(r *Reg) {
+		r.Register("cxo.Developer", genDeveloper{})
+		r.Register("cxo.Group", genGroup{})
+		r.Register("cxo.Team", genTeam{})
+		r.Register("cxo.User", genUser{})
+	})
+	if gen.Reference() != reg.Reference() {
+		t.Errorf("generated registry has another reference: want %s, got %s",
+			reg.Reference().Short(), gen.Reference().Short())
+	}
+	if !strings.Contains(string(src), reg.Reference().String()) {
+		t.Error("missing reference of the registry in source")
+	}
+
+	for name, want := range map[string]string{
+		"cxo.User":   "User",
+		"a.b-c":      "C",
+		"x.9lives":   "T9lives",
+		"_internal_": "T_internal_",
+	} {
+		if got := goIdent(name, true); got != want {
+			t.Errorf("goIdent(%q): want %q, got %q", name, want, got)
+		}
+	}
+	if got := goIdent("cxo.User", false); got != "CxoUser" {
+		t.Error("wrong identifier:", got)
+	}
+
+}
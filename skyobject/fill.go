@@ -8,19 +8,74 @@ import (
 
 	"github.com/skycoin/skycoin/src/cipher"
 	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
 )
 
 // some of Root dropping reasons
 var (
 	ErrEmptyRegsitryRef = errors.New("empty registry reference")
+
+	// limits (see Config)
+
+	ErrRefsTooDeep     = errors.New("Refs tree is too deep")
+	ErrRefsTooLong     = errors.New("Refs is too long")
+	ErrObjectTooLarge  = errors.New("object is too large")
+	ErrTooManyObjects  = errors.New("too many objects in Root")
+	ErrRootTooLarge    = errors.New("Root is too large")
+	ErrMalformedRefs   = errors.New("malformed Refs")
+	ErrMalformedObject = errors.New("malformed object")
 )
 
+// IsLimitError returns true if given error is one of limit
+// errors or malformed data errors of Filler. Such errors
+// means that a peer sends malicious (or broken) Root
+func IsLimitError(err error) bool {
+	switch err {
+	case ErrRefsTooDeep, ErrRefsTooLong, ErrObjectTooLarge,
+		ErrTooManyObjects, ErrRootTooLarge, ErrMalformedRefs,
+		ErrMalformedObject:
+		return true
+	}
+	return false
+}
+
 // A WCXO represents wanted CX object
 type WCXO struct {
 	Hash cipher.SHA256 // hash of wanted CX object
 	GotQ chan []byte   // cahnnel to sent requested CX object
 }
 
+// A FillingProgress represents progress of filling
+// of a Root. The Discovered field is estimated amount
+// of objects of the Root. The estimation is based on
+// lengths of Refs and grows while the Root is filling
+type FillingProgress struct {
+	Feed cipher.PubKey // feed of the Root
+	Seq  uint64        // seq number of the Root
+	Hash cipher.SHA256 // hash of the Root
+
+	Requested  int        // requested objects
+	Received   int        // received objects
+	Bytes      data.Space // received bytes
+	Local      int        // objects found in local DB
+	Discovered int        // estimated amount of objects of the Root
+}
+
+// Done returns amount of objects that already
+// in local DB (received or found)
+func (f *FillingProgress) Done() int {
+	return f.Local + f.Received
+}
+
+// Percent returns estimated percentage of filling
+func (f *FillingProgress) Percent() float64 {
+	if f.Discovered == 0 || f.Done() >= f.Discovered {
+		return 100
+	}
+	return float64(f.Done()) * 100 / float64(f.Discovered)
+}
+
 // A Filler represents filling Root. The Filelr is
 // not thread safe
 type Filler struct {
@@ -29,9 +84,10 @@ type Filler struct {
 
 	// TODO (kostyarin): organize them better way
 
-	wantq chan<- WCXO          // reference
-	fullq chan<- *Root         // reference
-	dropq chan<- DropRootError // reference
+	wantq chan<- WCXO            // reference
+	fullq chan<- *Root           // reference
+	dropq chan<- DropRootError   // reference
+	progq chan<- FillingProgress // reference (can be nil)
 
 	// own fields
 
@@ -42,12 +98,22 @@ type Filler struct {
 
 	gotq chan []byte // reply
 
+	prog FillingProgress // progress
+	size int             // total size of objects of the Root
+
+	quota Quota      // quota of feed of the Root
+	used  data.Space // space used by the feed before the Root
+
 	closeq chan struct{}
 	closeo sync.Once
 }
 
+// NewFiller creates Filler of given Root and starts filling. The progq
+// channel is optional and can be nil. If it's not nil, then the Filler
+// sends FillingProgress to the channel every time it receives an object
 func (c *Container) NewFiller(r *Root, wantq chan<- WCXO, fullq chan<- *Root,
-	dropq chan<- DropRootError, wg *sync.WaitGroup) (fl *Filler) {
+	dropq chan<- DropRootError, progq chan<- FillingProgress,
+	wg *sync.WaitGroup) (fl *Filler) {
 
 	c.Debugln(VerbosePin, "NewFiller", r.Short())
 
@@ -58,10 +124,15 @@ func (c *Container) NewFiller(r *Root, wantq chan<- WCXO, fullq chan<- *Root,
 	fl.wantq = wantq
 	fl.dropq = dropq
 	fl.fullq = fullq
+	fl.progq = progq
 
 	fl.r = r
 	fl.gotq = make(chan []byte, 1)
 
+	fl.prog.Feed = r.Pub
+	fl.prog.Seq = r.Seq
+	fl.prog.Hash = r.Hash
+
 	fl.closeq = make(chan struct{})
 
 	wg.Add(1)
@@ -70,10 +141,27 @@ func (c *Container) NewFiller(r *Root, wantq chan<- WCXO, fullq chan<- *Root,
 	return
 }
 
+// Root returns filling Root
 func (f *Filler) Root() *Root {
 	return f.r
 }
 
+// discovered n objects of the Root
+func (f *Filler) discovered(n int) {
+	f.prog.Discovered += n
+}
+
+// send progress to progq
+func (f *Filler) progress() {
+	if f.progq == nil {
+		return
+	}
+	select {
+	case f.progq <- f.prog:
+	case <-f.closeq:
+	}
+}
+
 func (f *Filler) drop(err error) {
 	f.c.Debugln(VerbosePin, "(*Filler).drop", f.r.Short(), err)
 
@@ -104,15 +192,38 @@ func (f *Filler) fill(wg *sync.WaitGroup) {
 	var val []byte
 	var ok bool
 	var err error
+	if f.quota, err = f.c.Quota(f.r.Pub); err != nil {
+		f.drop(err)
+		return
+	}
+	_, f.used = f.c.feedUsage(f.r.Pub)
 	if f.reg = f.c.Registry(f.r.Reg); f.reg == nil {
-		if val, ok = f.request(cipher.SHA256(f.r.Reg)); !ok {
+		f.discovered(1)
+		if val, ok, err = f.request(cipher.SHA256(f.r.Reg)); err != nil {
+			f.drop(err)
+			return
+		} else if !ok {
 			return // drop
 		}
 		if f.reg, err = DecodeRegistry(val); err != nil {
 			f.drop(err)
+			return
 		}
 		f.c.addRegistry(f.reg) // already saved by the request call
 	}
+	if !f.r.IsOwn() {
+		if err = f.fillCertificate(); err != nil || f.isClosed() {
+			// the Root can't be kept unverified
+			if derr := f.c.delNonFullRoot(f.r); derr != nil {
+				f.c.Printf("[ERR] can't remove unverified Root %s: %v",
+					f.r.Short(), derr)
+			}
+			if err != nil {
+				f.drop(err)
+			}
+			return
+		}
+	}
 	if len(f.r.Refs) == 0 {
 		f.full()
 		return
@@ -127,26 +238,89 @@ func (f *Filler) fill(wg *sync.WaitGroup) {
 	return
 }
 
-func (f *Filler) request(hash cipher.SHA256) (val []byte, ok bool) {
+func (f *Filler) isClosed() bool {
+	select {
+	case <-f.closeq:
+		return true
+	default:
+	}
+	return false
+}
+
+// fillCertificate gets certificate of
+// the Root and verifies the Root
+func (f *Filler) fillCertificate() (err error) {
+	if f.r.Cert == (cipher.SHA256{}) {
+		return ErrMissingCertificate
+	}
+	f.discovered(1)
+	var val []byte
+	var ok bool
+	if val, ok, err = f.get(f.r.Cert); err != nil || !ok {
+		return
+	}
+	var cert *Certificate
+	if cert, err = DecodeCertificate(val); err != nil {
+		return
+	}
+	return f.c.verifyDelegated(f.r, f.r.Cert, cert)
+}
+
+// limit checks limits of the Root adding
+// an object with given size
+func (f *Filler) limit(size int) (err error) {
+	conf := f.c.conf
+	if conf.MaxObjectSize > 0 && size > conf.MaxObjectSize {
+		return ErrObjectTooLarge
+	}
+	if conf.MaxRootObjects > 0 && f.prog.Done() > conf.MaxRootObjects {
+		return ErrTooManyObjects
+	}
+	if f.size += size; conf.MaxRootSize > 0 && f.size > conf.MaxRootSize {
+		return ErrRootTooLarge
+	}
+	// received objects are new for DB
+	if q := f.quota.MaxSpace; q > 0 && f.used+f.prog.Bytes > q {
+		return ErrSpaceQuota
+	}
+	return
+}
+
+// request an object from peer; the ok is false if the Filler closed
+func (f *Filler) request(hash cipher.SHA256) (val []byte, ok bool,
+	err error) {
+
 	select {
 	case f.wantq <- WCXO{hash, f.gotq}:
+		f.prog.Requested++
 	case <-f.closeq:
 		return
 	}
 	select {
 	case val = <-f.gotq:
 		ok = true
+		f.prog.Received++
+		f.prog.Bytes += data.Space(len(val))
+		if err = f.limit(len(val)); err != nil {
+			return
+		}
+		f.progress()
 	case <-f.closeq:
 	}
 	return
 }
 
-func (f *Filler) get(hash cipher.SHA256) (val []byte, ok bool) {
+// get an object from DB or request it from peer. An object
+// found in DB is shaded before, since it can be unreachable
+// for current cycle of incremental CleanUp (e.g. its parent
+// is not saved yet), but the Filler will not request it again
+func (f *Filler) get(hash cipher.SHA256) (val []byte, ok bool, err error) {
+	f.c.inc.shade(hash) // keep it from incremental CleanUp
 	if val = f.c.Get(hash); val != nil {
-		return val, true
+		f.prog.Local++
+		return val, true, f.limit(len(val))
 	}
-	val, ok = f.request(hash)
-	return
+	return f.request(hash)
 }
 
 // Close the Filler
@@ -180,9 +354,19 @@ func (f *Filler) fillRef(sch Schema, ref cipher.SHA256) (err error) {
 	if ref == (cipher.SHA256{}) {
 		return // blank (represents nil)
 	}
+	f.discovered(1)
+	return f.fillHash(sch, ref)
+}
+
+// fillHash is the same as fillRef, but the
+// object should be already discovered
+func (f *Filler) fillHash(sch Schema, ref cipher.SHA256) (err error) {
 	var val []byte
 	var ok bool
-	if val, ok = f.get(ref); !ok {
+	if val, ok, err = f.get(ref); err != nil || !ok {
+		return
+	}
+	if err = f.c.validate(sch, ref, val); err != nil {
 		return
 	}
 	return f.fillData(sch, val)
@@ -233,6 +417,9 @@ func (f *Filler) fillDataSlice(sch Schema, val []byte) (err error) {
 	if ln, err = getLength(val); err != nil {
 		return
 	}
+	if ln > len(val)-4 {
+		return ErrMalformedObject // an element can't be less then one byte
+	}
 	el := sch.Elem() // schema of element
 	if el == nil {
 		err = fmt.Errorf("nil schema of element of slice: %s", sch)
@@ -348,17 +535,48 @@ func (f *Filler) fillDataRefs(sch Schema, val []byte) (err error) {
 		return
 	}
 
+	f.discovered(1)
+
 	var ok bool
-	if val, ok = f.get(refs.Hash); !ok {
+	if val, ok, err = f.get(refs.Hash); err != nil || !ok {
 		return
 	}
 	var ers encodedRefs
 	if err = encoder.DeserializeRaw(val, &ers); err != nil {
 		return
 	}
+	if err = f.checkRefs(&ers); err != nil {
+		return
+	}
+	f.discovered(int(ers.Length)) // estimate
 	return f.fillRefsNode(ers.Depth, ers.Nested, el)
 }
 
+// checkRefs checks limits of Refs using its root node
+func (f *Filler) checkRefs(ers *encodedRefs) (err error) {
+	conf := f.c.conf
+	if conf.MaxRefsDepth > 0 && int64(ers.Depth) > int64(conf.MaxRefsDepth) {
+		return ErrRefsTooDeep
+	}
+	if conf.MaxRefsLength > 0 &&
+		int64(ers.Length) > int64(conf.MaxRefsLength) {
+
+		return ErrRefsTooLong
+	}
+	if ers.Degree < 2 && ers.Length > 0 {
+		return ErrMalformedRefs
+	}
+	return checkRefsNode(ers)
+}
+
+// checkRefsNode checks degree of a node of Refs
+func checkRefsNode(ers *encodedRefs) (err error) {
+	if len(ers.Nested) > int(ers.Degree) {
+		return ErrMalformedRefs
+	}
+	return
+}
+
 func (f *Filler) fillRefsNode(depth uint32, hs []cipher.SHA256,
 	sch Schema) (err error) {
 
@@ -367,7 +585,11 @@ func (f *Filler) fillRefsNode(depth uint32, hs []cipher.SHA256,
 
 	if depth == 0 { // the leaf
 		for _, hash := range hs {
-			if err = f.fillRef(sch, hash); err != nil {
+			if hash == (cipher.SHA256{}) {
+				continue
+			}
+			// already discovered using length of the Refs
+			if err = f.fillHash(sch, hash); err != nil {
 				return
 			}
 		}
@@ -378,14 +600,19 @@ func (f *Filler) fillRefsNode(depth uint32, hs []cipher.SHA256,
 		if hash == (cipher.SHA256{}) {
 			continue
 		}
-		val, ok := f.get(hash)
-		if !ok {
+		f.discovered(1)
+		var val []byte
+		var ok bool
+		if val, ok, err = f.get(hash); err != nil || !ok {
 			return
 		}
 		var ers encodedRefs
 		if err = encoder.DeserializeRaw(val, &ers); err != nil {
 			return
 		}
+		if err = checkRefsNode(&ers); err != nil {
+			return
+		}
 		if err = f.fillRefsNode(depth-1, ers.Nested, sch); err != nil {
 			return
 		}
@@ -5,7 +5,7 @@ import (
 	"testing"
 
 	"github.com/skycoin/skycoin/src/cipher"
-	//"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
 
 	"github.com/skycoin/cxo/data"
 	"github.com/skycoin/cxo/node/log"
@@ -120,7 +120,7 @@ func Test_filling(t *testing.T) {
 	dropq := make(chan DropRootError)
 	wg := new(sync.WaitGroup)
 
-	filler := c2.NewFiller(r2, wantq, fullq, dropq, wg)
+	filler := c2.NewFiller(r2, wantq, fullq, dropq, nil, wg)
 
 Loop1:
 	for {
@@ -197,7 +197,7 @@ Loop1:
 
 	// fill fill fill
 
-	filler = c2.NewFiller(r2, wantq, fullq, dropq, wg)
+	filler = c2.NewFiller(r2, wantq, fullq, dropq, nil, wg)
 
 Loop2:
 	for {
@@ -226,3 +226,166 @@ Loop2:
 	//
 
 }
+
+func TestFiller_checkRefs(t *testing.T) {
+
+	c := getCont()
+	defer c.Close()
+
+	c.conf.MaxRefsDepth = 2
+	c.conf.MaxRefsLength = 10
+
+	f := &Filler{c: c}
+
+	for _, tc := range []struct {
+		name string
+		ers  encodedRefs
+		err  error
+	}{
+		{"valid", encodedRefs{Depth: 1, Degree: 2, Length: 4}, nil},
+		{"too deep", encodedRefs{Depth: 3, Degree: 2, Length: 4},
+			ErrRefsTooDeep},
+		{"too long", encodedRefs{Depth: 1, Degree: 2, Length: 11},
+			ErrRefsTooLong},
+		{"degree", encodedRefs{Depth: 1, Degree: 1, Length: 4},
+			ErrMalformedRefs},
+		{"nested", encodedRefs{Depth: 0, Degree: 2, Length: 3,
+			Nested: make([]cipher.SHA256, 3)}, ErrMalformedRefs},
+	} {
+		if err := f.checkRefs(&tc.ers); err != tc.err {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.err, err)
+		}
+	}
+
+}
+
+func TestFiller_limit(t *testing.T) {
+
+	c := getCont()
+	defer c.Close()
+
+	c.conf.MaxObjectSize = 10
+	c.conf.MaxRootObjects = 2
+	c.conf.MaxRootSize = 15
+
+	f := &Filler{c: c}
+
+	if err := f.limit(11); err != ErrObjectTooLarge {
+		t.Error("wrong error:", err)
+	}
+
+	f = &Filler{c: c}
+	f.prog.Local = 1
+	if err := f.limit(8); err != nil {
+		t.Error(err)
+	}
+	f.prog.Local = 2
+	if err := f.limit(8); err != ErrRootTooLarge {
+		t.Error("wrong error:", err)
+	}
+	f.prog.Local = 3
+	if err := f.limit(1); err != ErrTooManyObjects {
+		t.Error("wrong error:", err)
+	}
+
+}
+
+func TestFiller_progress(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+	conf.MerkleDegree = 2 // deep Refs
+
+	src := NewContainer(data.NewMemoryDB(), conf)
+	defer src.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+	if err := src.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := src.NewRoot(pk, sk, 0, src.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	leader := &User{Name: "Alice", Age: 21}
+	pack.Append(&Group{
+		Name:   "Mates",
+		Leader: pack.Ref(leader),
+		Members: pack.Refs(
+			&User{Name: "Eva", Age: 20},
+			&User{Name: "Ammy", Age: 19},
+			&User{Name: "Kate", Age: 22},
+		),
+	})
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	if err = c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+	// the leader is already in local DB
+	lr := pack.Ref(leader)
+	if err = c.Set(lr.Hash, encoder.Serialize(leader)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.AddRoot(pk, pack.Root().Pack())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantq := make(chan WCXO)
+	fullq := make(chan *Root, 1)
+	dropq := make(chan DropRootError, 1)
+	progq := make(chan FillingProgress)
+
+	var wg sync.WaitGroup
+	fl := c.NewFiller(r, wantq, fullq, dropq, progq, &wg)
+	defer wg.Wait()
+	defer fl.Close()
+
+	var (
+		sent int             // sent objects
+		last FillingProgress // last reported progress
+	)
+
+	for {
+		select {
+		case wc := <-wantq:
+			val := src.Get(wc.Hash)
+			if val == nil {
+				t.Fatal("request of unknown object:", wc.Hash.Hex()[:7])
+			}
+			wc.GotQ <- val
+			sent++
+		case fp := <-progq:
+			if fp.Feed != pk || fp.Seq != r.Seq || fp.Hash != r.Hash {
+				t.Fatalf("progress of another Root: %+v", fp)
+			}
+			if fp.Done() < last.Done() || fp.Discovered < last.Discovered {
+				t.Errorf("progress decreased: %+v -> %+v", last, fp)
+			}
+			last = fp
+		case <-fullq:
+			t.Logf("progress: %+v", last)
+			if last.Received != sent || last.Requested != sent {
+				t.Errorf("wrong progress, %d objects sent: %+v", sent, last)
+			}
+			if last.Local != 1 {
+				t.Error("wrong number of local objects:", last.Local)
+			}
+			if last.Done() != last.Discovered || last.Percent() != 100 {
+				t.Errorf("not finished progress: %+v", last)
+			}
+			return
+		case drop := <-dropq:
+			t.Fatal(drop.Err)
+		}
+	}
+
+}
@@ -0,0 +1,158 @@
+package skyobject
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// A FeedGarbage represents Root objects of a feed and
+// objects of them that CleanUp would remove
+type FeedGarbage struct {
+	Roots   []uint64   // seq numbers of Root objects
+	Objects int        // objects of the Root objects
+	Space   data.Space // space taken by the objects
+}
+
+// A CleanUpPlan represents what CleanUp would remove
+type CleanUpPlan struct {
+	// Feeds is garbage of feeds, it's nil if there is nothing
+	// to remove. An object can be used by many feeds, thus it
+	// can be counted by many feeds
+	Feeds map[cipher.PubKey]FeedGarbage
+
+	Objects []cipher.SHA256 // all objects to be removed
+	Space   data.Space      // total space taken by the Objects
+
+	// Unreachable objects are not reachable from
+	// any Root (they are counted in the Objects)
+	Unreachable      int
+	UnreachableSpace data.Space
+
+	// Registries are unpacked registries to be removed
+	Registries []RegistryRef
+}
+
+// CleanUpPlan returns what CleanUp with given keepRoots would remove.
+// It doesn't remove anything. The plan is valid until DB changes
+func (c *Container) CleanUpPlan(keepRoots bool) (plan *CleanUpPlan,
+	err error) {
+
+	c.Debugln(VerbosePin, "CleanUpPlan, keep roots:", keepRoots)
+
+	c.cleanmx.Lock()
+	defer c.cleanmx.Unlock()
+
+	coll := make(map[cipher.SHA256]int)
+	collRoots := make(map[cipher.PubKey]uint64)
+
+	var sp space
+	sp.init()
+
+	plan = new(CleanUpPlan)
+
+	err = c.DB().View(func(tx data.Tv) (err error) {
+
+		feeds := tx.Feeds()
+		objs := tx.Objects()
+
+		err = feeds.Range(func(pk cipher.PubKey) error {
+			return c.cleanUpCollectFeed(pk, feeds.Roots(pk), objs, coll,
+				collRoots, &sp, keepRoots)
+		})
+		if err != nil {
+			return
+		}
+
+		if cr := c.CoreRegistry(); cr != nil {
+			coll[cipher.SHA256(cr.Reference())] = 1
+		}
+
+		// the same rule as CleanUp and incremental CleanUp
+		// use: all objects that are not collected are removed
+
+		reachable := make(map[cipher.SHA256]struct{})
+
+		for pk, before := range collRoots {
+			fg, err := c.feedGarbage(pk, feeds.Roots(pk), objs, before,
+				coll, reachable)
+			if err != nil {
+				return err
+			}
+			if len(fg.Roots) == 0 {
+				continue
+			}
+			if plan.Feeds == nil {
+				plan.Feeds = make(map[cipher.PubKey]FeedGarbage)
+			}
+			plan.Feeds[pk] = fg
+		}
+
+		return objs.Range(func(key cipher.SHA256, val []byte) (_ error) {
+			if _, ok := coll[key]; ok {
+				return
+			}
+			plan.Objects = append(plan.Objects, key)
+			plan.Space += data.Space(len(val))
+			if _, ok := reachable[key]; !ok {
+				plan.Unreachable++
+				plan.UnreachableSpace += data.Space(len(val))
+			}
+			return
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Registries = c.unusedRegistries(coll)
+	return
+}
+
+// feedGarbage walks Root objects of a feed before given seq number
+// except pinned, and counts objects of them that are not collected.
+// The objects are added to given reachable map
+func (c *Container) feedGarbage(pk cipher.PubKey, roots data.ViewRoots,
+	objs data.ViewObjects, before uint64, coll map[cipher.SHA256]int,
+	reachable map[cipher.SHA256]struct{}) (fg FeedGarbage, err error) {
+
+	seen := make(map[cipher.SHA256]struct{})
+
+	fn := func(hash cipher.SHA256) (deeper bool, _ error) {
+		if _, ok := coll[hash]; ok {
+			return // will be kept with all subtree
+		}
+		if _, ok := seen[hash]; ok {
+			return
+		}
+		seen[hash] = struct{}{}
+		val := objs.Get(hash)
+		if val == nil {
+			return // missing object
+		}
+		reachable[hash] = struct{}{}
+		fg.Objects++
+		fg.Space += data.Space(len(val))
+		return true, nil
+	}
+
+	err = roots.Range(func(rp *data.RootPack) (err error) {
+		if rp.Seq >= before {
+			return data.ErrStopRange
+		}
+		if roots.Meta(rp.Seq).IsPinned() {
+			return
+		}
+		fg.Roots = append(fg.Roots, rp.Seq)
+
+		var r *Root
+		if r, err = c.unpackRoot(pk, rp); err != nil {
+			return
+		}
+		if kerr := c.knowsAbout(r, objs, fn); kerr != nil {
+			c.Printf("[ERR] knowsAbout of %s error: %v", r.Short(), kerr)
+		}
+		return
+	})
+	return
+}
@@ -0,0 +1,157 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestContainer_CleanUpPlan(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := []User{{Name: "Alice"}, {Name: "Eva"}, {Name: "Ammy"}}
+	for i := range users {
+		pack.Clear()
+		pack.Append(&users[i])
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orphan := []byte("orphan")
+	if err = c.Set(cipher.SumSHA256(orphan), orphan); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := c.CleanUpPlan(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fg, ok := plan.Feeds[pk]
+	if !ok {
+		t.Fatal("missing feed")
+	}
+	if len(fg.Roots) != 2 || fg.Roots[0] != 0 || fg.Roots[1] != 1 {
+		t.Error("wrong Root objects:", fg.Roots)
+	}
+	if fg.Objects != 2 || fg.Space == 0 {
+		t.Errorf("wrong garbage of feed: %+v", fg)
+	}
+	if len(plan.Objects) != 3 || plan.Unreachable != 1 ||
+		plan.UnreachableSpace != data.Space(len(orphan)) ||
+		plan.Space != fg.Space+plan.UnreachableSpace {
+
+		t.Errorf("wrong plan: %+v", plan)
+	}
+
+	// nothing removed
+	for _, hash := range plan.Objects {
+		if c.Get(hash) == nil {
+			t.Error("object removed by plan")
+		}
+	}
+
+	if err = c.CleanUp(false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hash := range plan.Objects {
+		if c.Get(hash) != nil {
+			t.Error("planned object is not removed")
+		}
+	}
+	ammy := cipher.SumSHA256(encoder.Serialize(users[2]))
+	if c.Get(ammy) == nil {
+		t.Error("removed object is not in the plan")
+	}
+
+	if plan, err = c.CleanUpPlan(false); err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Feeds) != 0 || len(plan.Objects) != 0 {
+		t.Errorf("wrong plan after CleanUp: %+v", plan)
+	}
+
+}
+
+func TestContainer_CleanUpPlan_noRoots(t *testing.T) {
+
+	orphan := []byte("orphan")
+	hash := cipher.SumSHA256(orphan)
+
+	for _, incremental := range []bool{false, true} {
+
+		conf := NewConfig()
+		conf.Registry = getRegisty()
+		conf.CleanUp = 0
+		if incremental {
+			conf.CleanUpObjects = 1
+		}
+
+		c := NewContainer(data.NewMemoryDB(), conf)
+
+		if err := c.Set(hash, orphan); err != nil {
+			t.Fatal(err)
+		}
+
+		plan, err := c.CleanUpPlan(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var planned bool
+		for _, ph := range plan.Objects {
+			if ph == hash {
+				planned = true
+			}
+		}
+		if !planned || plan.Unreachable == 0 {
+			t.Errorf("orphan is not in the plan (incremental %t): %+v",
+				incremental, plan)
+		}
+
+		if incremental {
+			for done := false; !done; {
+				if done, err = c.CleanUpStep(false); err != nil {
+					t.Fatal(err)
+				}
+			}
+		} else if err = c.CleanUp(false); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, ph := range plan.Objects {
+			if c.Get(ph) != nil {
+				t.Errorf("planned object is not removed (incremental %t)",
+					incremental)
+			}
+		}
+		if c.Get(hash) != nil {
+			t.Errorf("orphan is not removed (incremental %t)", incremental)
+		}
+
+		c.Close()
+	}
+
+}
@@ -36,11 +36,20 @@ func (c *Container) knowsAbout(r *Root, g getter,
 		}
 		return
 	}
+	// 2) certificate
+	if r.Cert != (cipher.SHA256{}) {
+		if _, err = fn(r.Cert); err != nil {
+			if err == ErrStopRange {
+				err = nil
+			}
+			return
+		}
+	}
 	var reg *Registry
 	if reg = c.Registry(r.Reg); reg == nil {
 		return // return nil (no "missing Registry" errors)
 	}
-	// 2) refs ([]Dynamic)
+	// 3) refs ([]Dynamic)
 	var kn knowsAbout
 	kn.fn = fn
 	kn.g = g
@@ -57,6 +66,9 @@ type knowsAbout struct {
 	fn  knowsAboutFunc
 	g   getter
 	reg *Registry
+
+	// optional, validate objects
+	vfn func(sch Schema, hash cipher.SHA256, val []byte) error
 }
 
 func (k *knowsAbout) Dynamic(dr Dynamic) (err error) {
@@ -81,13 +93,18 @@ func (k *knowsAbout) Dynamic(dr Dynamic) (err error) {
 }
 
 func (k *knowsAbout) Hash(sch Schema, hash cipher.SHA256) (err error) {
-	if !sch.HasReferences() {
+	if !sch.HasReferences() && k.vfn == nil {
 		return // skip (no references)
 	}
 	var val []byte
 	if val = k.g.Get(hash); val == nil {
 		return // skip (not found)
 	}
+	if k.vfn != nil {
+		if err = k.vfn(sch, hash, val); err != nil {
+			return
+		}
+	}
 	return k.Data(sch, val)
 }
 
@@ -0,0 +1,132 @@
+package skyobject
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// quota errors
+var (
+	ErrRootsQuota = errors.New("quota of Root objects of feed exceeded")
+	ErrSpaceQuota = errors.New("space quota of feed exceeded")
+)
+
+// IsQuotaError returns true if given error is one of quota errors
+func IsQuotaError(err error) bool {
+	return err == ErrRootsQuota || err == ErrSpaceQuota
+}
+
+// name of end-user bucket of DB that keeps quotas of feeds
+var quotasBucket = []byte("skyobject.quotas")
+
+// A Quota represents storage limits of a feed. Zero
+// value of a field means that the field is not limited.
+//
+// The MaxRoots is a hard stop. Old Root objects are never
+// removed to make room for new ones; a feed that reached
+// the quota doesn't accept Root objects until CleanUp
+// removes old ones (not pinned Root objects older than
+// last full one) or until the quota is raised
+type Quota struct {
+	MaxRoots int        // max amount of Root objects of feed
+	MaxSpace data.Space // max space taken by objects of feed
+}
+
+// IsZero reports whether the Quota doesn't limit anything
+func (q Quota) IsZero() bool {
+	return q.MaxRoots == 0 && q.MaxSpace == 0
+}
+
+// String implements fmt.Stringer interface
+func (q Quota) String() string {
+	return fmt.Sprintf("{roots: %d, space: %s}", q.MaxRoots,
+		q.MaxSpace.String())
+}
+
+func (q Quota) encode() (b []byte) {
+	b = make([]byte, 16)
+	binary.BigEndian.PutUint64(b, uint64(q.MaxRoots))
+	binary.BigEndian.PutUint64(b[8:], uint64(q.MaxSpace))
+	return
+}
+
+func decodeQuota(b []byte) (q Quota, err error) {
+	if len(b) != 16 {
+		err = fmt.Errorf("malformed quota, length %d", len(b))
+		return
+	}
+	q.MaxRoots = int(binary.BigEndian.Uint64(b))
+	q.MaxSpace = data.Space(binary.BigEndian.Uint64(b[8:]))
+	return
+}
+
+// SetQuota sets Quota of given feed and keeps it in DB. Use
+// zero Quota to remove quota of the feed. In this case
+// default Quota (see Config) will be used for the feed
+func (c *Container) SetQuota(pk cipher.PubKey, q Quota) (err error) {
+	if q.MaxRoots < 0 || q.MaxSpace < 0 {
+		return fmt.Errorf("negative quota %s", q.String())
+	}
+	return c.DB().Update(func(tx data.Tu) error {
+		bk := tx.Bucket(quotasBucket)
+		if q.IsZero() {
+			return bk.Del(pk[:])
+		}
+		return bk.Set(pk[:], q.encode())
+	})
+}
+
+// Quota of given feed. It returns default Quota
+// if the feed doesn't have its own
+func (c *Container) Quota(pk cipher.PubKey) (q Quota, err error) {
+	q = c.conf.Quota
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		if val := tx.Bucket(quotasBucket).Get(pk[:]); val != nil {
+			q, err = decodeQuota(val)
+		}
+		return
+	})
+	return
+}
+
+// feedUsage returns amount of Root objects and
+// space taken by objects of given feed
+func (c *Container) feedUsage(pk cipher.PubKey) (roots int,
+	used data.Space) {
+
+	c.DB().View(func(tx data.Tv) (_ error) {
+		if rs := tx.Feeds().Roots(pk); rs != nil {
+			roots = rs.Stat().Roots
+		}
+		return
+	})
+
+	fs, err := c.feedSpace(pk)
+	if err != nil {
+		c.Printf("[ERR] reading space of %s: %v", pk.Hex()[:7], err)
+	}
+	used = fs.Total()
+	return
+}
+
+// checkQuota checks quota of given feed
+// before adding new Root to the feed
+func (c *Container) checkQuota(pk cipher.PubKey) (err error) {
+	var q Quota
+	if q, err = c.Quota(pk); err != nil || q.IsZero() {
+		return
+	}
+	roots, used := c.feedUsage(pk)
+	if q.MaxRoots > 0 && roots >= q.MaxRoots {
+		return ErrRootsQuota
+	}
+	if q.MaxSpace > 0 && used >= q.MaxSpace {
+		return ErrSpaceQuota
+	}
+	return
+}
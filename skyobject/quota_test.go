@@ -0,0 +1,91 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestContainer_SetQuota(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.Quota = Quota{MaxSpace: 1 << 20}
+
+	c1 := NewContainer(data.NewMemoryDB(), conf)
+	defer c1.Close()
+
+	c2 := NewContainer(data.NewMemoryDB(), conf)
+	defer c2.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	for _, c := range []*Container{c1, c2} {
+		if err := c.AddFeed(pk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		if q, err := c2.Quota(pk); err != nil {
+			t.Fatal(err)
+		} else if q != conf.Quota {
+			t.Error("wrong default quota:", q)
+		}
+	})
+
+	if err := c2.SetQuota(pk, Quota{MaxRoots: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("persistent", func(t *testing.T) {
+		if q, err := c2.Quota(pk); err != nil {
+			t.Fatal(err)
+		} else if q.MaxRoots != 1 || q.MaxSpace != 0 {
+			t.Error("wrong quota:", q)
+		}
+	})
+
+	pack, err := c1.NewRoot(pk, sk, 0, c1.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rps []*data.RootPack
+	for _, name := range []string{"Alice", "Eva"} {
+		pack.Append(&User{Name: name})
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+		rps = append(rps, pack.Root().Pack())
+	}
+
+	t.Run("roots", func(t *testing.T) {
+		if _, err := c2.AddRoot(pk, rps[0]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c2.AddRoot(pk, rps[0]); err != data.ErrRootAlreadyExists {
+			t.Error("wrong error:", err)
+		}
+		if _, err := c2.AddRoot(pk, rps[1]); err != ErrRootsQuota {
+			t.Error("wrong error:", err)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		if err := c2.SetQuota(pk, Quota{}); err != nil {
+			t.Fatal(err)
+		}
+		if q, err := c2.Quota(pk); err != nil {
+			t.Fatal(err)
+		} else if q != conf.Quota {
+			t.Error("wrong quota:", q)
+		}
+		if _, err := c2.AddRoot(pk, rps[1]); err != nil {
+			t.Error(err)
+		}
+	})
+
+}
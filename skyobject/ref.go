@@ -31,6 +31,15 @@ func (r RegistryRef) Short() string {
 	return r.String()[:7]
 }
 
+// RegistryRefFromHex parses hexadecimal encoded RegistryRef
+func RegistryRefFromHex(s string) (rr RegistryRef, err error) {
+	var hash cipher.SHA256
+	if hash, err = cipher.SHA256FromHex(s); err != nil {
+		return
+	}
+	return RegistryRef(hash), nil
+}
+
 //
 // SchemaRef
 //
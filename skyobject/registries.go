@@ -0,0 +1,180 @@
+package skyobject
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// registry related errors
+var (
+	ErrNoSuchRegistry = errors.New("no such registry")
+	ErrRegistryInUse  = errors.New("registry is in use")
+)
+
+// A RegistryInfo represents a registry
+// and Root objects that use it
+type RegistryInfo struct {
+	Ref    RegistryRef
+	Core   bool            // core registry of the Container
+	Loaded bool            // unpacked in memory
+	Saved  bool            // saved in DB
+	Feeds  []cipher.PubKey // feeds of Root objects that use it
+	Roots  int             // Root objects that use it
+}
+
+// IsUsed returns true if the registry is used by a Root
+// or it's core registry of the Container
+func (r *RegistryInfo) IsUsed() bool {
+	return r.Core || r.Roots > 0
+}
+
+// Registries returns information about all registries of the
+// Container: unpacked registries and registries of Root objects.
+// The list is ordered by RegistryRef
+func (c *Container) Registries() (ris []RegistryInfo, err error) {
+	c.Debugln(VerbosePin, "Registries")
+
+	idx := make(map[RegistryRef]*RegistryInfo)
+
+	info := func(rr RegistryRef) (ri *RegistryInfo) {
+		if ri = idx[rr]; ri == nil {
+			ri = &RegistryInfo{Ref: rr}
+			idx[rr] = ri
+		}
+		return
+	}
+
+	c.rmx.RLock()
+	for rr := range c.regs {
+		info(rr).Loaded = true
+	}
+	c.rmx.RUnlock()
+
+	if cr := c.CoreRegistry(); cr != nil {
+		info(cr.Reference()).Core = true
+	}
+
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		feeds := tx.Feeds()
+		err = feeds.Range(func(pk cipher.PubKey) error {
+			return feeds.Roots(pk).Range(func(rp *data.RootPack) (err error) {
+				var r *Root
+				if r, err = c.unpackRoot(pk, rp); err != nil {
+					return
+				}
+				ri := info(r.Reg)
+				if ri.Roots++; len(ri.Feeds) == 0 ||
+					ri.Feeds[len(ri.Feeds)-1] != pk {
+
+					ri.Feeds = append(ri.Feeds, pk)
+				}
+				return
+			})
+		})
+		if err != nil {
+			return
+		}
+		objs := tx.Objects()
+		for rr, ri := range idx {
+			ri.Saved = objs.IsExist(cipher.SHA256(rr))
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ris = make([]RegistryInfo, 0, len(idx))
+	for _, ri := range idx {
+		ris = append(ris, *ri)
+	}
+	sort.Slice(ris, func(i, j int) bool {
+		return bytes.Compare(ris[i].Ref[:], ris[j].Ref[:]) < 0
+	})
+	return
+}
+
+// FindRegistry returns unpacked Registry or Registry
+// decoded from DB. It returns ErrNoSuchRegistry if
+// there is no such Registry
+func (c *Container) FindRegistry(rr RegistryRef) (reg *Registry, err error) {
+	c.Debugln(VerbosePin, "FindRegistry", rr.Short())
+
+	if reg = c.Registry(rr); reg != nil {
+		return
+	}
+	var val []byte
+	if val = c.Get(cipher.SHA256(rr)); val == nil {
+		return nil, ErrNoSuchRegistry
+	}
+	return DecodeRegistry(val)
+}
+
+// DelRegistry removes Registry from memory and DB. It returns
+// ErrRegistryInUse if the Registry is core registry or it's used
+// by a Root. Unused registries removed by CleanUp too. Don't remove
+// a Registry that is used by a Pack, since Root objects of the Pack
+// will refer to missing Registry
+func (c *Container) DelRegistry(rr RegistryRef) (err error) {
+	c.Debugln(VerbosePin, "DelRegistry", rr.Short())
+
+	if cr := c.CoreRegistry(); cr != nil && cr.Reference() == rr {
+		return ErrRegistryInUse
+	}
+
+	// don't perform simultaneously with CleanUp
+	c.cleanmx.Lock()
+	defer c.cleanmx.Unlock()
+
+	err = c.DB().Update(func(tx data.Tu) (err error) {
+		feeds := tx.Feeds()
+		err = feeds.Range(func(pk cipher.PubKey) error {
+			return feeds.Roots(pk).Range(func(rp *data.RootPack) (err error) {
+				var r *Root
+				if r, err = c.unpackRoot(pk, rp); err != nil {
+					return
+				}
+				if r.Reg == rr {
+					return ErrRegistryInUse
+				}
+				return
+			})
+		})
+		if err != nil {
+			return
+		}
+		objs := tx.Objects()
+		if !objs.IsExist(cipher.SHA256(rr)) && c.Registry(rr) == nil {
+			return ErrNoSuchRegistry
+		}
+		return objs.Del(cipher.SHA256(rr))
+	})
+	if err != nil {
+		return
+	}
+
+	c.cleanUpRemoveRegistries(func(hash cipher.SHA256) bool {
+		return hash != cipher.SHA256(rr)
+	})
+	return
+}
+
+// unpacked registries that are not collected
+func (c *Container) unusedRegistries(
+	coll map[cipher.SHA256]int) (unused []RegistryRef) {
+
+	c.rmx.RLock()
+	defer c.rmx.RUnlock()
+
+	for rr := range c.regs {
+		if _, ok := coll[cipher.SHA256(rr)]; !ok {
+			unused = append(unused, rr)
+		}
+	}
+	return
+}
@@ -0,0 +1,106 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestContainer_Registries(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack.Append(&User{Name: "Alice"})
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	users := NewRegistry(func(r *Reg) {
+		r.Register("cxo.User", User{})
+	})
+	if err = c.AddRegistry(users); err != nil {
+		t.Fatal(err)
+	}
+
+	ris, err := c.Registries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ris) != 2 {
+		t.Fatal("wrong number of registries:", len(ris))
+	}
+	for _, ri := range ris {
+		if !ri.Loaded || !ri.Saved {
+			t.Errorf("wrong registry info: %+v", ri)
+		}
+		switch ri.Ref {
+		case c.CoreRegistry().Reference():
+			if !ri.Core || ri.Roots != 1 || len(ri.Feeds) != 1 ||
+				ri.Feeds[0] != pk {
+
+				t.Errorf("wrong core registry info: %+v", ri)
+			}
+		case users.Reference():
+			if ri.IsUsed() {
+				t.Errorf("wrong registry info: %+v", ri)
+			}
+		default:
+			t.Error("unexpected registry:", ri.Ref.Short())
+		}
+	}
+
+	if reg, err := c.FindRegistry(users.Reference()); err != nil {
+		t.Error(err)
+	} else if ss := reg.Schemas(); len(ss) != 1 ||
+		ss[0].Name() != "cxo.User" {
+
+		t.Error("wrong schemas:", ss)
+	}
+
+	err = c.DelRegistry(c.CoreRegistry().Reference())
+	if err != ErrRegistryInUse {
+		t.Error("unexpected error:", err)
+	}
+
+	// CleanUp removes unused registries even if it keeps Root objects
+	if err = c.CleanUp(true); err != nil {
+		t.Fatal(err)
+	}
+	if c.Registry(users.Reference()) != nil {
+		t.Error("unused registry is not removed from memory")
+	}
+	if c.Get(cipher.SHA256(users.Reference())) != nil {
+		t.Error("unused registry is not removed from DB")
+	}
+
+	if err = c.AddRegistry(users); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.DelRegistry(users.Reference()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.FindRegistry(users.Reference()); err != ErrNoSuchRegistry {
+		t.Error("unexpected error:", err)
+	}
+	if err = c.DelRegistry(users.Reference()); err != ErrNoSuchRegistry {
+		t.Error("unexpected error:", err)
+	}
+
+}
@@ -306,6 +306,23 @@ func (r *Registry) SchemaByName(name string) (Schema, error) {
 	return r.schemaByName(name)
 }
 
+// Schemas returns all registered schemas ordered by name
+func (r *Registry) Schemas() (ss []Schema) {
+	if len(r.reg) == 0 {
+		return
+	}
+	names := make([]string, 0, len(r.reg))
+	for name := range r.reg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ss = make([]Schema, 0, len(names))
+	for _, name := range names {
+		ss = append(ss, r.reg[name])
+	}
+	return
+}
+
 // Types returns Types of the Registry. If this regsitry creaded using
 // DecodeRegistry (received from network) then result will not
 // be valid (empty maps). The Types used to pack/unpack CX objects
@@ -15,7 +15,15 @@ var (
 	ErrNoSuchFeed = errors.New("no such feed")
 )
 
-// A Root represents root object of a feed
+// A Root represents root object of a feed.
+//
+// Root objects encoded before delegation and succession fields
+// were added (legacy Root objects) are decoded and encoded using
+// old layout. Since hash and signature of a Root are hash and
+// signature of its encoded form, legacy Root objects are never
+// re-encoded using current layout. They are treated as Root
+// objects signed by owner, without delegation and succession.
+// All new Root objects use current layout
 type Root struct {
 	Refs []Dynamic // main branches
 
@@ -25,17 +33,67 @@ type Root struct {
 	Seq  uint64 // seq number
 	Time int64  // timestamp (unix nano)
 
+	// delegation
+
+	Signer  cipher.PubKey   // writer (empty if signed by owner of the feed)
+	Cert    cipher.SHA256   // certificate of the writer (empty for owner)
+	Revoked []cipher.SHA256 // certificates revoked by owner of the feed
+
+	// succession (key rotation)
+
+	Successor   cipher.PubKey // new key of the feed (succession Root)
+	Predecessor cipher.PubKey // previous key of the feed (first Root)
+	Origin      cipher.SHA256 // hash of succession Root of Predecessor
+
 	Sig cipher.Sig `enc:"-"` // signature (not part of the Root)
 
 	Hash cipher.SHA256 `enc:"-"` // hash (not part of the Root)
 	Prev cipher.SHA256 // hash of previous root
+
+	legacy bool `enc:"-"` // old layout
+}
+
+// legacyRoot is layout of Root objects encoded
+// before delegation and succession
+type legacyRoot struct {
+	Refs []Dynamic
+	Reg  RegistryRef
+	Pub  cipher.PubKey
+	Seq  uint64
+	Time int64
+	Prev cipher.SHA256
 }
 
+// IsLegacy returns true if the Root encoded using
+// layout of Root objects before delegation and
+// succession (see Root)
+func (r *Root) IsLegacy() bool {
+	return r.legacy
+}
+
+// IsOwn returns true if the Root
+// signed by owner of the feed
+func (r *Root) IsOwn() bool {
+	return r.Signer == (cipher.PubKey{}) || r.Signer == r.Pub
+}
+
+// Encode the Root. Legacy Root objects
+// are encoded using old layout
 func (r *Root) Encode() []byte {
+	if r.legacy {
+		return encoder.Serialize(&legacyRoot{
+			Refs: r.Refs,
+			Reg:  r.Reg,
+			Pub:  r.Pub,
+			Seq:  r.Seq,
+			Time: r.Time,
+			Prev: r.Prev,
+		})
+	}
 	return encoder.Serialize(r)
 }
 
-// Pack of the Root (IsFull field always false)
+// Pack of the Root
 func (r *Root) Pack() (rp *data.RootPack) {
 	rp = new(data.RootPack)
 	rp.Root = r.Encode()
@@ -64,7 +122,7 @@ func (c *Container) LastFullPack(pk cipher.PubKey) (rp *data.RootPack,
 			return fmt.Errorf("no such feed %s", pk.Hex()[:7])
 		}
 		return roots.Reverse(func(rpd *data.RootPack) (_ error) {
-			if rpd.IsFull {
+			if roots.Meta(rpd.Seq).IsFull {
 				rp = rpd
 				return data.ErrStopRange // data.ErrStopRange
 			}
@@ -108,11 +166,28 @@ func (c *Container) Last(pk cipher.PubKey) (r *Root, err error) {
 	return c.unpackRoot(pk, rp)
 }
 
+// DecodeRoot decodes encoded Root, legacy Root too
 func DecodeRoot(val []byte) (r *Root, err error) {
 	r = new(Root)
-	if err = encoder.DeserializeRaw(val, r); err != nil {
+	if err = encoder.DeserializeRaw(val, r); err == nil {
+		return
+	}
+	// legacy Root is shorter then current
+	var lr legacyRoot
+	if encoder.DeserializeRaw(val, &lr) != nil {
 		r = nil
+		return // error of current layout
+	}
+	r = &Root{
+		Refs:   lr.Refs,
+		Reg:    lr.Reg,
+		Pub:    lr.Pub,
+		Seq:    lr.Seq,
+		Time:   lr.Time,
+		Prev:   lr.Prev,
+		legacy: true,
 	}
+	err = nil
 	return
 }
 
@@ -131,22 +206,183 @@ func (r *Root) String() string {
 		r.Hash.Hex()[:7])
 }
 
-// AddRoot to container. The method sets rp.IsFull to false
+// AddRoot to container. The Root will not be full. The method
+// checks hash and signature of the Root. If the Root signed by
+// a writer (not owner of the feed) and certificate of the writer
+// is not found in DB, then signature will be checked by Filler;
+// the Filler removes the Root if the check fails
 func (c *Container) AddRoot(pk cipher.PubKey, rp *data.RootPack) (r *Root,
 	err error) {
 
-	rp.IsFull = false
+	return c.AddRootFrom(pk, rp, "")
+}
+
+// AddRootFrom is the same as AddRoot, but it keeps address
+// of peer the Root received from in local metadata of the Root
+func (c *Container) AddRootFrom(pk cipher.PubKey, rp *data.RootPack,
+	peer string) (r *Root, err error) {
 
 	if r, err = c.unpackRoot(pk, rp); err != nil {
 		return
 	}
 
-	err = c.DB().Update(func(tx data.Tu) (_ error) {
-		roots := tx.Feeds().Roots(pk)
+	if r.Pub != pk {
+		err = fmt.Errorf("Root %s of another feed: %s", r.Short(),
+			pk.Hex()[:7])
+		return
+	}
+
+	if err = c.verifyRoot(r, rp); err != nil {
+		if err != ErrMissingCertificate {
+			return // invalid
+		}
+		err = nil // the Filler will check
+	}
+
+	var qerr error
+	if qerr = c.checkQuota(pk); qerr != nil && !IsQuotaError(qerr) {
+		return nil, qerr // DB error
+	}
+
+	err = c.DB().Update(func(tx data.Tu) (err error) {
+		feeds := tx.Feeds()
+		roots := feeds.Roots(pk)
 		if roots == nil {
 			return ErrNoSuchFeed
 		}
-		return roots.Add(rp)
+		if qerr != nil {
+			if roots.Get(rp.Seq) != nil {
+				return data.ErrRootAlreadyExists
+			}
+			return qerr
+		}
+		if err = roots.Add(rp); err != nil {
+			return
+		}
+		rm := roots.Meta(rp.Seq)
+		rm.Peer = peer
+		rm.Time = r.Time
+		if err = roots.SetMeta(rp.Seq, rm); err != nil {
+			return
+		}
+		if r.IsSuccession() {
+			if err = feeds.SetSuccessor(r.Pub, r.Successor); err != nil {
+				return
+			}
+		}
+		return saveRevoked(tx, r)
+	})
+	if err == nil && r.IsOwn() {
+		c.revoked.add(pk, r.Revoked)
+	}
+	return
+}
+
+// delNonFullRoot removes given Root from DB if it's stored
+// and not full. It's used to remove unverified Root objects
+func (c *Container) delNonFullRoot(r *Root) error {
+	return c.DB().Update(func(tx data.Tu) (_ error) {
+		roots := tx.Feeds().Roots(r.Pub)
+		if roots == nil {
+			return // feed removed
+		}
+		if rp := roots.Get(r.Seq); rp == nil || rp.Hash != r.Hash {
+			return // removed or replaced
+		}
+		if roots.Meta(r.Seq).IsFull {
+			return // verified
+		}
+		return roots.Del(r.Seq)
+	})
+}
+
+// name of end-user bucket of DB that keeps marker of
+// time index of Root objects (see loadRootTime)
+var rootTimeBucket = []byte("skyobject.time")
+
+// key of the marker, the index is built
+var rootTimeBuiltKey = []byte{'b'}
+
+// loadRootTime sets RootMeta.Time of Root objects that have
+// no the Time in metadata (DB upgraded from old version) using
+// Time of decoded Root. Thus, the Root objects are indexed by
+// time (see data.ViewRoots.SeqByTime). It's done once per DB
+func (c *Container) loadRootTime() error {
+	return c.DB().Update(func(tx data.Tu) (err error) {
+		bk := tx.Bucket(rootTimeBucket)
+		if bk.Get(rootTimeBuiltKey) != nil {
+			return // already built
+		}
+
+		feeds := tx.Feeds()
+
+		var pks []cipher.PubKey
+		err = feeds.Range(func(pk cipher.PubKey) (_ error) {
+			pks = append(pks, pk)
+			return
+		})
+		if err != nil {
+			return
+		}
+
+		for _, pk := range pks {
+			roots := feeds.Roots(pk)
+			times := make(map[uint64]int64) // seq -> time
+			err = roots.Range(func(rp *data.RootPack) (_ error) {
+				if rm := roots.Meta(rp.Seq); rm == nil || rm.Time != 0 {
+					return
+				}
+				r, err := c.unpackRoot(pk, rp)
+				if err != nil {
+					return // ignore malformed Root
+				}
+				if r.Time != 0 {
+					times[rp.Seq] = r.Time
+				}
+				return
+			})
+			if err != nil {
+				return
+			}
+			for seq, tm := range times {
+				rm := roots.Meta(seq)
+				rm.Time = tm
+				if err = roots.SetMeta(seq, rm); err != nil {
+					return
+				}
+			}
+		}
+
+		return bk.Set(rootTimeBuiltKey, []byte{1})
+	})
+}
+
+// IsSuccession returns true if the Root is succession Root
+// that names new public key of the feed (see Successor field)
+func (r *Root) IsSuccession() bool {
+	return r.Successor != (cipher.PubKey{}) && r.IsOwn()
+}
+
+// Successor returns new public key of given feed,
+// if the feed has been continued using another key
+func (c *Container) Successor(pk cipher.PubKey) (next cipher.PubKey,
+	ok bool) {
+
+	c.DB().View(func(tx data.Tv) (_ error) {
+		next, ok = tx.Feeds().Successor(pk)
+		return
+	})
+	return
+}
+
+// Predecessor returns previous public key of given
+// feed, if the feed continues another one
+func (c *Container) Predecessor(pk cipher.PubKey) (prev cipher.PubKey,
+	ok bool) {
+
+	c.DB().View(func(tx data.Tv) (_ error) {
+		prev, ok = tx.Feeds().Predecessor(pk)
+		return
 	})
 	return
 }
@@ -160,6 +396,49 @@ func (c *Container) MarkFull(r *Root) (err error) {
 		}
 		return roots.MarkFull(r.Seq)
 	})
+	if err == nil {
+		c.countSpace(r)
+	}
+	return
+}
+
+// RootMeta returns local metadata of Root
+// with given seq number of given feed
+func (c *Container) RootMeta(pk cipher.PubKey, seq uint64) (rm *data.RootMeta,
+	err error) {
+
+	err = c.DB().View(func(tx data.Tv) (_ error) {
+		if roots := tx.Feeds().Roots(pk); roots != nil {
+			rm = roots.Meta(seq)
+		}
+		return
+	})
+	if err == nil && rm == nil {
+		err = fmt.Errorf("root %d of %s not found", seq, pk.Hex()[:7])
+	}
+	return
+}
+
+// Pin or unpin Root with given seq number of given feed.
+// CleanUp never removes pinned Root objects and their
+// objects
+func (c *Container) Pin(pk cipher.PubKey, seq uint64, pin bool) (err error) {
+	err = c.DB().Update(func(tx data.Tu) (err error) {
+		roots := tx.Feeds().Roots(pk)
+		if roots == nil {
+			return ErrNoSuchFeed
+		}
+		rm := roots.Meta(seq)
+		if rm == nil {
+			return data.ErrNotFound
+		}
+		if pin {
+			rm.Flags |= data.RootPinned
+		} else {
+			rm.Flags &^= data.RootPinned
+		}
+		return roots.SetMeta(seq, rm)
+	})
 	return
 }
 
@@ -172,7 +451,9 @@ func (c *Container) RootBySeq(pk cipher.PubKey, seq uint64) (r *Root,
 	var rp *data.RootPack
 	err = c.DB().View(func(tx data.Tv) (_ error) {
 		if roots := tx.Feeds().Roots(pk); roots != nil {
-			rp = roots.Get(seq)
+			if rp = roots.Get(seq); rp != nil {
+				full = roots.Meta(seq).IsFull
+			}
 		}
 		return
 	})
@@ -183,7 +464,6 @@ func (c *Container) RootBySeq(pk cipher.PubKey, seq uint64) (r *Root,
 		err = fmt.Errorf("root %d of %s not found", seq, pk.Hex()[:7])
 		return
 	}
-	full = rp.IsFull
 	r, err = c.unpackRoot(pk, rp)
 	return
 }
@@ -0,0 +1,169 @@
+package skyobject
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestPack_SetSuccessor(t *testing.T) {
+
+	c := getCont()
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair() // old (leaked)
+	np, ns := cipher.GenerateKeyPair() // new
+
+	for _, feed := range []cipher.PubKey{pk, np} {
+		if err := c.AddFeed(feed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pack.SetSuccessor(pk); err == nil {
+		t.Error("missing error")
+	}
+	if err = pack.SetSuccessor(np); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	succ := pack.Root()
+
+	if !succ.IsSuccession() {
+		t.Error("not a succession Root")
+	}
+	if next, ok := c.Successor(pk); !ok || next != np {
+		t.Error("missing or wrong successor")
+	}
+	if prev, ok := c.Predecessor(np); !ok || prev != pk {
+		t.Error("missing or wrong predecessor")
+	}
+
+	// first Root of new feed
+	pack, err = c.NewRoot(np, ns, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	first := pack.Root()
+	if first.Predecessor != pk {
+		t.Error("missing predecessor")
+	}
+	if first.Origin != succ.Hash {
+		t.Error("missing link to succession Root")
+	}
+}
+
+func TestDecodeRoot_legacy(t *testing.T) {
+
+	c := getCont()
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	// Root encoded before delegation and succession
+	val := encoder.Serialize(&legacyRoot{
+		Reg:  c.CoreRegistry().Reference(),
+		Pub:  pk,
+		Time: time.Now().UnixNano(),
+	})
+
+	var rp data.RootPack
+	rp.Root = val
+	rp.Hash = cipher.SumSHA256(val)
+	rp.Sig = cipher.SignHash(rp.Hash, sk)
+
+	r, err := c.AddRoot(pk, &rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.IsLegacy() || !r.IsOwn() || r.Pub != pk {
+		t.Fatal("wrong Root:", r)
+	}
+	if !bytes.Equal(r.Encode(), val) {
+		t.Error("legacy Root re-encoded using current layout")
+	}
+
+	// next Root uses current layout
+	pack, err := c.Unpack(r, 0, c.CoreRegistry().Types(), sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if last, err := c.Last(pk); err != nil {
+		t.Fatal(err)
+	} else if last.IsLegacy() || last.Seq != 1 || last.Prev != rp.Hash {
+		t.Error("wrong Root:", last)
+	}
+
+}
+
+func TestContainer_loadRootTime(t *testing.T) {
+
+	c := getCont()
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	r := pack.Root()
+
+	// DB upgraded from old version has no time of the Root
+	err = c.DB().Update(func(tx data.Tu) (err error) {
+		roots := tx.Feeds().Roots(pk)
+		rm := roots.Meta(r.Seq)
+		rm.Time = 0
+		if err = roots.SetMeta(r.Seq, rm); err != nil {
+			return
+		}
+		return tx.DelBucket(rootTimeBucket)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.loadRootTime(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.DB().View(func(tx data.Tv) (_ error) {
+		roots := tx.Feeds().Roots(pk)
+		if rm := roots.Meta(r.Seq); rm.Time != r.Time {
+			t.Error("wrong time:", rm.Time)
+		}
+		if seq, ok := roots.SeqByTime(r.Time); !ok || seq != r.Seq {
+			t.Error("the Root is not indexed")
+		}
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+}
@@ -0,0 +1,350 @@
+package skyobject
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+// A FeedSpace represents space taken by objects of a feed.
+// Objects of a feed are all objects reachable from Root
+// objects of the feed kept in DB, including registries
+type FeedSpace struct {
+	Objects   int        `json:"objects"`   // amount of objects
+	Exclusive data.Space `json:"exclusive"` // used by the feed only
+	Shared    data.Space `json:"shared"`    // used by other feeds too
+}
+
+// Total space taken by objects of the feed
+func (f FeedSpace) Total() data.Space {
+	return f.Exclusive + f.Shared
+}
+
+// name of end-user bucket of DB that keeps the index of space
+var spaceBucket = []byte("skyobject.space")
+
+// keys of the space bucket
+//
+//  - {'f'} + feed   -> FeedSpace
+//  - {'o'} + object -> owners
+//  - {'b'}          -> the index is built
+//
+const (
+	spaceFeedPrefix   byte = 'f'
+	spaceObjectPrefix byte = 'o'
+)
+
+var spaceBuiltKey = []byte{'b'}
+
+func spaceFeedKey(pk cipher.PubKey) []byte {
+	return append([]byte{spaceFeedPrefix}, pk[:]...)
+}
+
+func spaceObjectKey(hash cipher.SHA256) []byte {
+	return append([]byte{spaceObjectPrefix}, hash[:]...)
+}
+
+func (f FeedSpace) encode() (b []byte) {
+	b = make([]byte, 24)
+	binary.BigEndian.PutUint64(b, uint64(f.Objects))
+	binary.BigEndian.PutUint64(b[8:], uint64(f.Exclusive))
+	binary.BigEndian.PutUint64(b[16:], uint64(f.Shared))
+	return
+}
+
+func decodeFeedSpace(b []byte) (f FeedSpace, err error) {
+	if len(b) != 24 {
+		err = fmt.Errorf("malformed space of feed, length %d", len(b))
+		return
+	}
+	f.Objects = int(binary.BigEndian.Uint64(b))
+	f.Exclusive = data.Space(binary.BigEndian.Uint64(b[8:]))
+	f.Shared = data.Space(binary.BigEndian.Uint64(b[16:]))
+	return
+}
+
+// owners of an object
+type objectOwners struct {
+	size  data.Space
+	feeds []cipher.PubKey // usually one or two
+}
+
+func (o *objectOwners) isOwnedBy(pk cipher.PubKey) bool {
+	for _, f := range o.feeds {
+		if f == pk {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *objectOwners) encode() (b []byte) {
+	b = make([]byte, 8, 8+len(o.feeds)*len(cipher.PubKey{}))
+	binary.BigEndian.PutUint64(b, uint64(o.size))
+	for _, pk := range o.feeds {
+		b = append(b, pk[:]...)
+	}
+	return
+}
+
+func decodeObjectOwners(b []byte) (o *objectOwners, err error) {
+	const pkl = len(cipher.PubKey{})
+	if len(b) < 8 || (len(b)-8)%pkl != 0 {
+		return nil, fmt.Errorf("malformed owners of object, length %d",
+			len(b))
+	}
+	o = new(objectOwners)
+	o.size = data.Space(binary.BigEndian.Uint64(b))
+	for b = b[8:]; len(b) > 0; b = b[pkl:] {
+		var pk cipher.PubKey
+		copy(pk[:], b)
+		o.feeds = append(o.feeds, pk)
+	}
+	return
+}
+
+// A space represents index of owners of objects. The index
+// is kept in DB (see spaceBucket). It's rebuilt by CleanUp and
+// updated when a Root becomes full. The space keeps only
+// objects and feeds touched; if the bk is not nil, then
+// missing objects and feeds are loaded from the bucket
+type space struct {
+	objs  map[cipher.SHA256]*objectOwners
+	feeds map[cipher.PubKey]*FeedSpace
+
+	bk data.ViewBucket // stored index (can be nil)
+}
+
+func (s *space) init() {
+	s.objs = make(map[cipher.SHA256]*objectOwners)
+	s.feeds = make(map[cipher.PubKey]*FeedSpace)
+}
+
+// owners of given object
+func (s *space) owners(hash cipher.SHA256) (oo *objectOwners, ok bool,
+	err error) {
+
+	if oo, ok = s.objs[hash]; ok || s.bk == nil {
+		return
+	}
+	var val []byte
+	if val = s.bk.Get(spaceObjectKey(hash)); val == nil {
+		return
+	}
+	if oo, err = decodeObjectOwners(val); err != nil {
+		return
+	}
+	s.objs[hash], ok = oo, true
+	return
+}
+
+// space of given feed
+func (s *space) feed(pk cipher.PubKey) (fs *FeedSpace, ok bool,
+	err error) {
+
+	if fs, ok = s.feeds[pk]; ok || s.bk == nil {
+		return
+	}
+	var val []byte
+	if val = s.bk.Get(spaceFeedKey(pk)); val == nil {
+		return
+	}
+	var f FeedSpace
+	if f, err = decodeFeedSpace(val); err != nil {
+		return
+	}
+	fs, ok = &f, true
+	s.feeds[pk] = fs
+	return
+}
+
+// own adds given feed to owners of given object. It returns
+// true if the object was not owned by the feed before. The
+// method is not thread-safe
+func (s *space) own(pk cipher.PubKey, hash cipher.SHA256,
+	size data.Space) (added bool, err error) {
+
+	var fs *FeedSpace
+	var ok bool
+	if fs, ok, err = s.feed(pk); err != nil {
+		return
+	} else if !ok {
+		fs = new(FeedSpace)
+		s.feeds[pk] = fs
+	}
+
+	var oo *objectOwners
+	if oo, ok, err = s.owners(hash); err != nil {
+		return
+	} else if !ok {
+		s.objs[hash] = &objectOwners{size, []cipher.PubKey{pk}}
+		fs.Objects++
+		fs.Exclusive += size
+		return true, nil
+	}
+
+	if oo.isOwnedBy(pk) {
+		return
+	}
+
+	if len(oo.feeds) == 1 {
+		// the object is not exclusive anymore
+		var prev *FeedSpace
+		if prev, ok, err = s.feed(oo.feeds[0]); err != nil {
+			return
+		} else if ok {
+			prev.Exclusive -= oo.size
+			prev.Shared += oo.size
+		}
+	}
+
+	oo.feeds = append(oo.feeds, pk)
+	fs.Objects++
+	fs.Shared += oo.size
+	return true, nil
+}
+
+// count objects of given Root using given getter; the
+// method walks only objects not owned by feed of the Root
+func (s *space) count(c *Container, r *Root, g getter) error {
+	return c.knowsAbout(r, g, func(hash cipher.SHA256) (_ bool, _ error) {
+		val := g.Get(hash)
+		if val == nil {
+			return // missing object
+		}
+		return s.own(r.Pub, hash, data.Space(len(val)))
+	})
+}
+
+// save touched objects and feeds to given bucket
+func (s *space) save(bk data.UpdateBucket) (err error) {
+	for hash, oo := range s.objs {
+		if err = bk.Set(spaceObjectKey(hash), oo.encode()); err != nil {
+			return
+		}
+	}
+	for pk, fs := range s.feeds {
+		if err = bk.Set(spaceFeedKey(pk), fs.encode()); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// store the space as new index replacing
+// stored one; the space must not use bucket
+func (s *space) store(tx data.Tu) (err error) {
+	if err = tx.DelBucket(spaceBucket); err != nil {
+		return
+	}
+	bk := tx.Bucket(spaceBucket)
+	if err = s.save(bk); err != nil {
+		return
+	}
+	return bk.Set(spaceBuiltKey, []byte{1})
+}
+
+// copy of statistic
+func (s *space) stat() (feeds map[cipher.PubKey]FeedSpace) {
+	if len(s.feeds) == 0 {
+		return // nil
+	}
+	feeds = make(map[cipher.PubKey]FeedSpace, len(s.feeds))
+	for pk, fs := range s.feeds {
+		feeds[pk] = *fs
+	}
+	return
+}
+
+// countSpace of given Root that becomes full
+func (c *Container) countSpace(r *Root) {
+	err := c.DB().Update(func(tx data.Tu) error {
+		return c.countSpaceTx(tx, r)
+	})
+	if err != nil {
+		c.Printf("[ERR] counting space of %s: %v", r.Short(), err)
+	}
+}
+
+// countSpaceTx counts space of given full Root using given transaction
+func (c *Container) countSpaceTx(tx data.Tu, r *Root) (err error) {
+	var sp space
+	sp.init()
+	bk := tx.Bucket(spaceBucket)
+	sp.bk = bk
+	if err = sp.count(c, r, tx.Objects()); err != nil {
+		return
+	}
+	return sp.save(bk)
+}
+
+// feedSpace returns space taken by objects of given feed
+func (c *Container) feedSpace(pk cipher.PubKey) (fs FeedSpace, err error) {
+	err = c.DB().View(func(tx data.Tv) (err error) {
+		if val := tx.Bucket(spaceBucket).Get(spaceFeedKey(pk)); val != nil {
+			fs, err = decodeFeedSpace(val)
+		}
+		return
+	})
+	return
+}
+
+// spaceStat returns space taken by objects of all feeds
+func (c *Container) spaceStat() (feeds map[cipher.PubKey]FeedSpace) {
+	err := c.DB().View(func(tx data.Tv) error {
+		bk := tx.Bucket(spaceBucket)
+		return tx.Feeds().Range(func(pk cipher.PubKey) (err error) {
+			var val []byte
+			if val = bk.Get(spaceFeedKey(pk)); val == nil {
+				return
+			}
+			var fs FeedSpace
+			if fs, err = decodeFeedSpace(val); err != nil {
+				return
+			}
+			if feeds == nil {
+				feeds = make(map[cipher.PubKey]FeedSpace)
+			}
+			feeds[pk] = fs
+			return
+		})
+	})
+	if err != nil {
+		c.Printf("[ERR] reading space of feeds: %v", err)
+	}
+	return
+}
+
+// loadSpace builds the index from all Root objects of DB
+// if the index is not built yet (new or old DB file)
+func (c *Container) loadSpace() error {
+	return c.DB().Update(func(tx data.Tu) (err error) {
+		if tx.Bucket(spaceBucket).Get(spaceBuiltKey) != nil {
+			return // already built
+		}
+
+		var sp space
+		sp.init()
+
+		feeds, objs := tx.Feeds(), tx.Objects()
+		err = feeds.Range(func(pk cipher.PubKey) error {
+			return feeds.Roots(pk).Range(func(rp *data.RootPack) (_ error) {
+				r, err := c.unpackRoot(pk, rp)
+				if err != nil {
+					return // ignore malformed Root
+				}
+				if err = sp.count(c, r, objs); err != nil {
+					c.Printf("[ERR] counting space of %s: %v", r.Short(), err)
+				}
+				return
+			})
+		})
+		if err != nil {
+			return
+		}
+		return sp.store(tx)
+	})
+}
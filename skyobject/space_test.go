@@ -0,0 +1,136 @@
+package skyobject
+
+import (
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func Test_space_own(t *testing.T) {
+
+	var sp space
+	sp.init()
+
+	a, _ := cipher.GenerateKeyPair()
+	b, _ := cipher.GenerateKeyPair()
+
+	one := cipher.SumSHA256([]byte("one"))
+	two := cipher.SumSHA256([]byte("two"))
+
+	own := func(pk cipher.PubKey, hash cipher.SHA256,
+		size data.Space) bool {
+
+		added, err := sp.own(pk, hash, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return added
+	}
+
+	if !own(a, one, 10) || !own(a, two, 20) {
+		t.Fatal("not added")
+	}
+	if own(a, one, 10) {
+		t.Error("owned twice")
+	}
+	if !own(b, one, 10) {
+		t.Error("not added")
+	}
+
+	feeds := sp.stat()
+
+	if fs := feeds[a]; fs.Objects != 2 || fs.Exclusive != 20 ||
+		fs.Shared != 10 {
+
+		t.Errorf("wrong space of a: %+v", fs)
+	}
+	if fs := feeds[b]; fs.Objects != 1 || fs.Exclusive != 0 ||
+		fs.Shared != 10 {
+
+		t.Errorf("wrong space of b: %+v", fs)
+	}
+}
+
+func TestContainer_Stat(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	a, ask := cipher.GenerateKeyPair()
+	b, bsk := cipher.GenerateKeyPair()
+
+	for _, pk := range []cipher.PubKey{a, b} {
+		if err := c.AddFeed(pk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alice := &User{Name: "Alice", Age: 21}
+
+	for _, f := range []struct {
+		pk cipher.PubKey
+		sk cipher.SecKey
+		u  *User
+	}{
+		{a, ask, &User{Name: "Bob", Age: 32}},
+		{b, bsk, &User{Name: "Eva", Age: 23}},
+	} {
+		pack, err := c.NewRoot(f.pk, f.sk, 0, c.CoreRegistry().Types())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pack.Append(alice, f.u)
+		if _, err = pack.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(t *testing.T) {
+		feeds := c.Stat().Feeds
+		for _, pk := range []cipher.PubKey{a, b} {
+			fs, ok := feeds[pk]
+			if !ok {
+				t.Error("missing feed")
+				continue
+			}
+			// registry, Alice, and Bob or Eva
+			if fs.Objects != 3 {
+				t.Error("wrong amount of objects:", fs.Objects)
+			}
+			if fs.Exclusive == 0 || fs.Shared == 0 {
+				t.Errorf("wrong space: %+v", fs)
+			}
+		}
+	}
+
+	t.Run("save", check)
+
+	if err := c.CleanUp(false); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("clean up", check)
+
+	// DB file without the index
+	err := c.DB().Update(func(tx data.Tu) error {
+		return tx.DelBucket(spaceBucket)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Stat().Feeds != nil {
+		t.Error("unexpected space")
+	}
+	if err = c.loadSpace(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("load", check)
+
+}
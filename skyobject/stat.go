@@ -3,6 +3,8 @@ package skyobject
 import (
 	"sync"
 	"time"
+
+	"github.com/skycoin/skycoin/src/cipher"
 )
 
 // A Stat represents Container statistic
@@ -10,6 +12,16 @@ type Stat struct {
 	Registries int           // amount of unpacked registries
 	Save       time.Duration // avg time of pack.Save() call
 	CleanUp    time.Duration // avg time of c.CleanUp() call
+
+	// incremental CleanUp (see Config.CleanUpObjects)
+
+	CleanUpPause    time.Duration   // avg time of a slice
+	CleanUpMaxPause time.Duration   // max time of a slice
+	CleanUpProgress CleanUpProgress // current cycle
+
+	// Feeds is space taken by objects of feeds,
+	// it's nil if there are no feeds
+	Feeds map[cipher.PubKey]FeedSpace
 }
 
 // rolling average of duration
@@ -24,12 +36,17 @@ type stat struct {
 	Save    time.Duration // avg time of pack.Save() call
 	CleanUp time.Duration // avg time of c.CleanUp() call
 
+	CleanUpPause    time.Duration   // avg time of a slice
+	CleanUpMaxPause time.Duration   // max time of a slice
+	CleanUpProgress CleanUpProgress // current cycle
+
 	//
 	// rolling averages
 	//
 
 	packSave rollavg // avg time of pack.Save() call
 	cleanUp  rollavg // avg time of c.CleanUp() call (GC)
+	pause    rollavg // avg time of a slice of incremental CleanUp
 }
 
 func (s *stat) init(samples int) {
@@ -38,6 +55,7 @@ func (s *stat) init(samples int) {
 	}
 	s.packSave = rolling(samples)
 	s.cleanUp = rolling(samples)
+	s.pause = rolling(samples)
 }
 
 func (s *stat) addPackSave(dur time.Duration) {
@@ -54,6 +72,17 @@ func (s *stat) addCleanUp(dur time.Duration) {
 	s.CleanUp = s.cleanUp(dur)
 }
 
+func (s *stat) addCleanUpSlice(dur time.Duration, cp CleanUpProgress) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.CleanUpPause = s.pause(dur)
+	if dur > s.CleanUpMaxPause {
+		s.CleanUpMaxPause = dur
+	}
+	s.CleanUpProgress = cp
+}
+
 func (s *stat) addRegistry(delta int) {
 	s.mx.Lock()
 	defer s.mx.Unlock()
@@ -92,10 +121,15 @@ func (s *stat) Stat() (cp Stat) {
 	cp.Registries = s.Registries
 	cp.Save = s.Save
 	cp.CleanUp = s.CleanUp
+	cp.CleanUpPause = s.CleanUpPause
+	cp.CleanUpMaxPause = s.CleanUpMaxPause
+	cp.CleanUpProgress = s.CleanUpProgress
 	return
 }
 
 // Stat of Container
-func (c *Container) Stat() Stat {
-	return c.stat.Stat()
+func (c *Container) Stat() (s Stat) {
+	s = c.stat.Stat()
+	s.Feeds = c.spaceStat()
+	return
 }
@@ -0,0 +1,742 @@
+package skyobject
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+)
+
+// TreeJSON returns JSON representation of objects tree of given Root.
+// The JSON looks like
+//
+//     {
+//         "pub": "<public key>",
+//         "seq": 0,
+//         "hash": "<hash of the Root>",
+//         "registry": "<registry reference>",
+//         "refs": [
+//             {"schema": "cxo.User", "hash": "<hash>", "value": {...}}
+//         ]
+//     }
+//
+// Values of Root.Refs are always included. Structures are represented
+// as JSON objects with fields in order of Schema, []byte and [N]byte
+// as hex strings. A nil reference is null. A Ref is {"hash": "<hash>"},
+// a Refs is {"hash": "<hash>", "degree": 16, "items": [...]} where
+// items are Ref, and a Dynamic is {"schema": "<name>", "hash":
+// "<hash>"}. A string that is not valid UTF-8 is {"hex": "<bytes>"}
+// and a NaN or an infinite float is {"hex": "<encoded float>"}, since
+// JSON can't represent them as is. If the inline is true then values
+// of all references are included as "value" field. All objects to
+// show must exist
+func (c *Container) TreeJSON(r *Root, inline bool) (tree []byte,
+	err error) {
+
+	c.Debugln(VerbosePin, "TreeJSON", r.Short(), inline)
+
+	tw := treeWriter{c: c, inline: inline}
+	if tw.reg, err = c.FindRegistry(r.Reg); err != nil {
+		return
+	}
+
+	fmt.Fprintf(&tw.b, `{"pub":%q,"seq":%d,"hash":%q,"registry":%q,`,
+		r.Pub.Hex(), r.Seq, r.Hash.Hex(), r.Reg.String())
+	tw.b.WriteString(`"refs":[`)
+	for i, dr := range r.Refs {
+		if i > 0 {
+			tw.b.WriteByte(',')
+		}
+		if err = tw.dynamic(dr, true); err != nil {
+			return
+		}
+	}
+	tw.b.WriteString("]}")
+
+	var ind bytes.Buffer
+	if err = json.Indent(&ind, tw.b.Bytes(), "", "  "); err != nil {
+		return
+	}
+	tree = ind.Bytes()
+	return
+}
+
+type treeWriter struct {
+	c      *Container
+	reg    *Registry
+	inline bool
+
+	b bytes.Buffer
+}
+
+func (t *treeWriter) dynamic(dr Dynamic, value bool) (err error) {
+	if dr.IsBlank() {
+		t.b.WriteString("null")
+		return
+	}
+	if !dr.IsValid() {
+		return ErrInvalidDynamicReference
+	}
+	var sch Schema
+	if sch, err = t.reg.SchemaByReference(dr.SchemaRef); err != nil {
+		return
+	}
+	fmt.Fprintf(&t.b, `{"schema":%q`, sch.Name())
+	if dr.Object != (cipher.SHA256{}) {
+		t.b.WriteByte(',')
+		if err = t.hash(sch, dr.Object, value); err != nil {
+			return
+		}
+	}
+	t.b.WriteByte('}')
+	return
+}
+
+// "hash" and "value" fields of a reference
+func (t *treeWriter) hash(sch Schema, hash cipher.SHA256,
+	value bool) (err error) {
+
+	fmt.Fprintf(&t.b, `"hash":%q`, hash.Hex())
+	if !value {
+		return
+	}
+	val := t.c.Get(hash)
+	if val == nil {
+		return fmt.Errorf("missing object [%s]", hash.Hex()[:7])
+	}
+	t.b.WriteString(`,"value":`)
+	var n int
+	if n, err = t.value(sch, val); err == nil && n != len(val) {
+		err = fmt.Errorf("malformed object [%s] of <%s>", hash.Hex()[:7],
+			sch.String())
+	}
+	return
+}
+
+func (t *treeWriter) ref(sch Schema, hash cipher.SHA256) (err error) {
+	if hash == (cipher.SHA256{}) {
+		t.b.WriteString("null")
+		return
+	}
+	t.b.WriteByte('{')
+	if err = t.hash(sch, hash, t.inline); err != nil {
+		return
+	}
+	t.b.WriteByte('}')
+	return
+}
+
+func (t *treeWriter) refs(sch Schema, hash cipher.SHA256) (err error) {
+	if hash == (cipher.SHA256{}) {
+		t.b.WriteString("null")
+		return
+	}
+	var er encodedRefs
+	if er, err = t.refsNode(hash); err != nil {
+		return
+	}
+	fmt.Fprintf(&t.b, `{"hash":%q,"degree":%d,"items":[`, hash.Hex(),
+		er.Degree)
+	first := true
+	if err = t.refsItems(sch, er, &first); err != nil {
+		return
+	}
+	t.b.WriteString("]}")
+	return
+}
+
+func (t *treeWriter) refsNode(hash cipher.SHA256) (er encodedRefs,
+	err error) {
+
+	val := t.c.Get(hash)
+	if val == nil {
+		err = fmt.Errorf("missing object [%s]", hash.Hex()[:7])
+		return
+	}
+	err = encoder.DeserializeRaw(val, &er)
+	return
+}
+
+func (t *treeWriter) refsItems(sch Schema, er encodedRefs,
+	first *bool) (err error) {
+
+	for _, hash := range er.Nested {
+		if hash == (cipher.SHA256{}) {
+			continue // removed
+		}
+		if er.Depth > 0 {
+			var ner encodedRefs
+			if ner, err = t.refsNode(hash); err != nil {
+				return
+			}
+			if err = t.refsItems(sch, ner, first); err != nil {
+				return
+			}
+			continue
+		}
+		if !*first {
+			t.b.WriteByte(',')
+		}
+		*first = false
+		if err = t.ref(sch, hash); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// value writes JSON of given encoded value returning
+// number of bytes used by the value
+func (t *treeWriter) value(sch Schema, p []byte) (n int, err error) {
+	if n, err = SchemaSize(sch, p); err != nil {
+		return
+	}
+	p = p[:n]
+
+	if sch.IsReference() {
+		switch sch.ReferenceType() {
+		case ReferenceTypeSingle:
+			var ref Ref
+			if err = encoder.DeserializeRaw(p, &ref); err == nil {
+				err = t.ref(sch.Elem(), ref.Hash)
+			}
+		case ReferenceTypeSlice:
+			var refs Refs
+			if err = encoder.DeserializeRaw(p, &refs); err == nil {
+				err = t.refs(sch.Elem(), refs.Hash)
+			}
+		case ReferenceTypeDynamic:
+			var dr Dynamic
+			if err = encoder.DeserializeRaw(p, &dr); err == nil {
+				err = t.dynamic(dr, t.inline)
+			}
+		default:
+			err = ErrInvalidSchema
+		}
+		return
+	}
+
+	switch sch.Kind() {
+	case reflect.Array, reflect.Slice:
+		err = t.list(sch, p)
+	case reflect.Struct:
+		err = t.fields(sch, p)
+	default:
+		var x interface{}
+		if x, err = flatValue(sch.Kind()); err != nil {
+			return
+		}
+		if err = encoder.DeserializeRaw(p, x); err != nil {
+			return
+		}
+		if !jsonFlat(x) {
+			fmt.Fprintf(&t.b, "{\"hex\":%q}",
+				hex.EncodeToString(hexFlat(x, p)))
+			return
+		}
+		var js []byte
+		if js, err = json.Marshal(x); err != nil {
+			return
+		}
+		t.b.Write(js)
+	}
+	return
+}
+
+// array or slice
+func (t *treeWriter) list(sch Schema, p []byte) (err error) {
+	el := sch.Elem()
+	ln := sch.Len()
+	if sch.Kind() == reflect.Slice {
+		if ln, err = getLength(p); err != nil {
+			return
+		}
+		p = p[4:]
+	}
+	if isBytes(el) {
+		fmt.Fprintf(&t.b, "%q", hex.EncodeToString(p))
+		return
+	}
+	t.b.WriteByte('[')
+	var n int
+	for i := 0; i < ln; i++ {
+		if i > 0 {
+			t.b.WriteByte(',')
+		}
+		if n, err = t.value(el, p); err != nil {
+			return
+		}
+		p = p[n:]
+	}
+	t.b.WriteByte(']')
+	return
+}
+
+func (t *treeWriter) fields(sch Schema, p []byte) (err error) {
+	t.b.WriteByte('{')
+	var n int
+	for i, f := range sch.Fields() {
+		if i > 0 {
+			t.b.WriteByte(',')
+		}
+		fmt.Fprintf(&t.b, "%q:", f.Name())
+		if n, err = t.value(f.Schema(), p); err != nil {
+			return
+		}
+		p = p[n:]
+	}
+	t.b.WriteByte('}')
+	return
+}
+
+// elements of []byte and [N]byte are shown as hex string
+func isBytes(el Schema) bool {
+	return !el.IsReference() && el.Kind() == reflect.Uint8
+}
+
+// jsonFlat reports whether given flat value (pointer) can be
+// represented by JSON string or number losslessly
+func jsonFlat(x interface{}) bool {
+	switch v := x.(type) {
+	case *string:
+		return utf8.ValidString(*v)
+	case *float32:
+		f := float64(*v)
+		return !math.IsNaN(f) && !math.IsInf(f, 0)
+	case *float64:
+		return !math.IsNaN(*v) && !math.IsInf(*v, 0)
+	}
+	return true
+}
+
+// hexFlat returns bytes of {"hex": ...} of given flat value (pointer)
+// and its encoded form: bytes of a string or encoded float
+func hexFlat(x interface{}, p []byte) []byte {
+	if s, ok := x.(*string); ok {
+		return []byte(*s)
+	}
+	return p
+}
+
+// flatValue returns pointer to zero value of given flat kind
+func flatValue(kind reflect.Kind) (x interface{}, err error) {
+	switch kind {
+	case reflect.Bool:
+		x = new(bool)
+	case reflect.Int8:
+		x = new(int8)
+	case reflect.Uint8:
+		x = new(uint8)
+	case reflect.Int16:
+		x = new(int16)
+	case reflect.Uint16:
+		x = new(uint16)
+	case reflect.Int32:
+		x = new(int32)
+	case reflect.Uint32:
+		x = new(uint32)
+	case reflect.Int64:
+		x = new(int64)
+	case reflect.Uint64:
+		x = new(uint64)
+	case reflect.Float32:
+		x = new(float32)
+	case reflect.Float64:
+		x = new(float64)
+	case reflect.String:
+		x = new(string)
+	default:
+		err = ErrInvalidSchema
+	}
+	return
+}
+
+// RootFromJSON creates and saves new Root of given feed using JSON
+// created by TreeJSON and given Registry. The Registry is added to
+// the Container. Fields "pub", "seq", "hash" and "registry" of the
+// JSON are ignored. If a reference has "value" field, then the value
+// is saved and the "hash" field is ignored. Otherwise object with the
+// "hash" must exist. Thus, it's possible to edit values of a JSON
+// created with inlined values, or to refer to existing objects
+func (c *Container) RootFromJSON(pk cipher.PubKey, sk cipher.SecKey,
+	reg *Registry, tree []byte) (r *Root, err error) {
+
+	c.Debugln(VerbosePin, "RootFromJSON", pk.Hex()[:7],
+		reg.Reference().Short())
+
+	var jt struct {
+		Refs []interface{} `json:"refs"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(tree))
+	dec.UseNumber()
+	if err = dec.Decode(&jt); err != nil {
+		return
+	}
+
+	if err = c.AddRegistry(reg); err != nil {
+		return
+	}
+
+	types := &Types{
+		Direct:  make(map[string]reflect.Type),
+		Inverse: make(map[reflect.Type]string),
+	}
+
+	tr := treeReader{reg: reg}
+	if tr.p, err = c.NewRootReg(pk, sk, reg.Reference(), 0,
+		types); err != nil {
+
+		return
+	}
+
+	for _, v := range jt.Refs {
+		var dr Dynamic
+		if dr, err = tr.dynamic(v); err != nil {
+			return
+		}
+		tr.p.r.Refs = append(tr.p.r.Refs, dr)
+	}
+
+	if _, err = tr.p.Save(); err != nil {
+		return
+	}
+	r = tr.p.Root()
+	return
+}
+
+type treeReader struct {
+	p   *Pack
+	reg *Registry
+}
+
+func invalidJSONError(sch Schema, v interface{}) error {
+	return fmt.Errorf("invalid JSON value for <%s>: %v", sch.String(), v)
+}
+
+func (t *treeReader) dynamic(v interface{}) (dr Dynamic, err error) {
+	if v == nil {
+		return // blank
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		err = fmt.Errorf("invalid JSON value for Dynamic: %v", v)
+		return
+	}
+	name, ok := m["schema"].(string)
+	if !ok {
+		err = fmt.Errorf("missing schema of Dynamic: %v", v)
+		return
+	}
+	var sch Schema
+	if sch, err = t.reg.SchemaByName(name); err != nil {
+		return
+	}
+	dr.SchemaRef = sch.Reference()
+	dr.Object, err = t.hash(sch, m)
+	return
+}
+
+// hash of a reference from its "value" or "hash" fields
+func (t *treeReader) hash(sch Schema,
+	m map[string]interface{}) (hash cipher.SHA256, err error) {
+
+	if v, ok := m["value"]; ok {
+		var val []byte
+		if val, err = t.value(sch, v); err != nil {
+			return
+		}
+		hash = t.p.add(val)
+		return
+	}
+	hs, ok := m["hash"].(string)
+	if !ok || hs == "" {
+		return // nil
+	}
+	if hash, err = cipher.SHA256FromHex(hs); err != nil {
+		return
+	}
+	_, err = t.p.get(hash) // must exist
+	return
+}
+
+func (t *treeReader) ref(sch Schema, v interface{}) (hash cipher.SHA256,
+	err error) {
+
+	if v == nil {
+		return // blank
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		err = fmt.Errorf("invalid JSON value for Ref of <%s>: %v",
+			sch.String(), v)
+		return
+	}
+	return t.hash(sch, m)
+}
+
+func (t *treeReader) refs(sch Schema, v interface{}) (hash cipher.SHA256,
+	err error) {
+
+	if v == nil {
+		return // blank
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		err = fmt.Errorf("invalid JSON value for Refs of <%s>: %v",
+			sch.String(), v)
+		return
+	}
+
+	degree := t.p.c.conf.MerkleDegree
+	if dn, ok := m["degree"].(json.Number); ok {
+		var d int64
+		if d, err = dn.Int64(); err != nil {
+			return
+		}
+		if d < 2 {
+			err = fmt.Errorf("invalid degree of Refs of <%s>: %d",
+				sch.String(), d)
+			return
+		}
+		degree = int(d)
+	}
+
+	items, _ := m["items"].([]interface{})
+	hashes := make([]cipher.SHA256, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			err = errors.New("nil item of Refs")
+			return
+		}
+		var ih cipher.SHA256
+		if ih, err = t.ref(sch, item); err != nil {
+			return
+		}
+		hashes = append(hashes, ih)
+	}
+
+	var depth int
+	for pow(degree, depth+1) < len(hashes) {
+		depth++
+	}
+	hash = t.refsNode(hashes, depth, degree)
+	return
+}
+
+// refsNode saves node of Refs the same way the Refs.Append does
+func (t *treeReader) refsNode(hashes []cipher.SHA256,
+	depth, degree int) cipher.SHA256 {
+
+	var er encodedRefs
+
+	er.Depth = uint32(depth)
+	er.Degree = uint32(degree)
+	er.Length = uint32(len(hashes))
+
+	if depth == 0 {
+		er.Nested = hashes
+	} else {
+		size := pow(degree, depth) // items per branch
+		for i := 0; i < len(hashes); i += size {
+			j := i + size
+			if j > len(hashes) {
+				j = len(hashes)
+			}
+			er.Nested = append(er.Nested,
+				t.refsNode(hashes[i:j], depth-1, degree))
+		}
+	}
+
+	return t.p.add(encoder.Serialize(er))
+}
+
+// value encodes given JSON value
+func (t *treeReader) value(sch Schema, v interface{}) (val []byte,
+	err error) {
+
+	if sch.IsReference() {
+		switch sch.ReferenceType() {
+		case ReferenceTypeSingle:
+			var ref Ref
+			if ref.Hash, err = t.ref(sch.Elem(), v); err == nil {
+				val = encoder.Serialize(ref)
+			}
+		case ReferenceTypeSlice:
+			var refs Refs
+			if refs.Hash, err = t.refs(sch.Elem(), v); err == nil {
+				val = encoder.Serialize(refs)
+			}
+		case ReferenceTypeDynamic:
+			var dr Dynamic
+			if dr, err = t.dynamic(v); err == nil {
+				val = encoder.Serialize(dr)
+			}
+		default:
+			err = ErrInvalidSchema
+		}
+		return
+	}
+
+	switch sch.Kind() {
+	case reflect.Array, reflect.Slice:
+		return t.list(sch, v)
+	case reflect.Struct:
+		return t.fields(sch, v)
+	case reflect.Bool:
+		x, ok := v.(bool)
+		if !ok {
+			return nil, invalidJSONError(sch, v)
+		}
+		return encoder.Serialize(x), nil
+	case reflect.String:
+		if m, ok := v.(map[string]interface{}); ok {
+			var p []byte
+			if p, err = hexJSON(sch, m); err != nil {
+				return
+			}
+			return encoder.Serialize(string(p)), nil
+		}
+		x, ok := v.(string)
+		if !ok {
+			return nil, invalidJSONError(sch, v)
+		}
+		return encoder.Serialize(x), nil
+	case reflect.Float32, reflect.Float64:
+		if m, ok := v.(map[string]interface{}); ok {
+			if val, err = hexJSON(sch, m); err != nil {
+				return
+			}
+			if len(val) != fixedSize(sch.Kind()) {
+				return nil, invalidJSONError(sch, v)
+			}
+			return
+		}
+	}
+
+	num, ok := v.(json.Number)
+	if !ok {
+		return nil, invalidJSONError(sch, v)
+	}
+	var x interface{}
+	switch bits := fixedSize(sch.Kind()) * 8; sch.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(num.String(), 10, bits); err != nil {
+			return
+		}
+		x = reflect.ValueOf(i).Convert(flatType(sch.Kind())).Interface()
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(num.String(), 10, bits); err != nil {
+			return
+		}
+		x = reflect.ValueOf(u).Convert(flatType(sch.Kind())).Interface()
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(num.String(), bits); err != nil {
+			return
+		}
+		x = reflect.ValueOf(f).Convert(flatType(sch.Kind())).Interface()
+	default:
+		return nil, ErrInvalidSchema
+	}
+	return encoder.Serialize(x), nil
+}
+
+// hexJSON decodes {"hex": ...} of a string or a float
+func hexJSON(sch Schema, m map[string]interface{}) (p []byte, err error) {
+	s, ok := m["hex"].(string)
+	if !ok || len(m) != 1 {
+		return nil, invalidJSONError(sch, m)
+	}
+	return hex.DecodeString(s)
+}
+
+// reflect.Type of given flat kind
+func flatType(kind reflect.Kind) reflect.Type {
+	x, _ := flatValue(kind)
+	return reflect.TypeOf(x).Elem()
+}
+
+// array or slice
+func (t *treeReader) list(sch Schema, v interface{}) (val []byte,
+	err error) {
+
+	el := sch.Elem()
+
+	var ln int
+	var items []interface{}
+	if isBytes(el) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, invalidJSONError(sch, v)
+		}
+		if val, err = hex.DecodeString(s); err != nil {
+			return
+		}
+		ln = len(val)
+	} else {
+		var ok bool
+		if items, ok = v.([]interface{}); !ok {
+			return nil, invalidJSONError(sch, v)
+		}
+		ln = len(items)
+	}
+
+	if sch.Kind() == reflect.Array && ln != sch.Len() {
+		return nil, fmt.Errorf("wrong length of <%s>: %d", sch.String(), ln)
+	}
+
+	var b bytes.Buffer
+	if sch.Kind() == reflect.Slice {
+		b.Write(encoder.Serialize(uint32(ln)))
+	}
+	if items == nil {
+		b.Write(val)
+		return b.Bytes(), nil
+	}
+	for _, item := range items {
+		var ev []byte
+		if ev, err = t.value(el, item); err != nil {
+			return
+		}
+		b.Write(ev)
+	}
+	return b.Bytes(), nil
+}
+
+func (t *treeReader) fields(sch Schema, v interface{}) (val []byte,
+	err error) {
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, invalidJSONError(sch, v)
+	}
+	fs := sch.Fields()
+	if len(m) != len(fs) {
+		return nil, fmt.Errorf("wrong number of fields of <%s>: %d",
+			sch.String(), len(m))
+	}
+	var b bytes.Buffer
+	for _, f := range fs {
+		fv, ok := m[f.Name()]
+		if !ok {
+			return nil, fmt.Errorf("missing field %s of <%s>", f.Name(),
+				sch.String())
+		}
+		var ev []byte
+		if ev, err = t.value(f.Schema(), fv); err != nil {
+			return
+		}
+		b.Write(ev)
+	}
+	return b.Bytes(), nil
+}
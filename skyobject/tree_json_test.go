@@ -0,0 +1,230 @@
+package skyobject
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+
+	"github.com/skycoin/cxo/data"
+)
+
+func TestContainer_TreeJSON(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+	conf.MerkleDegree = 2 // deep Refs
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pack.Append(
+		&Group{
+			Name:   "Mates",
+			Leader: pack.Ref(&User{Name: "Alice", Age: 21}),
+			Members: pack.Refs(
+				&User{Name: "Eva", Age: 20},
+				&User{Name: "Ammy", Age: 19},
+				&User{Name: "Kate", Age: 22},
+				&User{Name: "Jane", Age: 18},
+				&User{Name: "Mia", Age: 23},
+			),
+			Curator: pack.Dynamic(&Developer{Name: "Bob", GitHub: "bob"}),
+		},
+		&User{Name: "Eva", Age: 20},
+	)
+	pack.r.Refs = append(pack.r.Refs, Dynamic{}) // nil
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	root := pack.Root()
+
+	// references only
+	tree, err := c.TreeJSON(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(tree), "Alice") ||
+		!strings.Contains(string(tree), `"Name": "Mates"`) {
+
+		t.Errorf("wrong JSON:\n%s", tree)
+	}
+
+	// other feed, references to existing objects
+	pk2, sk2 := cipher.GenerateKeyPair()
+	if err = c.AddFeed(pk2); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(c *Container, tree []byte) {
+		r, err := c.RootFromJSON(pk2, sk2, c.CoreRegistry(), tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Refs) != len(root.Refs) {
+			t.Fatal("wrong number of Root.Refs:", len(r.Refs))
+		}
+		for i := range r.Refs {
+			if !r.Refs[i].Eq(&root.Refs[i]) {
+				t.Errorf("different Dynamic %d: %s, %s", i, r.Refs[i].Short(),
+					root.Refs[i].Short())
+			}
+		}
+	}
+
+	check(c, tree)
+
+	// inline
+	if tree, err = c.TreeJSON(root, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Mia", "Bob"} {
+		if !strings.Contains(string(tree), name) {
+			t.Errorf("missing %s in JSON:\n%s", name, tree)
+		}
+	}
+
+	// new container, the values must be saved
+	nc := NewContainer(data.NewMemoryDB(), conf)
+	defer nc.Close()
+	if err = nc.AddFeed(pk2); err != nil {
+		t.Fatal(err)
+	}
+	check(nc, tree)
+
+	last, err := nc.Last(pk2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree2, err := nc.TreeJSON(last, true); err != nil {
+		t.Fatal(err)
+	} else if !sameTreeValues(t, tree, tree2) {
+		t.Errorf("different trees:\n%s\n%s", tree, tree2)
+	}
+
+	// edit value
+	edited := strings.Replace(string(tree), `"Alice"`, `"Alisa"`, 1)
+	r, err := nc.RootFromJSON(pk2, sk2, nc.CoreRegistry(), []byte(edited))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Refs[0].Eq(&root.Refs[0]) {
+		t.Error("value is not changed")
+	}
+
+	// missing field
+	broken := strings.Replace(string(tree), `"Age": 21`, `"Aged": 21`, 1)
+	_, err = nc.RootFromJSON(pk2, sk2, nc.CoreRegistry(), []byte(broken))
+	if err == nil {
+		t.Error("missing error")
+	}
+
+}
+
+type treeFlat struct {
+	Name string
+	F32  float32
+	F64  float64
+}
+
+func TestContainer_TreeJSON_lossless(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = NewRegistry(func(r *Reg) {
+		r.Register("cxo.Flat", treeFlat{})
+	})
+	conf.CleanUp = 0
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nan := math.Float64frombits(0x7ff8000000000001) // with payload
+	pack.Append(
+		&treeFlat{Name: "\xff\xfeinvalid", F32: float32(math.NaN()), F64: nan},
+		&treeFlat{Name: "valid", F32: float32(math.Inf(1)),
+			F64: math.Inf(-1)},
+	)
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+	root := pack.Root()
+
+	tree, err := c.TreeJSON(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tree), `"hex": "fffe696e76616c6964"`) {
+		t.Errorf("invalid UTF-8 string is not hex:\n%s", tree)
+	}
+
+	nc := NewContainer(data.NewMemoryDB(), conf)
+	defer nc.Close()
+	if err = nc.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+	r, err := nc.RootFromJSON(pk, sk, nc.CoreRegistry(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Refs) != len(root.Refs) {
+		t.Fatal("wrong number of Root.Refs:", len(r.Refs))
+	}
+	for i := range r.Refs {
+		if !r.Refs[i].Eq(&root.Refs[i]) {
+			t.Errorf("different Dynamic %d: %s, %s", i, r.Refs[i].Short(),
+				root.Refs[i].Short())
+		}
+	}
+
+	// wrong size of encoded float
+	broken := strings.Replace(string(tree), `"F64": {
+          "hex": "`, `"F64": {
+          "hex": "00`, 1)
+	if broken == string(tree) {
+		t.Fatalf("unexpected JSON:\n%s", tree)
+	}
+	_, err = nc.RootFromJSON(pk, sk, nc.CoreRegistry(), []byte(broken))
+	if err == nil {
+		t.Error("missing error")
+	}
+
+}
+
+// compare JSON trees except fields of Root
+func sameTreeValues(t *testing.T, a, b []byte) bool {
+	var x, y map[string]interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatal(err)
+	}
+	xs, _ := json.Marshal(x["refs"])
+	ys, _ := json.Marshal(y["refs"])
+	return string(xs) == string(ys)
+}
@@ -137,6 +137,12 @@ func (p *Pack) Save() (root data.RootPack, err error) {
 	// single transaction required (to perform rollback on error)
 	err = p.c.DB().Update(func(tx data.Tu) (err error) {
 
+		// validate objects before signing
+
+		if err = p.validate(tx.Objects()); err != nil {
+			return
+		}
+
 		// save Root
 
 		roots := tx.Feeds().Roots(p.r.Pub)
@@ -155,6 +161,12 @@ func (p *Pack) Save() (root data.RootPack, err error) {
 		p.r.Seq = seq
 		p.r.Time = time.Now().UnixNano()
 		p.r.Prev = prev
+		p.r.legacy = false // new Root uses current layout
+
+		// link to previous feed (if the feed is successor)
+		if seq == 0 {
+			p.setOrigin(tx.Feeds())
+		}
 
 		val := p.r.Encode()
 
@@ -164,7 +176,6 @@ func (p *Pack) Save() (root data.RootPack, err error) {
 		var rp data.RootPack
 
 		rp.Hash = p.r.Hash
-		rp.IsFull = true
 		rp.Prev = p.r.Prev
 		rp.Root = val
 		rp.Seq = p.r.Seq
@@ -173,12 +184,30 @@ func (p *Pack) Save() (root data.RootPack, err error) {
 		if err = roots.Add(&rp); err != nil {
 			return
 		}
+		rm := roots.Meta(rp.Seq)
+		rm.IsFull = true
+		rm.Time = p.r.Time
+		if err = roots.SetMeta(rp.Seq, rm); err != nil {
+			return
+		}
+		if p.r.IsSuccession() {
+			if err = tx.Feeds().SetSuccessor(p.r.Pub, p.r.Successor); err != nil {
+				return
+			}
+		}
+		if err = saveRevoked(tx, p.r); err != nil {
+			return
+		}
 		// save objects
 		return tx.Objects().SetMap(p.unsaved)
 	})
 
 	if err == nil {
 		p.unsaved = make(map[cipher.SHA256][]byte) // clear
+		p.c.countSpace(p.r)
+		if p.r.IsOwn() {
+			p.c.revoked.add(p.r.Pub, p.r.Revoked)
+		}
 	}
 
 	st := time.Now().Sub(tp)
@@ -189,6 +218,37 @@ func (p *Pack) Save() (root data.RootPack, err error) {
 	return
 }
 
+// SetSuccessor makes next Root to be succession Root that names new
+// public key of the feed. Subscribers of the feed will follow to the
+// new key. The Pack must be created with secret key of owner of the
+// feed. Use it if secret key of the feed leaked or to rotate keys
+func (p *Pack) SetSuccessor(next cipher.PubKey) (err error) {
+	if !p.r.IsOwn() {
+		return fmt.Errorf("only owner of feed %s can set successor",
+			p.r.Pub.Hex()[:7])
+	}
+	if next == p.r.Pub {
+		return ErrInvalidArgument
+	}
+	p.r.Successor = next
+	return
+}
+
+// setOrigin links first Root of a feed with
+// succession Root of previous feed if any
+func (p *Pack) setOrigin(feeds data.UpdateFeeds) {
+	prev, ok := feeds.Predecessor(p.r.Pub)
+	if !ok {
+		return
+	}
+	p.r.Predecessor = prev
+	if roots := feeds.Roots(prev); roots != nil {
+		if last := roots.Last(); last != nil {
+			p.r.Origin = last.Hash
+		}
+	}
+}
+
 // Initialize the Pack. It creates Root WalkNode and
 // unpack entire tree if appropriate flag is set
 func (p *Pack) init() (err error) {
@@ -0,0 +1,88 @@
+package skyobject
+
+import (
+	"fmt"
+
+	"github.com/skycoin/skycoin/src/cipher"
+)
+
+// A Validator is a hook that validates content of an object
+// of a registered type. The val is encoded object, use
+// encoder.DeserializeRaw to decode it. The Validator
+// must return non-nil error if the object violates
+// rules of an application
+type Validator func(sch Schema, hash cipher.SHA256, val []byte) error
+
+// A Validators maps registered names of schemas to Validator
+type Validators map[string]Validator
+
+// A ValidationError represents error returned by Validator
+type ValidationError struct {
+	Schema string        // name of schema
+	Hash   cipher.SHA256 // hash of the object
+	Err    error         // error returned by Validator
+}
+
+// Error implements error interface
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("invalid object <%s> [%s]: %v",
+		v.Schema,
+		v.Hash.Hex()[:7],
+		v.Err)
+}
+
+// validate an object using validator of given schema if any
+func (c *Container) validate(sch Schema, hash cipher.SHA256,
+	val []byte) (err error) {
+
+	if len(c.conf.Validators) == 0 || !sch.IsRegistered() {
+		return
+	}
+	var vf Validator
+	if vf = c.conf.Validators[sch.Name()]; vf == nil {
+		return
+	}
+	if err = vf(sch, hash, val); err != nil {
+		err = &ValidationError{sch.Name(), hash, err}
+	}
+	return
+}
+
+// packGetter gets objects from unsaved objects
+// of a Pack or from given getter
+type packGetter struct {
+	p    *Pack
+	objs getter
+}
+
+func (g *packGetter) Get(key cipher.SHA256) []byte {
+	if val, ok := g.p.unsaved[key]; ok {
+		return val
+	}
+	return g.objs.Get(key)
+}
+
+// validate unsaved objects of the Pack using Validators of Container;
+// given getter is used to get objects already saved, saved objects
+// are already validated and are not inspected
+func (p *Pack) validate(objs getter) (err error) {
+	if len(p.c.conf.Validators) == 0 {
+		return
+	}
+
+	var kn knowsAbout
+	kn.fn = func(hash cipher.SHA256) (deeper bool, _ error) {
+		_, deeper = p.unsaved[hash]
+		return
+	}
+	kn.g = &packGetter{p, objs}
+	kn.reg = p.reg
+	kn.vfn = p.c.validate
+
+	for _, dr := range p.r.Refs {
+		if err = kn.Dynamic(dr); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,160 @@
+package skyobject
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+
+	"github.com/skycoin/cxo/data"
+)
+
+var errTooOld = errors.New("too old")
+
+// validateUser rejects users older then 100
+func validateUser(_ Schema, _ cipher.SHA256, val []byte) (err error) {
+	var usr User
+	if err = encoder.DeserializeRaw(val, &usr); err != nil {
+		return
+	}
+	if usr.Age > 100 {
+		return errTooOld
+	}
+	return
+}
+
+func TestPack_validate(t *testing.T) {
+
+	var calls int // calls of validator
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+	conf.Validators = Validators{
+		"cxo.User": func(sch Schema, hash cipher.SHA256, val []byte) error {
+			calls++
+			return validateUser(sch, hash, val)
+		},
+	}
+
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+	if err := c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := c.NewRoot(pk, sk, 0, c.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		pack.Append(&User{Name: "Alice", Age: 21})
+		if _, err := pack.Save(); err != nil {
+			t.Error(err)
+		}
+		if calls != 1 {
+			t.Error("wrong number of validations:", calls)
+		}
+	})
+
+	t.Run("saved", func(t *testing.T) {
+		calls = 0
+		pack.Append(&User{Name: "Eva", Age: 20})
+		if _, err := pack.Save(); err != nil {
+			t.Error(err)
+		}
+		if calls != 1 {
+			t.Error("saved objects validated again:", calls)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		pack.Append(&Group{
+			Name:   "Old Boys",
+			Leader: pack.Ref(&User{Name: "Methuselah", Age: 969}),
+		})
+		_, err := pack.Save()
+		if ve, ok := err.(*ValidationError); !ok {
+			t.Error("missing or wrong error:", err)
+		} else if ve.Schema != "cxo.User" || ve.Err != errTooOld {
+			t.Error("wrong error:", ve)
+		}
+	})
+
+}
+
+func TestFiller_validate(t *testing.T) {
+
+	conf := NewConfig()
+	conf.Registry = getRegisty()
+	conf.CleanUp = 0
+
+	// source without validators
+	src := NewContainer(data.NewMemoryDB(), conf)
+	defer src.Close()
+
+	pk, sk := cipher.GenerateKeyPair()
+	if err := src.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := src.NewRoot(pk, sk, 0, src.CoreRegistry().Types())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack.Append(&Group{
+		Name:   "Old Boys",
+		Leader: pack.Ref(&User{Name: "Methuselah", Age: 969}),
+	})
+	if _, err = pack.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// destination with validators
+	conf.Validators = Validators{"cxo.User": validateUser}
+	c := NewContainer(data.NewMemoryDB(), conf)
+	defer c.Close()
+
+	if err = c.AddFeed(pk); err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.AddRoot(pk, pack.Root().Pack())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantq := make(chan WCXO)
+	fullq := make(chan *Root, 1)
+	dropq := make(chan DropRootError, 1)
+
+	var wg sync.WaitGroup
+	fl := c.NewFiller(r, wantq, fullq, dropq, nil, &wg)
+	defer wg.Wait()
+	defer fl.Close()
+
+	for {
+		select {
+		case wc := <-wantq:
+			val := src.Get(wc.Hash)
+			if val == nil {
+				t.Fatal("request of unknown object:", wc.Hash.Hex()[:7])
+			}
+			wc.GotQ <- val
+		case <-fullq:
+			t.Fatal("invalid Root filled")
+		case drop := <-dropq:
+			if ve, ok := drop.Err.(*ValidationError); !ok {
+				t.Fatal("missing or wrong error:", drop.Err)
+			} else if ve.Schema != "cxo.User" || ve.Err != errTooOld {
+				t.Fatal("wrong error:", ve)
+			}
+			return
+		}
+	}
+
+}
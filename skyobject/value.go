@@ -117,6 +117,10 @@ func schemaArraySliceSize(el Schema, l, shift int, p []byte) (n int,
 	n += shift
 
 	if s := fixedSize(el.Kind()); s > 0 {
+		if l > (len(p)-n)/s {
+			err = ErrInvalidSchemaOrData // malformed length
+			return
+		}
 		n += l * s
 	} else {
 		var m int